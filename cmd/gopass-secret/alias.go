@@ -0,0 +1,110 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/godbus/dbus/v5"
+
+	dbustypes "github.com/nikicat/gopass-secret-service/internal/dbus"
+)
+
+const aliasUsage = "Usage: gopass-secret alias <list|set|rm> [args]\n"
+
+func runAlias(args []string) {
+	if len(args) < 1 {
+		fmt.Fprint(os.Stderr, aliasUsage)
+		os.Exit(1)
+	}
+
+	switch args[0] {
+	case "list", "ls":
+		runAliasList(args[1:])
+	case "set":
+		runAliasSet(args[1:])
+	case "rm":
+		runAliasRm(args[1:])
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown alias subcommand: %s\n", args[0])
+		fmt.Fprint(os.Stderr, aliasUsage)
+		os.Exit(1)
+	}
+}
+
+func runAliasList(args []string) {
+	fs := flag.NewFlagSet("alias list", flag.ExitOnError)
+	mustParse(fs, args)
+
+	conn, err := dbus.SessionBus()
+	if err != nil {
+		log.Fatalf("Failed to connect to session bus: %v", err)
+	}
+	defer conn.Close()
+
+	var aliases map[string]dbus.ObjectPath
+	svc := conn.Object(dbustypes.ServiceName, dbustypes.ServicePath)
+	if err := svc.Call(dbustypes.GopassSecretInterface+".ListAliases", 0).Store(&aliases); err != nil {
+		log.Fatalf("Failed to list aliases: %v", err)
+	}
+
+	names := make([]string, 0, len(aliases))
+	for name := range aliases {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ALIAS\tCOLLECTION")
+	for _, name := range names {
+		coll, err := dbustypes.ParseCollectionPath(aliases[name])
+		if err != nil {
+			coll = string(aliases[name])
+		}
+		fmt.Fprintf(w, "%s\t%s\n", name, coll)
+	}
+	w.Flush()
+}
+
+func runAliasSet(args []string) {
+	fs := flag.NewFlagSet("alias set", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: gopass-secret alias set <alias> <collection>\n")
+	}
+	mustParse(fs, args)
+	if fs.NArg() != 2 {
+		fs.Usage()
+		os.Exit(1)
+	}
+	setAlias(fs.Arg(0), dbustypes.CollectionPath(fs.Arg(1)))
+}
+
+func runAliasRm(args []string) {
+	fs := flag.NewFlagSet("alias rm", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: gopass-secret alias rm <alias>\n")
+	}
+	mustParse(fs, args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(1)
+	}
+	setAlias(fs.Arg(0), "/")
+}
+
+// setAlias points alias at collection; "/" removes it.
+func setAlias(alias string, collection dbus.ObjectPath) {
+	conn, err := dbus.SessionBus()
+	if err != nil {
+		log.Fatalf("Failed to connect to session bus: %v", err)
+	}
+	defer conn.Close()
+
+	svc := conn.Object(dbustypes.ServiceName, dbustypes.ServicePath)
+	if err := svc.Call(dbustypes.SecretServiceInterface+".SetAlias", 0, alias, collection).Err; err != nil {
+		log.Fatalf("Failed to set alias %s: %v", alias, err)
+	}
+}
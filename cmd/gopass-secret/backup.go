@@ -0,0 +1,153 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/nikicat/gopass-secret-service/internal/backup"
+	"github.com/nikicat/gopass-secret-service/internal/service"
+	"github.com/nikicat/gopass-secret-service/internal/store"
+)
+
+// stringsFlag collects the values of a repeatable flag.
+type stringsFlag []string
+
+func (f *stringsFlag) String() string { return "" }
+
+func (f *stringsFlag) Set(v string) error {
+	*f = append(*f, v)
+	return nil
+}
+
+// conditionsFlag collects -where conditions for the local store.
+type conditionsFlag []store.Condition
+
+func (f *conditionsFlag) String() string { return "" }
+
+func (f *conditionsFlag) Set(v string) error {
+	c, err := store.ParseCondition(v)
+	if err != nil {
+		return err
+	}
+	*f = append(*f, c)
+	return nil
+}
+
+func runExport(args []string) {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	var flags commonFlags
+	addCommonFlags(fs, &flags)
+	output := fs.String("o", "", "Write the archive to this file instead of stdout")
+	var recipients, collections, schemas stringsFlag
+	var where conditionsFlag
+	fs.Var(&recipients, "r", "Encrypt to an age recipient (age1...) or a GPG key (repeatable)")
+	fs.Var(&collections, "collection", "Only export this collection (repeatable)")
+	fs.Var(&schemas, "schema", "Only export items with this xdg:schema (repeatable)")
+	fs.Var(&where, "where", "Only export items matching a condition (repeatable), as in list")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: gopass-secret export -r <recipient> [options]\n\n")
+		fmt.Fprintf(os.Stderr, "Writes an encrypted archive of collections, aliases and items that\n")
+		fmt.Fprintf(os.Stderr, "'gopass-secret import' restores.\n\n")
+		fs.PrintDefaults()
+	}
+	mustParse(fs, args)
+	if len(recipients) == 0 || fs.NArg() > 0 {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	q, err := store.CompileQuery(where)
+	if err != nil {
+		log.Fatalf("Invalid condition: %v", err)
+	}
+	cfg, err := flags.loadConfig()
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+	ctx := context.Background()
+	s, err := service.OpenStore(ctx, cfg)
+	if err != nil {
+		log.Fatalf("Failed to open store: %v", err)
+	}
+
+	a, err := backup.Export(ctx, s, backup.Filter{Collections: collections, Schemas: schemas, Query: q})
+	if err != nil {
+		log.Fatalf("Failed to export: %v", err)
+	}
+
+	out := os.Stdout
+	if *output != "" {
+		if out, err = os.OpenFile(*output, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600); err != nil {
+			log.Fatalf("Failed to create archive: %v", err)
+		}
+	}
+	err = backup.Write(out, a, recipients)
+	a.Wipe()
+	if err != nil {
+		log.Fatalf("Failed to write archive: %v", err)
+	}
+	if err := out.Close(); err != nil {
+		log.Fatalf("Failed to write archive: %v", err)
+	}
+
+	n := 0
+	for _, c := range a.Collections {
+		n += len(c.Items)
+	}
+	fmt.Fprintf(os.Stderr, "Exported %d items from %d collections\n", n, len(a.Collections))
+}
+
+func runImportArchive(args []string) {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	var flags commonFlags
+	addCommonFlags(fs, &flags)
+	var identities stringsFlag
+	fs.Var(&identities, "i", "Age identity file to decrypt the archive with (repeatable)")
+	policyName := fs.String("conflict", string(backup.PolicySkip), "What to do with items already in the store: skip, overwrite or keep-both")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: gopass-secret import [options] <archive>\n")
+		fmt.Fprintf(os.Stderr, "       gopass-secret import gnome-keyring [options] <file.keyring>...\n\n")
+		fmt.Fprintf(os.Stderr, "Restores an archive written by 'gopass-secret export'. An item is already\n")
+		fmt.Fprintf(os.Stderr, "in the store when its collection has one with the same attributes.\n\n")
+		fs.PrintDefaults()
+	}
+	mustParse(fs, args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(1)
+	}
+	policy, err := backup.ParsePolicy(*policyName)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	f, err := os.Open(fs.Arg(0))
+	if err != nil {
+		log.Fatalf("Failed to open archive: %v", err)
+	}
+	defer f.Close()
+	a, err := backup.Read(f, identities)
+	if err != nil {
+		log.Fatalf("Failed to read archive: %v", err)
+	}
+
+	cfg, err := flags.loadConfig()
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+	ctx := context.Background()
+	s, err := service.OpenStore(ctx, cfg)
+	if err != nil {
+		log.Fatalf("Failed to open store: %v", err)
+	}
+
+	res, err := backup.Restore(ctx, s, a, policy)
+	a.Wipe()
+	fmt.Printf("Created %d items, overwrote %d, skipped %d\n", res.Created, res.Overwritten, res.Skipped)
+	if err != nil {
+		log.Fatalf("Failed to import: %v", err)
+	}
+}
@@ -41,7 +41,9 @@ func runConfigShow(args []string) {
 	}
 
 	// Validate and warn
-	if _, err := os.Stat(cfg.StorePath); os.IsNotExist(err) {
+	if cfg.StorePath == "" {
+		fmt.Fprintf(os.Stderr, "Note: store_path is empty, using the root store from the gopass config\n")
+	} else if _, err := os.Stat(cfg.StorePath); os.IsNotExist(err) {
 		fmt.Fprintf(os.Stderr, "Warning: store path does not exist: %s\n", cfg.StorePath)
 	}
 	switch cfg.LogLevel {
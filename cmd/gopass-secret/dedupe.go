@@ -0,0 +1,139 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/godbus/dbus/v5"
+
+	dbustypes "github.com/nikicat/gopass-secret-service/internal/dbus"
+)
+
+func runDedupe(args []string) {
+	fs := flag.NewFlagSet("dedupe", flag.ExitOnError)
+	interactive := fs.Bool("i", false, "Ask which item of each group to keep")
+	dryRun := fs.Bool("n", false, "Only show duplicates, don't delete anything")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: gopass-secret dedupe [-i] [-n] [collection]\n\n")
+		fmt.Fprintf(os.Stderr, "Finds items with exactly the same attributes and deletes all but the newest\n")
+		fmt.Fprintf(os.Stderr, "(or, with -i, the chosen) item of each group.\n\n")
+		fs.PrintDefaults()
+	}
+	mustParse(fs, args)
+	if fs.NArg() > 1 {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	conn, err := dbus.SessionBus()
+	if err != nil {
+		log.Fatalf("Failed to connect to session bus: %v", err)
+	}
+	defer conn.Close()
+
+	svc := conn.Object(dbustypes.ServiceName, dbustypes.ServicePath)
+
+	var groups []dbustypes.DuplicateGroup
+	if err := svc.Call(dbustypes.GopassSecretInterface+".FindDuplicates", 0, fs.Arg(0)).Store(&groups); err != nil {
+		log.Fatalf("Failed to find duplicates: %v", err)
+	}
+	if len(groups) == 0 {
+		fmt.Println("No duplicates found")
+		return
+	}
+
+	stdin := bufio.NewReader(os.Stdin)
+	failed := false
+	for _, g := range groups {
+		printDuplicateGroup(g)
+		if *dryRun {
+			continue
+		}
+
+		keep := g.Items[0].Path
+		if *interactive {
+			choice, ok := chooseDuplicate(stdin, len(g.Items))
+			if !ok {
+				fmt.Println("Skipped")
+				fmt.Println()
+				continue
+			}
+			keep = g.Items[choice].Path
+		}
+
+		var removed []dbus.ObjectPath
+		if err := svc.Call(dbustypes.GopassSecretInterface+".RemoveDuplicates", 0, keep).Store(&removed); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to remove duplicates of %s: %v\n", itemRef(keep), err)
+			failed = true
+			continue
+		}
+		fmt.Printf("Kept %s, deleted %d\n\n", itemRef(keep), len(removed))
+	}
+
+	if failed {
+		os.Exit(1)
+	}
+}
+
+func printDuplicateGroup(g dbustypes.DuplicateGroup) {
+	keys := make([]string, 0, len(g.Attributes))
+	for k := range g.Attributes {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	attrs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		attrs = append(attrs, k+"="+g.Attributes[k])
+	}
+	values := "same value"
+	if g.ValuesDiffer {
+		values = "values differ"
+	}
+	fmt.Printf("%s (%s)\n", strings.Join(attrs, " "), values)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "  #\tITEM\tLABEL\tCREATED\tMODIFIED")
+	for i, item := range g.Items {
+		fmt.Fprintf(w, "  %d\t%s\t%s\t%s\t%s\n", i+1, itemRef(item.Path), truncate(item.Label, defaultMaxWidth),
+			formatUnix(item.Created), formatUnix(item.Modified))
+	}
+	w.Flush()
+}
+
+// chooseDuplicate asks which of n items to keep and returns its index, or
+// false to skip the group. The default is the first, newest, item.
+func chooseDuplicate(r *bufio.Reader, n int) (int, bool) {
+	for {
+		fmt.Printf("Keep [1-%d, s to skip] (1): ", n)
+		line, err := r.ReadString('\n')
+		if err != nil && line == "" {
+			return 0, false
+		}
+		line = strings.TrimSpace(line)
+		switch line {
+		case "":
+			return 0, true
+		case "s":
+			return 0, false
+		}
+		if i, err := strconv.Atoi(line); err == nil && i >= 1 && i <= n {
+			return i - 1, true
+		}
+	}
+}
+
+// itemRef formats an item path as collection/id, as accepted by history.
+func itemRef(path dbus.ObjectPath) string {
+	coll, id, err := dbustypes.ParseItemPath(path)
+	if err != nil {
+		return string(path)
+	}
+	return coll + "/" + id
+}
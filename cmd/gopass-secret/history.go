@@ -0,0 +1,119 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+
+	dbustypes "github.com/nikicat/gopass-secret-service/internal/dbus"
+)
+
+func runHistory(args []string) {
+	fs := flag.NewFlagSet("history", flag.ExitOnError)
+	show := fs.String("show", "", "Print the secret at this revision instead of listing revisions")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: gopass-secret history [-show REVISION] <collection/id>\n\n")
+		fs.PrintDefaults()
+	}
+	mustParse(fs, args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(1)
+	}
+	itemPath := parseItemRef(fs.Arg(0))
+
+	conn, err := dbus.SessionBus()
+	if err != nil {
+		log.Fatalf("Failed to connect to session bus: %v", err)
+	}
+	defer conn.Close()
+
+	item := conn.Object(dbustypes.ServiceName, itemPath)
+
+	if *show != "" {
+		sessionPath := openPlainSession(conn)
+		defer conn.Object(dbustypes.ServiceName, sessionPath).Call(dbustypes.SessionInterface+".Close", 0)
+
+		var secret dbustypes.Secret
+		if err := item.Call(dbustypes.GopassSecretItemInterface+".GetSecretAt", 0, *show, sessionPath).Store(&secret); err != nil {
+			log.Fatalf("Failed to read revision %s: %v", *show, err)
+		}
+		os.Stdout.Write(secret.Value)
+		return
+	}
+
+	var revs []dbustypes.Revision
+	if err := item.Call(dbustypes.GopassSecretItemInterface+".Revisions", 0).Store(&revs); err != nil {
+		log.Fatalf("Failed to list revisions: %v", err)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "REVISION\tDATE\tMESSAGE")
+	for _, r := range revs {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", r.ID, time.Unix(r.Time, 0).Format(time.DateTime), r.Message)
+	}
+	w.Flush()
+}
+
+func runRestore(args []string) {
+	fs := flag.NewFlagSet("restore", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: gopass-secret restore <collection/id> <revision>\n")
+	}
+	mustParse(fs, args)
+	if fs.NArg() != 2 {
+		fs.Usage()
+		os.Exit(1)
+	}
+	itemPath := parseItemRef(fs.Arg(0))
+	revision := fs.Arg(1)
+
+	conn, err := dbus.SessionBus()
+	if err != nil {
+		log.Fatalf("Failed to connect to session bus: %v", err)
+	}
+	defer conn.Close()
+
+	item := conn.Object(dbustypes.ServiceName, itemPath)
+	if err := item.Call(dbustypes.GopassSecretItemInterface+".Restore", 0, revision).Err; err != nil {
+		log.Fatalf("Failed to restore %s: %v", revision, err)
+	}
+	fmt.Printf("Restored %s to revision %s\n", fs.Arg(0), revision)
+}
+
+// parseItemRef accepts an item as "collection/id" (as shown by list) or as
+// its D-Bus object path.
+func parseItemRef(ref string) dbus.ObjectPath {
+	if strings.HasPrefix(ref, "/") {
+		p := dbus.ObjectPath(ref)
+		if _, _, err := dbustypes.ParseItemPath(p); err != nil {
+			log.Fatalf("Invalid item path %s: %v", ref, err)
+		}
+		return p
+	}
+	coll, id, ok := strings.Cut(ref, "/")
+	if !ok || coll == "" || id == "" {
+		log.Fatalf("Invalid item %q (expected collection/id)", ref)
+	}
+	return dbustypes.ItemPath(coll, id)
+}
+
+// openPlainSession opens a Secret Service session with the plain algorithm.
+func openPlainSession(conn *dbus.Conn) dbus.ObjectPath {
+	var output dbus.Variant
+	var sessionPath dbus.ObjectPath
+	err := conn.Object(dbustypes.ServiceName, dbustypes.ServicePath).Call(
+		dbustypes.SecretServiceInterface+".OpenSession", 0,
+		dbustypes.AlgorithmPlain, dbus.MakeVariant(""),
+	).Store(&output, &sessionPath)
+	if err != nil {
+		log.Fatalf("Failed to open session: %v", err)
+	}
+	return sessionPath
+}
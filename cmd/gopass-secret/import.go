@@ -0,0 +1,157 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"golang.org/x/term"
+
+	"github.com/nikicat/gopass-secret-service/internal/gnomekeyring"
+	"github.com/nikicat/gopass-secret-service/internal/service"
+	"github.com/nikicat/gopass-secret-service/internal/store"
+)
+
+func runImport(args []string) {
+	if len(args) > 0 && args[0] == "gnome-keyring" {
+		runImportGnomeKeyring(args[1:])
+		return
+	}
+	runImportArchive(args)
+}
+
+func runImportGnomeKeyring(args []string) {
+	fs := flag.NewFlagSet("import gnome-keyring", flag.ExitOnError)
+	var flags commonFlags
+	addCommonFlags(fs, &flags)
+	collection := fs.String("collection", "", "Collection to import into (default: named after the file, login goes to default)")
+	dryRun := fs.Bool("n", false, "Only list what would be imported")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: gopass-secret import gnome-keyring [options] <file.keyring>...\n\n")
+		fmt.Fprintf(os.Stderr, "Imports the items of GNOME Keyring files, usually found in\n")
+		fmt.Fprintf(os.Stderr, "~/.local/share/keyrings, asking for each keyring's password.\n\n")
+		fs.PrintDefaults()
+	}
+	mustParse(fs, args)
+	if fs.NArg() == 0 || (*collection != "" && fs.NArg() > 1) {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	ctx := context.Background()
+	var s store.Store
+	if !*dryRun {
+		cfg, err := flags.loadConfig()
+		if err != nil {
+			log.Fatalf("Failed to load config: %v", err)
+		}
+		if s, err = service.OpenStore(ctx, cfg); err != nil {
+			log.Fatalf("Failed to open store: %v", err)
+		}
+	}
+
+	failed := false
+	for _, path := range fs.Args() {
+		name := *collection
+		if name == "" {
+			name = keyringCollection(path)
+		}
+		kr, err := readKeyring(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to read %s: %v\n", path, err)
+			failed = true
+			continue
+		}
+		if *dryRun {
+			printKeyring(kr, name)
+			continue
+		}
+		imported, skipped, err := gnomekeyring.Import(ctx, s, name, kr)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to import %s: %v\n", path, err)
+			fmt.Printf("Partially imported %s into %s: %d items before the error (%d already there)\n", path, name, imported, skipped)
+			failed = true
+			continue
+		}
+		fmt.Printf("Imported %d items from %s into %s (%d already there)\n", imported, path, name, skipped)
+	}
+
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// keyringCollection names the collection a keyring file is imported into:
+// its file name, except that the login keyring, which GNOME Keyring serves
+// as the default collection, goes to default.
+func keyringCollection(path string) string {
+	name := strings.TrimSuffix(filepath.Base(path), ".keyring")
+	if name == "login" {
+		return "default"
+	}
+	return store.SanitizeName(name)
+}
+
+// readKeyring asks for the password of the keyring at path until it
+// decrypts. An empty password is tried first, as keyrings left unprotected
+// have one.
+func readKeyring(path string) (*gnomekeyring.Keyring, error) {
+	kr, err := gnomekeyring.ReadFile(path, nil)
+	if !errors.Is(err, gnomekeyring.ErrBadPassword) {
+		return kr, err
+	}
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return nil, errors.New("keyring is password protected and stdin is not a terminal")
+	}
+	for range 3 {
+		fmt.Fprintf(os.Stderr, "Password for %s: ", filepath.Base(path))
+		password, err := term.ReadPassword(int(os.Stdin.Fd()))
+		fmt.Fprintln(os.Stderr) // newline after hidden input
+		if err != nil {
+			return nil, err
+		}
+		kr, err = gnomekeyring.ReadFile(path, password)
+		if !errors.Is(err, gnomekeyring.ErrBadPassword) {
+			return kr, err
+		}
+		fmt.Fprintln(os.Stderr, "Incorrect password")
+	}
+	return nil, gnomekeyring.ErrBadPassword
+}
+
+func printKeyring(kr *gnomekeyring.Keyring, collection string) {
+	fmt.Printf("%s -> %s (%d items)\n", kr.Name, collection, len(kr.Items))
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "  LABEL\tCREATED\tMODIFIED\tATTRIBUTES")
+	for _, item := range kr.Items {
+		keys := make([]string, 0, len(item.Attributes))
+		for k := range item.Attributes {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		attrs := make([]string, 0, len(keys))
+		for _, k := range keys {
+			attrs = append(attrs, k+"="+item.Attributes[k])
+		}
+		fmt.Fprintf(w, "  %s\t%s\t%s\t%s\n", truncate(item.Label, defaultMaxWidth),
+			formatTime(item.Created), formatTime(item.Modified), strings.Join(attrs, " "))
+	}
+	w.Flush()
+	fmt.Println()
+}
+
+// formatTime formats a keyring time, where the zero time means unknown.
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return "unknown"
+	}
+	return t.Format(time.DateTime)
+}
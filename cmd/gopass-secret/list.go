@@ -7,11 +7,13 @@ import (
 	"os"
 	"sort"
 	"text/tabwriter"
+	"time"
 	"unicode/utf8"
 
 	"github.com/godbus/dbus/v5"
 
 	dbustypes "github.com/nikicat/gopass-secret-service/internal/dbus"
+	"github.com/nikicat/gopass-secret-service/internal/store"
 )
 
 const defaultMaxWidth = 30
@@ -19,6 +21,9 @@ const defaultMaxWidth = 30
 func runList(args []string) {
 	fs := flag.NewFlagSet("list", flag.ExitOnError)
 	maxWidth := fs.Int("max-width", defaultMaxWidth, "Max attribute value width (0 = unlimited)")
+	var where whereFlag
+	fs.Var(&where, "where", "Only list items matching a condition (repeatable): key=value, key^=prefix,\n"+
+		"key~=glob, key=~regex, key@=host, key? (has key) or !key (lacks key)")
 	mustParse(fs, args)
 
 	var filterCollection string
@@ -34,111 +39,102 @@ func runList(args []string) {
 
 	svc := conn.Object(dbustypes.ServiceName, dbustypes.ServicePath)
 
-	// Get collection paths from the Collections property
-	variant, err := svc.GetProperty(dbustypes.SecretServiceInterface + ".Collections")
-	if err != nil {
-		log.Fatalf("Failed to get collections: %v", err)
+	// Every collection and item with its properties, in one call
+	var objects map[dbus.ObjectPath]map[string]map[string]dbus.Variant
+	if err := svc.Call(dbustypes.ObjectManagerInterface+".GetManagedObjects", 0).Store(&objects); err != nil {
+		log.Fatalf("Failed to list objects: %v", err)
 	}
 
-	collPaths, ok := variant.Value().([]dbus.ObjectPath)
-	if !ok {
-		log.Fatalf("Unexpected Collections property type: %T", variant.Value())
+	// With conditions, the daemon picks the items and we only list them.
+	var matched map[dbus.ObjectPath]bool
+	if len(where) > 0 {
+		var unlocked, locked []dbus.ObjectPath
+		if err := svc.Call(dbustypes.GopassSecretInterface+".SearchQuery", 0, filterCollection, []dbustypes.Condition(where)).Store(&unlocked, &locked); err != nil {
+			log.Fatalf("Failed to search: %v", err)
+		}
+		matched = make(map[dbus.ObjectPath]bool)
+		for _, p := range append(unlocked, locked...) {
+			matched[p] = true
+		}
 	}
 
 	type row struct {
 		collection string
 		id         string
 		label      string
+		expires    uint64
 		attrs      map[string]string
 	}
 
 	var rows []row
 	attrKeys := make(map[string]bool)
+	anyExpires := false
+	collectionFound := false
 
-	for _, collPath := range collPaths {
-		collName, err := dbustypes.ParseCollectionPath(collPath)
-		if err != nil {
-			log.Printf("Warning: invalid collection path %s: %v", collPath, err)
+	for path, ifaces := range objects {
+		if _, ok := ifaces[dbustypes.CollectionInterface]; ok {
+			name, err := dbustypes.ParseCollectionPath(path)
+			collectionFound = collectionFound || err == nil && name == filterCollection
 			continue
 		}
-
-		if filterCollection != "" && collName != filterCollection {
+		allProps, ok := ifaces[dbustypes.ItemInterface]
+		if !ok {
 			continue
 		}
-
-		collObj := conn.Object(dbustypes.ServiceName, collPath)
-
-		// Get item paths from the collection's Items property
-		itemsVariant, err := collObj.GetProperty(dbustypes.CollectionInterface + ".Items")
+		if matched != nil && !matched[path] {
+			continue
+		}
+		collName, itemID, err := dbustypes.ParseItemPath(path)
 		if err != nil {
-			log.Printf("Warning: failed to get items for %s: %v", collName, err)
+			log.Printf("Warning: invalid item path %s: %v", path, err)
 			continue
 		}
-
-		itemPaths, ok := itemsVariant.Value().([]dbus.ObjectPath)
-		if !ok {
-			log.Printf("Warning: unexpected Items property type for %s: %T", collName, itemsVariant.Value())
+		if filterCollection != "" && collName != filterCollection {
 			continue
 		}
 
-		for _, itemPath := range itemPaths {
-			_, itemID, err := dbustypes.ParseItemPath(itemPath)
-			if err != nil {
-				log.Printf("Warning: invalid item path %s: %v", itemPath, err)
-				continue
-			}
-
-			itemObj := conn.Object(dbustypes.ServiceName, itemPath)
-
-			// Get all item properties at once
-			var allProps map[string]dbus.Variant
-			err = itemObj.Call("org.freedesktop.DBus.Properties.GetAll", 0, dbustypes.ItemInterface).Store(&allProps)
-			if err != nil {
-				log.Printf("Warning: failed to get properties for %s: %v", itemPath, err)
-				continue
-			}
-
-			label := ""
-			if v, ok := allProps["Label"]; ok {
-				if s, ok := v.Value().(string); ok {
-					label = s
-				}
-			}
-
-			attrs := map[string]string{}
-			if v, ok := allProps["Attributes"]; ok {
-				if a, ok := v.Value().(map[string]string); ok {
-					attrs = a
-				}
+		label := ""
+		if v, ok := allProps["Label"]; ok {
+			if s, ok := v.Value().(string); ok {
+				label = s
 			}
+		}
 
-			for k := range attrs {
-				attrKeys[k] = true
+		attrs := map[string]string{}
+		if v, ok := allProps["Attributes"]; ok {
+			if a, ok := v.Value().(map[string]string); ok {
+				attrs = a
 			}
+		}
 
-			rows = append(rows, row{
-				collection: collName,
-				id:         itemID,
-				label:      label,
-				attrs:      attrs,
-			})
+		for k := range attrs {
+			attrKeys[k] = true
 		}
-	}
 
-	if filterCollection != "" && len(rows) == 0 {
-		// Check if the collection existed at all
-		found := false
-		for _, collPath := range collPaths {
-			name, _ := dbustypes.ParseCollectionPath(collPath)
-			if name == filterCollection {
-				found = true
-				break
-			}
+		var expires uint64
+		if v, ok := ifaces[dbustypes.GopassSecretItemInterface]["Expires"]; ok {
+			expires, _ = v.Value().(uint64)
 		}
-		if !found {
-			fmt.Fprintf(os.Stderr, "Collection not found: %s\n", filterCollection)
-			os.Exit(1)
+		anyExpires = anyExpires || expires != 0
+
+		rows = append(rows, row{
+			collection: collName,
+			id:         itemID,
+			label:      label,
+			expires:    expires,
+			attrs:      attrs,
+		})
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].collection != rows[j].collection {
+			return rows[i].collection < rows[j].collection
 		}
+		return rows[i].id < rows[j].id
+	})
+
+	if filterCollection != "" && !collectionFound {
+		fmt.Fprintf(os.Stderr, "Collection not found: %s\n", filterCollection)
+		os.Exit(1)
 	}
 
 	// Sort attribute keys
@@ -153,6 +149,9 @@ func runList(args []string) {
 
 	// Header
 	fmt.Fprintf(w, "COLLECTION\tID\tLABEL")
+	if anyExpires {
+		fmt.Fprintf(w, "\tEXPIRES")
+	}
 	for _, k := range sortedKeys {
 		fmt.Fprintf(w, "\t%s", k)
 	}
@@ -161,6 +160,9 @@ func runList(args []string) {
 	// Rows
 	for _, r := range rows {
 		fmt.Fprintf(w, "%s\t%s\t%s", r.collection, r.id, truncate(r.label, *maxWidth))
+		if anyExpires {
+			fmt.Fprintf(w, "\t%s", timeLeft(r.expires))
+		}
 		for _, k := range sortedKeys {
 			fmt.Fprintf(w, "\t%s", truncate(r.attrs[k], *maxWidth))
 		}
@@ -170,6 +172,41 @@ func runList(args []string) {
 	w.Flush()
 }
 
+// whereFlag collects the -where conditions.
+type whereFlag []dbustypes.Condition
+
+func (w *whereFlag) String() string { return "" }
+
+func (w *whereFlag) Set(v string) error {
+	c, err := store.ParseCondition(v)
+	if err != nil {
+		return err
+	}
+	*w = append(*w, dbustypes.Condition{Key: c.Key, Op: string(c.Op), Value: c.Value})
+	return nil
+}
+
+// timeLeft formats the time until an item expires (Unix seconds, 0 for
+// never) at the two most significant units, e.g. "2d4h" or "59m30s".
+func timeLeft(expires uint64) string {
+	if expires == 0 {
+		return "-"
+	}
+	d := time.Until(time.Unix(int64(expires), 0)).Round(time.Second)
+	switch {
+	case d <= 0:
+		return "expired"
+	case d >= 24*time.Hour:
+		return fmt.Sprintf("%dd%dh", d/(24*time.Hour), d%(24*time.Hour)/time.Hour)
+	case d >= time.Hour:
+		return fmt.Sprintf("%dh%dm", d/time.Hour, d%time.Hour/time.Minute)
+	case d >= time.Minute:
+		return fmt.Sprintf("%dm%ds", d/time.Minute, d%time.Minute/time.Second)
+	default:
+		return fmt.Sprintf("%ds", d/time.Second)
+	}
+}
+
 func truncate(s string, max int) string {
 	if max <= 0 || utf8.RuneCountInString(s) <= max {
 		return s
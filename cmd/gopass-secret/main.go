@@ -75,6 +75,24 @@ func main() {
 		runGet(os.Args[2:])
 	case "list", "ls":
 		runList(os.Args[2:])
+	case "sync":
+		runSync(os.Args[2:])
+	case "history":
+		runHistory(os.Args[2:])
+	case "restore":
+		runRestore(os.Args[2:])
+	case "alias":
+		runAlias(os.Args[2:])
+	case "dedupe":
+		runDedupe(os.Args[2:])
+	case "mv":
+		runMv(os.Args[2:])
+	case "export":
+		runExport(os.Args[2:])
+	case "import":
+		runImport(os.Args[2:])
+	case "migrate":
+		runMigrate(os.Args[2:])
 	case "version", "--version":
 		fmt.Printf("gopass-secret version %s\n", Version)
 	case "help", "-h", "--help":
@@ -97,7 +115,16 @@ Commands:
   config         Show configuration
   add            Add a secret to the store
   get            Look up a secret by type and attributes
-  list, ls       List secrets with attributes
+  list, ls       List secrets with attributes (-where to filter by conditions)
+  history        List an item's revisions (-show REV prints an old value)
+  restore        Restore an item to a previous revision
+  alias          List, set or remove collection aliases (list|set|rm)
+  mv             Move an item to another collection, or rename a collection
+  export         Write an encrypted archive of collections and items (-r RECIPIENT)
+  import         Restore an archive, or import GNOME Keyring files (import gnome-keyring FILE...)
+  migrate        Copy everything from another running Secret Service provider (-from-bus NAME|ADDRESS)
+  dedupe         Delete items with the same attributes as a newer one (-i to choose, -n to only show)
+  sync           Sync the store with its git remotes now (-status to only show status)
   version        Print version
   help           Show this help
 
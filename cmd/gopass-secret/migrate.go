@@ -0,0 +1,318 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+
+	"github.com/nikicat/gopass-secret-service/internal/crypto"
+	dbustypes "github.com/nikicat/gopass-secret-service/internal/dbus"
+	"github.com/nikicat/gopass-secret-service/internal/secmem"
+	"github.com/nikicat/gopass-secret-service/internal/service"
+	"github.com/nikicat/gopass-secret-service/internal/store"
+)
+
+// promptTimeout bounds how long migrate waits for the user to answer an
+// unlock prompt of the source provider.
+const promptTimeout = 5 * time.Minute
+
+func runMigrate(args []string) {
+	fs := flag.NewFlagSet("migrate", flag.ExitOnError)
+	var flags commonFlags
+	addCommonFlags(fs, &flags)
+	from := fs.String("from-bus", "", "Bus name of the provider on the session bus, or a D-Bus address (unix:path=...) where it owns "+dbustypes.ServiceName)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: gopass-secret migrate -from-bus <name|address> [options]\n\n")
+		fmt.Fprintf(os.Stderr, "Copies every collection and item of another running Secret Service provider\n")
+		fmt.Fprintf(os.Stderr, "into the store. Items whose collection already has one with the same\n")
+		fmt.Fprintf(os.Stderr, "attributes are skipped.\n\n")
+		fs.PrintDefaults()
+	}
+	mustParse(fs, args)
+	if *from == "" || fs.NArg() > 0 {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	cfg, err := flags.loadConfig()
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+
+	conn, dest, err := connectSource(*from)
+	if err != nil {
+		log.Fatalf("Failed to connect to %s: %v", *from, err)
+	}
+	defer conn.Close()
+
+	src := &sourceService{conn: conn, dest: dest}
+	if err := src.openSession(); err != nil {
+		log.Fatalf("Failed to open session: %v", err)
+	}
+	defer src.closeSession()
+
+	ctx := context.Background()
+	s, err := service.OpenStore(ctx, cfg)
+	if err != nil {
+		log.Fatalf("Failed to open store: %v", err)
+	}
+
+	collPaths, err := src.collections()
+	if err != nil {
+		log.Fatalf("Failed to list collections: %v", err)
+	}
+	// The source's default collection becomes ours, whatever its name.
+	var defaultPath dbus.ObjectPath
+	_ = src.service().Call(dbustypes.SecretServiceInterface+".ReadAlias", 0, "default").Store(&defaultPath)
+
+	failed := false
+	for _, collPath := range collPaths {
+		name := path.Base(string(collPath))
+		if name == store.SessionCollectionName {
+			continue
+		}
+		local := store.SanitizeName(name)
+		if collPath == defaultPath {
+			local = "default"
+		}
+		imported, skipped, err := migrateCollection(ctx, src, s, collPath, local)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to migrate %s: %v\n", name, err)
+			fmt.Printf("Partially migrated %s into %s: %d items before the error (%d already there)\n", name, local, imported, skipped)
+			failed = true
+			continue
+		}
+		fmt.Printf("Migrated %d items from %s into %s (%d already there)\n", imported, name, local, skipped)
+	}
+
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// connectSource connects to the provider to migrate from: a D-Bus address
+// such as unix:path=... on which it owns the Secret Service name, or a bus
+// name on the session bus. It returns the connection and the destination to
+// call.
+func connectSource(from string) (*dbus.Conn, string, error) {
+	if !strings.Contains(from, "=") {
+		conn, err := dbus.SessionBus()
+		return conn, from, err
+	}
+	conn, err := dbus.Dial(from)
+	if err != nil {
+		return nil, "", err
+	}
+	if err := conn.Auth(nil); err != nil {
+		conn.Close()
+		return nil, "", err
+	}
+	if err := conn.Hello(); err != nil {
+		conn.Close()
+		return nil, "", err
+	}
+	return conn, dbustypes.ServiceName, nil
+}
+
+// sourceService is a Secret Service client of the provider being migrated
+// from, with an encrypted session for reading secrets.
+type sourceService struct {
+	conn        *dbus.Conn
+	dest        string
+	session     *crypto.DHSession
+	sessionPath dbus.ObjectPath
+}
+
+func (c *sourceService) service() dbus.BusObject {
+	return c.conn.Object(c.dest, dbustypes.ServicePath)
+}
+
+func (c *sourceService) object(p dbus.ObjectPath) dbus.BusObject {
+	return c.conn.Object(c.dest, p)
+}
+
+// openSession negotiates a dh-ietf1024-sha256-aes128-cbc-pkcs7 session, so
+// secrets don't cross the bus in the clear.
+func (c *sourceService) openSession() error {
+	session, pub, err := crypto.NewDHClientSession()
+	if err != nil {
+		return err
+	}
+	var output dbus.Variant
+	if err := c.service().Call(
+		dbustypes.SecretServiceInterface+".OpenSession", 0,
+		crypto.AlgorithmDHAES, dbus.MakeVariant(pub),
+	).Store(&output, &c.sessionPath); err != nil {
+		return err
+	}
+	serverPub, ok := output.Value().([]byte)
+	if !ok {
+		return fmt.Errorf("unexpected OpenSession output type %T", output.Value())
+	}
+	if err := session.Complete(serverPub); err != nil {
+		return err
+	}
+	c.session = session
+	return nil
+}
+
+func (c *sourceService) closeSession() {
+	c.object(c.sessionPath).Call(dbustypes.SessionInterface+".Close", 0)
+	c.session.Close()
+}
+
+func (c *sourceService) collections() ([]dbus.ObjectPath, error) {
+	v, err := c.service().GetProperty(dbustypes.SecretServiceInterface + ".Collections")
+	if err != nil {
+		return nil, err
+	}
+	paths, ok := v.Value().([]dbus.ObjectPath)
+	if !ok {
+		return nil, fmt.Errorf("unexpected Collections property type: %T", v.Value())
+	}
+	return paths, nil
+}
+
+// unlock unlocks objects, answering the provider's prompt if it shows one.
+func (c *sourceService) unlock(objects []dbus.ObjectPath) error {
+	var unlocked []dbus.ObjectPath
+	var prompt dbus.ObjectPath
+	if err := c.service().Call(dbustypes.SecretServiceInterface+".Unlock", 0, objects).Store(&unlocked, &prompt); err != nil {
+		return err
+	}
+	if prompt == "/" {
+		return nil
+	}
+
+	if err := c.conn.AddMatchSignal(
+		dbus.WithMatchObjectPath(prompt),
+		dbus.WithMatchInterface(dbustypes.PromptInterface),
+		dbus.WithMatchMember("Completed"),
+	); err != nil {
+		return err
+	}
+	signals := make(chan *dbus.Signal, 1)
+	c.conn.Signal(signals)
+	defer c.conn.RemoveSignal(signals)
+
+	if err := c.object(prompt).Call(dbustypes.PromptInterface+".Prompt", 0, "").Err; err != nil {
+		return err
+	}
+	timeout := time.After(promptTimeout)
+	for {
+		select {
+		case sig := <-signals:
+			if sig.Path != prompt || sig.Name != dbustypes.PromptInterface+".Completed" {
+				continue
+			}
+			if len(sig.Body) > 0 && sig.Body[0] == true {
+				return errors.New("unlock prompt dismissed")
+			}
+			return nil
+		case <-timeout:
+			return errors.New("timed out waiting for the unlock prompt")
+		}
+	}
+}
+
+// migrateCollection copies the items of the source collection at collPath
+// into the local collection, creating it with the source's label if needed.
+func migrateCollection(ctx context.Context, src *sourceService, s store.Store, collPath dbus.ObjectPath, local string) (imported, skipped int, err error) {
+	coll := src.object(collPath)
+	var props map[string]dbus.Variant
+	if err := coll.Call("org.freedesktop.DBus.Properties.GetAll", 0, dbustypes.CollectionInterface).Store(&props); err != nil {
+		return 0, 0, err
+	}
+	if locked, _ := props["Locked"].Value().(bool); locked {
+		if err := src.unlock([]dbus.ObjectPath{collPath}); err != nil {
+			return 0, 0, fmt.Errorf("unlock: %w", err)
+		}
+		// Some providers only list the items of unlocked collections.
+		v, err := coll.GetProperty(dbustypes.CollectionInterface + ".Items")
+		if err != nil {
+			return 0, 0, err
+		}
+		props["Items"] = v
+	}
+	itemPaths, ok := props["Items"].Value().([]dbus.ObjectPath)
+	if !ok {
+		return 0, 0, fmt.Errorf("unexpected Items property type: %T", props["Items"].Value())
+	}
+	if len(itemPaths) == 0 {
+		return 0, 0, nil
+	}
+
+	if _, err := s.GetCollection(ctx, local); err != nil {
+		label, _ := props["Label"].Value().(string)
+		if err := s.CreateCollection(ctx, local, label); err != nil {
+			return 0, 0, fmt.Errorf("create collection %s: %w", local, err)
+		}
+	}
+
+	var secrets map[dbus.ObjectPath]dbustypes.Secret
+	if err := src.service().Call(
+		dbustypes.SecretServiceInterface+".GetSecrets", 0, itemPaths, src.sessionPath,
+	).Store(&secrets); err != nil {
+		return 0, 0, fmt.Errorf("get secrets: %w", err)
+	}
+
+	for _, itemPath := range itemPaths {
+		secret, ok := secrets[itemPath]
+		if !ok {
+			// GetSecrets leaves out items that stayed locked.
+			return imported, skipped, fmt.Errorf("%s: no secret returned, item locked?", itemPath)
+		}
+		item, value, err := src.item(itemPath, secret)
+		if err != nil {
+			return imported, skipped, fmt.Errorf("%s: %w", itemPath, err)
+		}
+		created, err := store.CreateItemOnce(ctx, s, local, item)
+		value.Wipe()
+		if err != nil {
+			return imported, skipped, fmt.Errorf("import %q: %w", item.Label, err)
+		}
+		if created {
+			imported++
+		} else {
+			skipped++
+		}
+	}
+	return imported, skipped, nil
+}
+
+// item reads the properties of the source item at itemPath and decrypts
+// its secret into value, which the caller wipes once the item is stored.
+func (c *sourceService) item(itemPath dbus.ObjectPath, secret dbustypes.Secret) (item *store.ItemData, value *secmem.Buffer, err error) {
+	var props map[string]dbus.Variant
+	if err := c.object(itemPath).Call("org.freedesktop.DBus.Properties.GetAll", 0, dbustypes.ItemInterface).Store(&props); err != nil {
+		return nil, nil, err
+	}
+	value, err = c.session.Decrypt(secret.Parameters, secret.Value)
+	if err != nil {
+		return nil, nil, fmt.Errorf("decrypt secret: %w", err)
+	}
+	item = &store.ItemData{
+		Secret:      value.Bytes(),
+		ContentType: secret.ContentType,
+		Attributes:  map[string]string{},
+	}
+	item.Label, _ = props["Label"].Value().(string)
+	if attrs, ok := props["Attributes"].Value().(map[string]string); ok {
+		item.Attributes = attrs
+	}
+	if created, _ := props["Created"].Value().(uint64); created != 0 {
+		item.Created = time.Unix(int64(created), 0)
+	}
+	if modified, _ := props["Modified"].Value().(uint64); modified != 0 {
+		item.Modified = time.Unix(int64(modified), 0)
+	}
+	return item, value, nil
+}
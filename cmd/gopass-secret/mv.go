@@ -0,0 +1,55 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/godbus/dbus/v5"
+
+	dbustypes "github.com/nikicat/gopass-secret-service/internal/dbus"
+)
+
+func runMv(args []string) {
+	fs := flag.NewFlagSet("mv", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: gopass-secret mv <collection/id> <collection>\n")
+		fmt.Fprintf(os.Stderr, "       gopass-secret mv <collection> <new-name>\n\n")
+		fmt.Fprintf(os.Stderr, "Moves an item to another collection, or renames a collection.\n")
+	}
+	mustParse(fs, args)
+	if fs.NArg() != 2 {
+		fs.Usage()
+		os.Exit(1)
+	}
+	src, dst := fs.Arg(0), fs.Arg(1)
+
+	conn, err := dbus.SessionBus()
+	if err != nil {
+		log.Fatalf("Failed to connect to session bus: %v", err)
+	}
+	defer conn.Close()
+
+	svc := conn.Object(dbustypes.ServiceName, dbustypes.ServicePath)
+
+	var moved dbus.ObjectPath
+	if strings.Contains(src, "/") {
+		itemPath := parseItemRef(src)
+		if err := svc.Call(dbustypes.GopassSecretInterface+".MoveItem", 0, itemPath, dbustypes.CollectionPath(dst)).Store(&moved); err != nil {
+			log.Fatalf("Failed to move %s: %v", src, err)
+		}
+		fmt.Printf("Moved %s to %s\n", itemRef(itemPath), itemRef(moved))
+		return
+	}
+
+	if err := svc.Call(dbustypes.GopassSecretInterface+".RenameCollection", 0, dbustypes.CollectionPath(src), dst).Store(&moved); err != nil {
+		log.Fatalf("Failed to rename %s: %v", src, err)
+	}
+	name, err := dbustypes.ParseCollectionPath(moved)
+	if err != nil {
+		name = string(moved)
+	}
+	fmt.Printf("Renamed %s to %s\n", src, name)
+}
@@ -11,6 +11,8 @@ import (
 	"path/filepath"
 	"syscall"
 
+	"github.com/nikicat/gopass-secret-service/internal/config"
+	"github.com/nikicat/gopass-secret-service/internal/secmem"
 	"github.com/nikicat/gopass-secret-service/internal/service"
 )
 
@@ -89,9 +91,21 @@ func runService(args []string) {
 		log.Printf("Using session bus")
 	}
 	log.Printf("Config file: %s", cfg.ConfigPath)
-	log.Printf("Using gopass prefix: %s", cfg.Prefix)
+	if cfg.Backend == config.BackendAge {
+		log.Printf("Using age store: %s", cfg.Age.Dir)
+	} else {
+		if cfg.StorePath != "" {
+			log.Printf("Using gopass store path: %s", cfg.StorePath)
+		}
+		log.Printf("Using gopass prefix: %s", cfg.Prefix)
+	}
 	log.Printf("Default collection: %s", cfg.DefaultCollection)
 
+	// Keep decrypted secrets out of core dumps and away from ptrace.
+	if err := secmem.DisableCoreDumps(); err != nil {
+		log.Printf("Warning: failed to disable core dumps: %v", err)
+	}
+
 	// Create and start the service
 	ctx := context.Background()
 	svc, err := service.New(ctx, cfg)
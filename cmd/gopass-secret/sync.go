@@ -0,0 +1,63 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+
+	dbustypes "github.com/nikicat/gopass-secret-service/internal/dbus"
+)
+
+func runSync(args []string) {
+	fs := flag.NewFlagSet("sync", flag.ExitOnError)
+	statusOnly := fs.Bool("status", false, "Only show sync status, don't sync")
+	mustParse(fs, args)
+
+	conn, err := dbus.SessionBus()
+	if err != nil {
+		log.Fatalf("Failed to connect to session bus: %v", err)
+	}
+	defer conn.Close()
+
+	svc := conn.Object(dbustypes.ServiceName, dbustypes.ServicePath)
+
+	failed := false
+	if !*statusOnly {
+		if err := svc.Call(dbustypes.GopassSecretInterface+".Sync", 0).Err; err != nil {
+			fmt.Fprintf(os.Stderr, "Sync failed: %v\n", err)
+			failed = true
+		}
+	}
+
+	var repos []dbustypes.SyncStatus
+	if err := svc.Call(dbustypes.GopassSecretInterface+".SyncStatus", 0).Store(&repos); err != nil {
+		log.Fatalf("Failed to get sync status: %v", err)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "REPOSITORY\tPENDING\tLAST PULL\tLAST PUSH\tERROR")
+	for _, r := range repos {
+		pending := "no"
+		if r.Pending {
+			pending = "yes"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", r.Dir, pending, formatUnix(r.LastPull), formatUnix(r.LastPush), r.LastError)
+	}
+	w.Flush()
+
+	if failed {
+		os.Exit(1)
+	}
+}
+
+func formatUnix(sec int64) string {
+	if sec == 0 {
+		return "never"
+	}
+	return time.Unix(sec, 0).Format(time.DateTime)
+}
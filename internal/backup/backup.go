@@ -0,0 +1,281 @@
+// Package backup writes and restores portable archives of secret-service
+// collections. An archive holds collections, aliases and items with their
+// attributes, content types and times, independent of how the store lays
+// them out, and is encrypted to age or GPG recipients (see crypt.go).
+package backup
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/nikicat/gopass-secret-service/internal/secmem"
+	"github.com/nikicat/gopass-secret-service/internal/store"
+)
+
+// Version is the archive format version written by Export. Archives of a
+// later version are refused.
+const Version = 1
+
+// Archive is the plaintext of a backup.
+type Archive struct {
+	Version     int          `json:"version"`
+	Created     time.Time    `json:"created"`
+	Collections []Collection `json:"collections"`
+	// Aliases map alias names to collections in the archive.
+	Aliases map[string]string `json:"aliases,omitempty"`
+}
+
+// Wipe overwrites the secrets of every item in the archive. Export reads
+// them from the store for the archive alone, so callers wipe it once it's
+// written or restored.
+func (a *Archive) Wipe() {
+	for i := range a.Collections {
+		a.Collections[i].wipe()
+	}
+}
+
+// Collection is a collection and the items of it that were selected.
+type Collection struct {
+	Name     string    `json:"name"`
+	Label    string    `json:"label"`
+	Created  time.Time `json:"created"`
+	Modified time.Time `json:"modified"`
+	Items    []Item    `json:"items"`
+}
+
+func (c *Collection) wipe() {
+	for _, item := range c.Items {
+		secmem.Wipe(item.Secret)
+	}
+}
+
+// Item is one secret.
+type Item struct {
+	Label       string            `json:"label"`
+	Secret      []byte            `json:"secret"`
+	ContentType string            `json:"content_type"`
+	Attributes  map[string]string `json:"attributes"`
+	Created     time.Time         `json:"created"`
+	Modified    time.Time         `json:"modified"`
+	Expires     time.Time         `json:"expires,omitzero"`
+}
+
+// Filter selects what Export archives. Empty fields select everything.
+type Filter struct {
+	// Collections are the names of the collections to export.
+	Collections []string
+	// Schemas are xdg:schema values; items with another schema, or none,
+	// are left out.
+	Schemas []string
+	// Query is what the attributes of an exported item must match.
+	Query *store.Query
+}
+
+// Export reads the items of s selected by f into an archive. Collections
+// are only included if they have a selected item, unless they were named in
+// f.Collections.
+func Export(ctx context.Context, s store.Store, f Filter) (*Archive, error) {
+	names := f.Collections
+	if len(names) == 0 {
+		var err error
+		if names, err = s.Collections(ctx); err != nil {
+			return nil, err
+		}
+	}
+	q := f.Query
+	if q == nil {
+		q = &store.Query{}
+	}
+
+	a := &Archive{Version: Version, Created: time.Now()}
+	for _, name := range names {
+		c, err := exportCollection(ctx, s, name, f.Schemas, q)
+		if err != nil {
+			a.Wipe()
+			return nil, fmt.Errorf("export %s: %w", name, err)
+		}
+		if len(c.Items) > 0 || len(f.Collections) > 0 {
+			a.Collections = append(a.Collections, *c)
+		}
+	}
+
+	if l, ok := s.(store.AliasLister); ok {
+		aliases, err := l.Aliases(ctx)
+		if err != nil && !errors.Is(err, store.ErrAliasesUnsupported) {
+			a.Wipe()
+			return nil, fmt.Errorf("aliases: %w", err)
+		}
+		for alias, coll := range aliases {
+			if slices.ContainsFunc(a.Collections, func(c Collection) bool { return c.Name == coll }) {
+				if a.Aliases == nil {
+					a.Aliases = make(map[string]string)
+				}
+				a.Aliases[alias] = coll
+			}
+		}
+	}
+	return a, nil
+}
+
+func exportCollection(ctx context.Context, s store.Store, name string, schemas []string, q *store.Query) (*Collection, error) {
+	cd, err := s.GetCollection(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	found, err := store.SearchQuery(ctx, s, name, q)
+	if err != nil {
+		return nil, err
+	}
+	c := &Collection{Name: name, Label: cd.Label, Created: cd.Created, Modified: cd.Modified}
+	for _, match := range found[name] {
+		if len(schemas) > 0 && !slices.Contains(schemas, match.Attributes["xdg:schema"]) {
+			continue
+		}
+		// Searches don't return secrets.
+		item, err := s.GetItem(ctx, name, match.ID)
+		if err != nil {
+			c.wipe()
+			return nil, fmt.Errorf("read %s: %w", match.ID, err)
+		}
+		c.Items = append(c.Items, Item{
+			Label:       item.Label,
+			Secret:      item.Secret,
+			ContentType: item.ContentType,
+			Attributes:  item.Attributes,
+			Created:     item.Created,
+			Modified:    item.Modified,
+			Expires:     item.Expires,
+		})
+	}
+	sort.SliceStable(c.Items, func(i, j int) bool { return c.Items[i].Created.Before(c.Items[j].Created) })
+	return c, nil
+}
+
+// Policy is what Restore does with an archived item when its collection
+// already has an item with the same attributes (and, for items without
+// attributes, the same label).
+type Policy string
+
+const (
+	// PolicySkip leaves the existing item alone.
+	PolicySkip Policy = "skip"
+	// PolicyOverwrite replaces the existing item's label, secret and
+	// attributes with the archived ones.
+	PolicyOverwrite Policy = "overwrite"
+	// PolicyKeepBoth creates the archived item next to the existing one.
+	PolicyKeepBoth Policy = "keep-both"
+)
+
+// ParsePolicy checks a policy name.
+func ParsePolicy(s string) (Policy, error) {
+	switch p := Policy(s); p {
+	case PolicySkip, PolicyOverwrite, PolicyKeepBoth:
+		return p, nil
+	}
+	return "", fmt.Errorf("unknown conflict policy %q (want skip, overwrite or keep-both)", s)
+}
+
+// Result counts what Restore did with the archived items.
+type Result struct {
+	Created     int
+	Overwritten int
+	Skipped     int
+}
+
+// Restore writes the archive into s, creating missing collections, and
+// resolves items that already exist according to p. Aliases are set unless
+// they already point somewhere, which only PolicyOverwrite changes.
+func Restore(ctx context.Context, s store.Store, a *Archive, p Policy) (Result, error) {
+	var res Result
+	for _, c := range a.Collections {
+		// Stores sanitize the names of collections they create, so an
+		// archive edited by hand or written elsewhere must be looked up
+		// under the name the collection gets.
+		name := store.SanitizeName(c.Name)
+		if _, err := s.GetCollection(ctx, name); err != nil {
+			if err := s.CreateCollection(ctx, name, c.Label); err != nil {
+				return res, fmt.Errorf("create collection %s: %w", name, err)
+			}
+		}
+		for _, it := range c.Items {
+			if err := restoreItem(ctx, s, name, it, p, &res); err != nil {
+				return res, fmt.Errorf("restore %s/%q: %w", name, it.Label, err)
+			}
+		}
+	}
+
+	var existing map[string]string
+	if l, ok := s.(store.AliasLister); ok {
+		existing, _ = l.Aliases(ctx)
+	}
+	for _, alias := range slices.Sorted(maps.Keys(a.Aliases)) {
+		coll := store.SanitizeName(a.Aliases[alias])
+		if cur, ok := existing[alias]; ok && cur != coll && p != PolicyOverwrite {
+			continue
+		}
+		if err := s.SetAlias(ctx, alias, coll); err != nil {
+			return res, fmt.Errorf("set alias %s: %w", alias, err)
+		}
+	}
+	return res, nil
+}
+
+func restoreItem(ctx context.Context, s store.Store, collection string, it Item, p Policy, res *Result) error {
+	item := &store.ItemData{
+		Label:       it.Label,
+		Secret:      it.Secret,
+		ContentType: it.ContentType,
+		Attributes:  it.Attributes,
+		Created:     it.Created,
+		Modified:    it.Modified,
+		Expires:     it.Expires,
+	}
+	if p != PolicyKeepBoth {
+		existing, err := store.FindItem(ctx, s, collection, item)
+		if err != nil {
+			return err
+		}
+		if existing != nil {
+			if p == PolicySkip {
+				res.Skipped++
+				return nil
+			}
+			if err := s.UpdateItem(ctx, collection, existing.ID, item); err != nil {
+				return err
+			}
+			res.Overwritten++
+			return nil
+		}
+	}
+	if _, err := s.CreateItem(ctx, collection, item); err != nil {
+		return err
+	}
+	res.Created++
+	return nil
+}
+
+// Encode writes the archive's plaintext.
+func Encode(w io.Writer, a *Archive) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(a)
+}
+
+// Decode reads an archive's plaintext.
+func Decode(r io.Reader) (*Archive, error) {
+	var a Archive
+	if err := json.NewDecoder(r).Decode(&a); err != nil {
+		return nil, fmt.Errorf("invalid archive: %w", err)
+	}
+	if a.Version < 1 || a.Version > Version {
+		return nil, fmt.Errorf("unsupported archive version %d", a.Version)
+	}
+	return &a, nil
+}
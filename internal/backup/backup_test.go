@@ -0,0 +1,151 @@
+package backup
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"filippo.io/age"
+
+	"github.com/nikicat/gopass-secret-service/internal/store"
+)
+
+func newTestStore(t *testing.T) *store.AgeStore {
+	t.Helper()
+	s, err := store.NewAgeStoreWithPassphrase(t.TempDir(), "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestExportWriteReadRestore(t *testing.T) {
+	ctx := context.Background()
+	src := newTestStore(t)
+	created := time.Unix(1600000000, 0)
+	for _, c := range []string{"default", "work"} {
+		if err := src.CreateCollection(ctx, c, c+" label"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	add := func(coll, label string, attrs map[string]string) {
+		t.Helper()
+		if _, err := src.CreateItem(ctx, coll, &store.ItemData{
+			Label: label, Secret: []byte(label + " secret"), ContentType: "application/octet-stream",
+			Attributes: attrs, Created: created, Modified: created,
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	add("default", "GitHub", map[string]string{"xdg:schema": "org.gnome.keyring.NetworkPassword", "server": "github.com"})
+	add("default", "Wi-Fi", map[string]string{"xdg:schema": "org.freedesktop.NetworkManager.Connection", "ssid": "home"})
+	add("work", "VPN", map[string]string{"xdg:schema": "org.gnome.keyring.NetworkPassword", "server": "vpn.example.com"})
+	if err := src.SetAlias(ctx, "login", "default"); err != nil {
+		t.Fatal(err)
+	}
+
+	q, err := store.CompileQuery([]store.Condition{{Key: "server", Op: store.OpExists}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, err := Export(ctx, src, Filter{Schemas: []string{"org.gnome.keyring.NetworkPassword"}, Query: q})
+	if err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+	if len(a.Collections) != 2 || len(a.Collections[0].Items) != 1 || a.Aliases["login"] != "default" {
+		t.Fatalf("archive = %+v", a)
+	}
+
+	id, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	idFile := filepath.Join(t.TempDir(), "key.txt")
+	if err := os.WriteFile(idFile, []byte(id.String()+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := Write(&buf, a, []string{id.Recipient().String()}); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if bytes.Contains(buf.Bytes(), []byte("GitHub")) {
+		t.Error("archive isn't encrypted")
+	}
+	a, err = Read(&buf, []string{idFile})
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+
+	dst := newTestStore(t)
+	res, err := Restore(ctx, dst, a, PolicySkip)
+	if err != nil || res.Created != 2 {
+		t.Fatalf("Restore = %+v, %v", res, err)
+	}
+	items, err := dst.SearchItems(ctx, "default", map[string]string{"server": "github.com"})
+	if err != nil || len(items) != 1 {
+		t.Fatalf("SearchItems = %v, %v", items, err)
+	}
+	got, err := dst.GetItem(ctx, "default", items[0].ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Label != "GitHub" || string(got.Secret) != "GitHub secret" ||
+		got.ContentType != "application/octet-stream" || !got.Created.Equal(created) {
+		t.Errorf("restored item = %+v", got)
+	}
+	if c, err := dst.GetCollection(ctx, "work"); err != nil || c.Label != "work label" {
+		t.Errorf("restored collection = %+v, %v", c, err)
+	}
+	if coll, err := dst.GetAlias(ctx, "login"); err != nil || coll != "default" {
+		t.Errorf("restored alias = %q, %v", coll, err)
+	}
+
+	if res, err := Restore(ctx, dst, a, PolicySkip); err != nil || res.Skipped != 2 || res.Created != 0 {
+		t.Errorf("Restore with skip = %+v, %v", res, err)
+	}
+	a.Collections[0].Items[0].Secret = []byte("changed")
+	if res, err := Restore(ctx, dst, a, PolicyOverwrite); err != nil || res.Overwritten != 2 {
+		t.Errorf("Restore with overwrite = %+v, %v", res, err)
+	}
+	if got, _ := dst.GetItem(ctx, "default", items[0].ID); got == nil || string(got.Secret) != "changed" {
+		t.Errorf("overwritten item = %+v", got)
+	}
+	if res, err := Restore(ctx, dst, a, PolicyKeepBoth); err != nil || res.Created != 2 {
+		t.Errorf("Restore with keep-both = %+v, %v", res, err)
+	}
+	if ids, _ := dst.Items(ctx, "default"); len(ids) != 2 {
+		t.Errorf("default has %d items after keep-both, want 2", len(ids))
+	}
+}
+
+func TestRestoreSanitizesCollectionNames(t *testing.T) {
+	ctx := context.Background()
+	a := &Archive{
+		Version: Version,
+		Collections: []Collection{{
+			Name:  "My Work",
+			Label: "My Work",
+			Items: []Item{{Label: "VPN", Secret: []byte("s"), Attributes: map[string]string{"server": "vpn"}}},
+		}},
+		Aliases: map[string]string{"office": "My Work"},
+	}
+	dst := newTestStore(t)
+	if res, err := Restore(ctx, dst, a, PolicySkip); err != nil || res.Created != 1 {
+		t.Fatalf("Restore = %+v, %v", res, err)
+	}
+	if ids, err := dst.Items(ctx, "My_Work"); err != nil || len(ids) != 1 {
+		t.Errorf("items of My_Work = %v, %v", ids, err)
+	}
+	if coll, err := dst.GetAlias(ctx, "office"); err != nil || coll != "My_Work" {
+		t.Errorf("restored alias = %q, %v", coll, err)
+	}
+}
+
+func TestDecodeRejectsNewerVersion(t *testing.T) {
+	if _, err := Decode(bytes.NewReader([]byte(`{"version": 2}`))); err == nil {
+		t.Error("Decode accepted a newer archive version")
+	}
+}
@@ -0,0 +1,136 @@
+package backup
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+
+	"github.com/nikicat/gopass-secret-service/internal/secmem"
+)
+
+// ageMagic starts binary age files.
+const ageMagic = "age-encryption.org/"
+
+// Write encrypts the archive to recipients and writes it to w. Recipients
+// starting with age1 are age X25519 recipients; anything else is a GPG key
+// ID, fingerprint or email that gpg encrypts to. The two kinds can't be
+// mixed, since the archive is encrypted once.
+func Write(w io.Writer, a *Archive, recipients []string) error {
+	if len(recipients) == 0 {
+		return errors.New("no recipients")
+	}
+	var plain bytes.Buffer
+	err := Encode(&plain, a)
+	// Taken before gpg drains the buffer, so the plaintext can still be
+	// wiped once it's encrypted.
+	defer secmem.Wipe(plain.Bytes())
+	if err != nil {
+		return err
+	}
+
+	var ageRecipients []age.Recipient
+	var gpgRecipients []string
+	for _, r := range recipients {
+		if !strings.HasPrefix(r, "age1") {
+			gpgRecipients = append(gpgRecipients, r)
+			continue
+		}
+		rcpt, err := age.ParseX25519Recipient(r)
+		if err != nil {
+			return err
+		}
+		ageRecipients = append(ageRecipients, rcpt)
+	}
+	switch {
+	case len(gpgRecipients) == 0:
+		enc, err := age.Encrypt(w, ageRecipients...)
+		if err != nil {
+			return err
+		}
+		if _, err := enc.Write(plain.Bytes()); err != nil {
+			return err
+		}
+		return enc.Close()
+	case len(ageRecipients) == 0:
+		args := []string{"--batch", "--yes", "--encrypt"}
+		for _, r := range gpgRecipients {
+			args = append(args, "--recipient", r)
+		}
+		return gpg(&plain, w, args...)
+	default:
+		return errors.New("can't encrypt to both age and GPG recipients")
+	}
+}
+
+// Read decrypts an archive written by Write. Age archives, binary or
+// armored, are decrypted with the identities in identityFiles; anything else
+// is handed to gpg, which finds the key itself.
+func Read(r io.Reader, identityFiles []string) (*Archive, error) {
+	br := bufio.NewReader(r)
+	head, _ := br.Peek(len(armor.Header))
+	isArmored := string(head) == armor.Header
+	if !isArmored && !strings.HasPrefix(string(head), ageMagic) {
+		var plain bytes.Buffer
+		err := gpg(br, &plain, "--decrypt", "--quiet")
+		defer secmem.Wipe(plain.Bytes())
+		if err != nil {
+			return nil, err
+		}
+		return Decode(&plain)
+	}
+
+	var ids []age.Identity
+	for _, file := range identityFiles {
+		fileIDs, err := readIdentities(file)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, fileIDs...)
+	}
+	if len(ids) == 0 {
+		return nil, errors.New("archive is age-encrypted: an identity file is needed")
+	}
+	var in io.Reader = br
+	if isArmored {
+		in = armor.NewReader(br)
+	}
+	dec, err := age.Decrypt(in, ids...)
+	if err != nil {
+		return nil, err
+	}
+	return Decode(dec)
+}
+
+func readIdentities(file string) ([]age.Identity, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, fmt.Errorf("age identity: %w", err)
+	}
+	defer f.Close()
+	ids, err := age.ParseIdentities(f)
+	if err != nil {
+		return nil, fmt.Errorf("age identity %s: %w", file, err)
+	}
+	return ids, nil
+}
+
+// gpg runs gpg with args, feeding it in and writing its output to out.
+func gpg(in io.Reader, out io.Writer, args ...string) error {
+	cmd := exec.Command("gpg", args...)
+	cmd.Stdin = in
+	cmd.Stdout = out
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("gpg: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
@@ -3,16 +3,27 @@ package config
 import (
 	"fmt"
 	"os"
+	"path"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
 
 // Config holds the configuration for gopass-secret-service
 type Config struct {
-	// StorePath is the path to the GoPass store
+	// StorePath is the path to the GoPass root store. When set it overrides
+	// mounts.path from the gopass config; when empty gopass's own setting is used.
 	StorePath string `yaml:"store_path"`
 
+	// Backend selects the durable store: "gopass" or "age"
+	Backend string `yaml:"backend"`
+
+	// Age configures the age backend
+	Age AgeConfig `yaml:"age"`
+
 	// Prefix is the prefix for secret-service entries in gopass
 	Prefix string `yaml:"prefix"`
 
@@ -33,20 +44,286 @@ type Config struct {
 	// where child processes like gpg-agent/pinentry still need the real session bus).
 	BusAddress string `yaml:"bus_address"`
 
+	// Watch enables watching the store directory for changes made outside
+	// the daemon (gopass CLI, git pull) and reflecting them over D-Bus
+	Watch bool `yaml:"watch"`
+
+	// Index persists decrypted item attributes in an encrypted cache file so
+	// searches after a restart don't have to decrypt every entry
+	Index bool `yaml:"index"`
+
+	// Decrypt limits concurrent gopass decryption and configures the cache
+	// warm-up at startup
+	Decrypt DecryptConfig `yaml:"decrypt"`
+
+	// Sync configures automatic git sync of the password store
+	Sync SyncConfig `yaml:"sync"`
+
+	// Naming configures readable gopass paths for new items
+	Naming NamingConfig `yaml:"naming"`
+
+	// Routes map collections to gopass mounts. The first route whose pattern
+	// matches a collection name wins; unmatched collections live on the root
+	// store under Prefix.
+	Routes []Route `yaml:"routes"`
+
+	// Native exposes subtrees of ordinary gopass entries as read-only
+	// collections
+	Native []NativeCollection `yaml:"native"`
+
+	// Volatile keeps collections in the kernel keyring, like the built-in
+	// session collection, instead of the durable store
+	Volatile []VolatileCollection `yaml:"volatile"`
+
+	// TTL gives items in volatile collections a lifetime by their xdg:schema
+	// attribute ("*" for any other schema); the gopass:ttl attribute
+	// overrides it
+	TTL map[string]time.Duration `yaml:"ttl"`
+
+	// ReapInterval is how often items past their expiry are deleted (0 = never)
+	ReapInterval time.Duration `yaml:"reap_interval"`
+
 	// ConfigPath is the resolved path to the config file
 	ConfigPath string `yaml:"-"`
 }
 
+// Route places a set of collections on a gopass mount
+type Route struct {
+	// Collections are glob patterns (path.Match syntax) matched against collection names
+	Collections []string `yaml:"collections"`
+
+	// Mount is the gopass mount point holding the collections (empty for the root store)
+	Mount string `yaml:"mount"`
+
+	// Prefix is the prefix for secret-service entries on the mount (defaults to Config.Prefix)
+	Prefix string `yaml:"prefix"`
+}
+
+// Durable store backends
+const (
+	BackendGopass = "gopass"
+	BackendAge    = "age"
+)
+
+// AgeConfig configures the age backend, which keeps each collection in one
+// age-encrypted file. Exactly one of Identity, PassphraseFile or Passphrase
+// must be set.
+type AgeConfig struct {
+	// Dir is the directory holding the collection files
+	Dir string `yaml:"dir"`
+
+	// Identity is an age identity file, as written by age-keygen
+	Identity string `yaml:"identity"`
+
+	// PassphraseFile is a file whose first line is the passphrase
+	PassphraseFile string `yaml:"passphrase_file"`
+
+	// Passphrase is only taken from the environment, never from the file
+	Passphrase string `yaml:"-"`
+}
+
+// NamingConfig selects a path template (Go text/template) for new items by
+// their xdg:schema attribute. Templates see .Attr "name", .Label and
+// .Collection; items no template applies to are named after a UUID.
+type NamingConfig struct {
+	// Default is the template for items whose schema has none of its own
+	Default string `yaml:"default"`
+
+	// Schemas maps xdg:schema values to templates
+	Schemas map[string]string `yaml:"schemas"`
+}
+
+// NativeCollection maps a subtree of native gopass entries (password on the
+// first line, "key: value" fields below) to a read-only collection
+type NativeCollection struct {
+	// Collection is the name of the collection
+	Collection string `yaml:"collection"`
+
+	// Mount is the gopass mount point holding the subtree (empty for the root store)
+	Mount string `yaml:"mount"`
+
+	// Path is the subtree's path relative to the root of Mount
+	Path string `yaml:"path"`
+
+	// Label is the collection label (defaults to Collection)
+	Label string `yaml:"label"`
+
+	// Schema is reported as the xdg:schema attribute of every item
+	Schema string `yaml:"schema"`
+
+	// Fields renames gopass fields to attributes, e.g. {url: server}
+	Fields map[string]string `yaml:"fields"`
+}
+
+// VolatileCollection names collections whose items live only in memory and
+// are gone when the daemon exits
+type VolatileCollection struct {
+	// Collection is a collection name or a glob pattern (path.Match syntax).
+	// Exact names are created at startup; collections clients create under a
+	// matching name are volatile too.
+	Collection string `yaml:"collection"`
+
+	// Label is the label of a collection created at startup (defaults to Collection)
+	Label string `yaml:"label"`
+}
+
+// IsPattern reports whether v matches collection names rather than naming one
+func (v VolatileCollection) IsPattern() bool {
+	return strings.ContainsAny(v.Collection, "*?[\\")
+}
+
+// DecryptConfig controls how gopass entries are decrypted
+type DecryptConfig struct {
+	// Workers is how many entries are decrypted at once, across all routes
+	// (1 for a smartcard that can't keep up)
+	Workers int `yaml:"workers"`
+
+	// WarmUp decrypts every entry missing from the attribute cache in the
+	// background at startup, so the first search doesn't have to
+	WarmUp bool `yaml:"warm_up"`
+}
+
+// SyncConfig controls pushing and pulling the git repositories behind the
+// root store and every routed mount
+type SyncConfig struct {
+	// Enabled turns on the sync scheduler
+	Enabled bool `yaml:"enabled"`
+
+	// PushDelay is how long to wait after the last write before pushing
+	PushDelay time.Duration `yaml:"push_delay"`
+
+	// PullInterval is the period of background pulls (0 = only at startup)
+	PullInterval time.Duration `yaml:"pull_interval"`
+}
+
+// RoutePrefix returns the full gopass path prefix for collections matched by r
+func (c *Config) RoutePrefix(r Route) string {
+	return path.Join(r.Mount, c.MountPrefix(r))
+}
+
+// Prefix returns the full gopass path of n's subtree
+func (n NativeCollection) Prefix() string {
+	return path.Join(n.Mount, n.Path)
+}
+
+// MountPrefix returns the prefix for collections matched by r relative to the
+// root of r's mount
+func (c *Config) MountPrefix(r Route) string {
+	if r.Prefix != "" {
+		return r.Prefix
+	}
+	return c.Prefix
+}
+
+// Validate checks the config for errors that would only surface later at runtime
+func (c *Config) Validate() error {
+	for i, r := range c.Routes {
+		if len(r.Collections) == 0 {
+			return fmt.Errorf("routes[%d]: no collections", i)
+		}
+		for _, pattern := range r.Collections {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return fmt.Errorf("routes[%d]: invalid pattern %q: %w", i, pattern, err)
+			}
+		}
+		if strings.HasPrefix(r.Mount, "/") || strings.Contains(r.Mount, "..") {
+			return fmt.Errorf("routes[%d]: invalid mount %q", i, r.Mount)
+		}
+	}
+	switch c.Backend {
+	case BackendGopass:
+	case BackendAge:
+		if err := c.validateAge(); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unknown backend %q", c.Backend)
+	}
+	seen := make(map[string]bool, len(c.Native))
+	for i, n := range c.Native {
+		if n.Collection == "" || strings.ContainsAny(n.Collection, "/*?[\\") {
+			return fmt.Errorf("native[%d]: invalid collection %q", i, n.Collection)
+		}
+		if n.Collection == "session" || seen[n.Collection] {
+			return fmt.Errorf("native[%d]: collection %q is already in use", i, n.Collection)
+		}
+		seen[n.Collection] = true
+		if n.Path == "" || strings.HasPrefix(n.Path, "/") || strings.Contains(n.Path, "..") {
+			return fmt.Errorf("native[%d]: invalid path %q", i, n.Path)
+		}
+		if strings.HasPrefix(n.Mount, "/") || strings.Contains(n.Mount, "..") {
+			return fmt.Errorf("native[%d]: invalid mount %q", i, n.Mount)
+		}
+	}
+	for i, v := range c.Volatile {
+		if _, err := path.Match(v.Collection, ""); v.Collection == "" || err != nil {
+			return fmt.Errorf("volatile[%d]: invalid collection %q", i, v.Collection)
+		}
+		if v.Collection == "session" || seen[v.Collection] {
+			return fmt.Errorf("volatile[%d]: collection %q is already in use", i, v.Collection)
+		}
+		seen[v.Collection] = true
+	}
+	for schema, ttl := range c.TTL {
+		if ttl < 0 {
+			return fmt.Errorf("ttl: negative lifetime for %q", schema)
+		}
+	}
+	if c.ReapInterval < 0 {
+		return fmt.Errorf("negative reap_interval")
+	}
+	if c.Decrypt.Workers < 1 {
+		return fmt.Errorf("decrypt: workers must be at least 1")
+	}
+	if c.Sync.PushDelay < 0 || c.Sync.PullInterval < 0 {
+		return fmt.Errorf("sync: negative push_delay or pull_interval")
+	}
+	return nil
+}
+
+// validateAge checks the age backend settings and rejects the options that
+// only make sense with gopass
+func (c *Config) validateAge() error {
+	set := 0
+	for _, v := range []string{c.Age.Identity, c.Age.PassphraseFile, c.Age.Passphrase} {
+		if v != "" {
+			set++
+		}
+	}
+	if set != 1 {
+		return fmt.Errorf("age: exactly one of identity, passphrase_file or a passphrase is required")
+	}
+	if c.Age.Dir == "" {
+		return fmt.Errorf("age: no dir")
+	}
+	if len(c.Routes) > 0 || len(c.Native) > 0 || c.Sync.Enabled {
+		return fmt.Errorf("routes, native collections and sync require the gopass backend")
+	}
+	return nil
+}
+
 // DefaultConfig returns a new Config with default values
 func DefaultConfig() *Config {
-	homeDir, _ := os.UserHomeDir()
 	return &Config{
-		StorePath:         filepath.Join(homeDir, ".local/share/gopass/stores/root"),
+		Backend: BackendGopass,
+		Age: AgeConfig{
+			Dir: filepath.Join(dataHome(), "gopass-secret-service", "age"),
+		},
 		Prefix:            "secret-service",
 		DefaultCollection: "default",
 		LogLevel:          "info",
 		LogFile:           "",
 		Replace:           false,
+		Watch:             true,
+		Index:             true,
+		ReapInterval:      time.Minute,
+		Decrypt: DecryptConfig{
+			Workers: 4,
+		},
+		Sync: SyncConfig{
+			PushDelay:    30 * time.Second,
+			PullInterval: 15 * time.Minute,
+		},
 	}
 }
 
@@ -80,6 +357,13 @@ func LoadFromFileAndEnv(configPath string) (*Config, error) {
 	// Expand ~ in paths
 	cfg.StorePath = expandPath(cfg.StorePath)
 	cfg.LogFile = expandPath(cfg.LogFile)
+	cfg.Age.Dir = expandPath(cfg.Age.Dir)
+	cfg.Age.Identity = expandPath(cfg.Age.Identity)
+	cfg.Age.PassphraseFile = expandPath(cfg.Age.PassphraseFile)
+
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
 
 	return cfg, nil
 }
@@ -112,11 +396,49 @@ func (c *Config) applyEnv() {
 	if v := os.Getenv("GOPASS_SECRET_SERVICE_REPLACE"); v == "true" || v == "1" {
 		c.Replace = true
 	}
+	if v := os.Getenv("GOPASS_SECRET_SERVICE_WATCH"); v != "" {
+		c.Watch = v == "true" || v == "1"
+	}
+	if v := os.Getenv("GOPASS_SECRET_SERVICE_INDEX"); v != "" {
+		c.Index = v == "true" || v == "1"
+	}
+	if v := os.Getenv("GOPASS_SECRET_SERVICE_DECRYPT_WORKERS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			c.Decrypt.Workers = n
+		}
+	}
+	if v := os.Getenv("GOPASS_SECRET_SERVICE_WARM_UP"); v != "" {
+		c.Decrypt.WarmUp = v == "true" || v == "1"
+	}
+	if v := os.Getenv("GOPASS_SECRET_SERVICE_SYNC"); v != "" {
+		c.Sync.Enabled = v == "true" || v == "1"
+	}
+	if v := os.Getenv("GOPASS_SECRET_SERVICE_BACKEND"); v != "" {
+		c.Backend = v
+	}
+	if v := os.Getenv("GOPASS_SECRET_SERVICE_AGE_DIR"); v != "" {
+		c.Age.Dir = v
+	}
+	if v := os.Getenv("GOPASS_SECRET_SERVICE_AGE_IDENTITY"); v != "" {
+		c.Age.Identity = v
+	}
+	if v := os.Getenv("GOPASS_SECRET_SERVICE_AGE_PASSPHRASE"); v != "" {
+		c.Age.Passphrase = v
+	}
 	if v := os.Getenv("GOPASS_SECRET_SERVICE_BUS_ADDRESS"); v != "" {
 		c.BusAddress = v
 	}
 }
 
+// dataHome returns $XDG_DATA_HOME or its default
+func dataHome() string {
+	if dir := os.Getenv("XDG_DATA_HOME"); dir != "" {
+		return dir
+	}
+	homeDir, _ := os.UserHomeDir()
+	return filepath.Join(homeDir, ".local/share")
+}
+
 func expandPath(path string) string {
 	if path == "" {
 		return path
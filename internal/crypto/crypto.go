@@ -4,6 +4,7 @@ import (
 	"fmt"
 
 	dbtypes "github.com/nikicat/gopass-secret-service/internal/dbus"
+	"github.com/nikicat/gopass-secret-service/internal/secmem"
 )
 
 // Session represents a crypto session for encrypting/decrypting secrets
@@ -14,8 +15,10 @@ type Session interface {
 	// Encrypt encrypts a secret value, returning parameters and ciphertext
 	Encrypt(plaintext []byte) (parameters, ciphertext []byte, err error)
 
-	// Decrypt decrypts a secret value using parameters and ciphertext
-	Decrypt(parameters, ciphertext []byte) (plaintext []byte, err error)
+	// Decrypt decrypts a secret value using parameters and ciphertext. The
+	// plaintext is in a locked buffer, which the caller wipes once the
+	// secret has been stored.
+	Decrypt(parameters, ciphertext []byte) (*secmem.Buffer, error)
 
 	// Close closes the session and releases any resources
 	Close() error
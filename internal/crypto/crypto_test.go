@@ -47,8 +47,9 @@ func TestPlainEncryptDecrypt(t *testing.T) {
 	if err != nil {
 		t.Fatalf("Decrypt failed: %v", err)
 	}
+	defer decrypted.Wipe()
 
-	if !bytes.Equal(decrypted, plaintext) {
+	if !bytes.Equal(decrypted.Bytes(), plaintext) {
 		t.Errorf("Expected decrypted to equal plaintext")
 	}
 }
@@ -78,3 +79,39 @@ func TestSupportedAlgorithms(t *testing.T) {
 		t.Error("Expected 'plain' to be in supported algorithms")
 	}
 }
+
+func TestDHClientServer(t *testing.T) {
+	client, clientPub, err := NewDHClientSession()
+	if err != nil {
+		t.Fatalf("NewDHClientSession failed: %v", err)
+	}
+	defer client.Close()
+
+	server, serverPub, err := NewSession(AlgorithmDHAES, clientPub)
+	if err != nil {
+		t.Fatalf("NewSession failed: %v", err)
+	}
+	defer server.Close()
+
+	if err := client.Complete(serverPub); err != nil {
+		t.Fatalf("Complete failed: %v", err)
+	}
+
+	plaintext := []byte("test secret value")
+	params, ciphertext, err := server.Encrypt(plaintext)
+	if err != nil {
+		t.Fatalf("Encrypt failed: %v", err)
+	}
+	if bytes.Equal(ciphertext, plaintext) {
+		t.Error("Ciphertext equals plaintext")
+	}
+
+	decrypted, err := client.Decrypt(params, ciphertext)
+	if err != nil {
+		t.Fatalf("Decrypt failed: %v", err)
+	}
+	defer decrypted.Wipe()
+	if !bytes.Equal(decrypted.Bytes(), plaintext) {
+		t.Errorf("Expected %q, got %q", plaintext, decrypted.Bytes())
+	}
+}
@@ -9,6 +9,8 @@ import (
 	"math/big"
 
 	"golang.org/x/crypto/hkdf"
+
+	"github.com/nikicat/gopass-secret-service/internal/secmem"
 )
 
 // DH-IETF1024-SHA256-AES128-CBC-PKCS7 algorithm constants
@@ -35,49 +37,83 @@ var (
 type DHSession struct {
 	privateKey *big.Int
 	publicKey  *big.Int
-	aesKey     []byte
+	// aesKey is kept in locked memory for the life of the session.
+	aesKey *secmem.Buffer
 }
 
 // NewDHSession creates a new DH session
 // clientPublic is the client's DH public key (big-endian bytes)
 func NewDHSession(clientPublic []byte) (*DHSession, []byte, error) {
-	// Generate server private key (random 1024 bits)
+	session, err := newDHKeys()
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := session.deriveKey(clientPublic); err != nil {
+		return nil, nil, err
+	}
+	// Return server's public key as output, padded to 128 bytes
+	return session, session.paddedPublic(), nil
+}
+
+// NewDHClientSession starts the client side of a DH session. The returned
+// public key goes to OpenSession; its output must then be passed to
+// Complete before the session can encrypt or decrypt.
+func NewDHClientSession() (*DHSession, []byte, error) {
+	session, err := newDHKeys()
+	if err != nil {
+		return nil, nil, err
+	}
+	return session, session.paddedPublic(), nil
+}
+
+// Complete derives the session key from the server's public key, for a
+// session created with NewDHClientSession.
+func (s *DHSession) Complete(serverPublic []byte) error {
+	return s.deriveKey(serverPublic)
+}
+
+func newDHKeys() (*DHSession, error) {
+	// Generate private key (random 1024 bits)
 	privateKey, err := rand.Int(rand.Reader, dhPrime)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to generate private key: %w", err)
+		return nil, fmt.Errorf("failed to generate private key: %w", err)
 	}
 
-	// Calculate server public key: g^private mod p
+	// Calculate public key: g^private mod p
 	publicKey := new(big.Int).Exp(dhGenerator, privateKey, dhPrime)
+	return &DHSession{privateKey: privateKey, publicKey: publicKey}, nil
+}
 
-	// Calculate shared secret: clientPublic^private mod p
-	clientPub := new(big.Int).SetBytes(clientPublic)
-	sharedSecret := new(big.Int).Exp(clientPub, privateKey, dhPrime)
+// deriveKey sets the AES key from the other side's public key.
+func (s *DHSession) deriveKey(peerPublic []byte) error {
+	// Calculate shared secret: peerPublic^private mod p
+	peerPub := new(big.Int).SetBytes(peerPublic)
+	sharedSecret := new(big.Int).Exp(peerPub, s.privateKey, dhPrime)
 
 	// Pad shared secret to 128 bytes (1024 bits) - spec requires leading zeros
 	sharedBytes := sharedSecret.Bytes()
 	paddedSecret := make([]byte, 128)
 	copy(paddedSecret[128-len(sharedBytes):], sharedBytes)
+	defer secmem.Wipe(paddedSecret)
+	defer secmem.Wipe(sharedBytes)
 
 	// Derive AES key using HKDF-SHA256 with NULL salt and empty info (per spec)
 	hkdfReader := hkdf.New(sha256.New, paddedSecret, nil, nil)
-	aesKey := make([]byte, 16)
-	if _, err := hkdfReader.Read(aesKey); err != nil {
-		return nil, nil, fmt.Errorf("HKDF failed: %w", err)
-	}
-
-	session := &DHSession{
-		privateKey: privateKey,
-		publicKey:  publicKey,
-		aesKey:     aesKey,
+	aesKey := secmem.New(16)
+	if _, err := hkdfReader.Read(aesKey.Bytes()); err != nil {
+		aesKey.Wipe()
+		return fmt.Errorf("HKDF failed: %w", err)
 	}
+	s.aesKey = aesKey
+	return nil
+}
 
-	// Return server's public key as output, padded to 128 bytes
-	pubBytes := publicKey.Bytes()
+// paddedPublic returns the public key padded to 128 bytes.
+func (s *DHSession) paddedPublic() []byte {
+	pubBytes := s.publicKey.Bytes()
 	paddedPub := make([]byte, 128)
 	copy(paddedPub[128-len(pubBytes):], pubBytes)
-
-	return session, paddedPub, nil
+	return paddedPub
 }
 
 // Algorithm returns the algorithm name
@@ -88,14 +124,16 @@ func (s *DHSession) Algorithm() string {
 // Encrypt encrypts plaintext using AES-128-CBC with PKCS7 padding
 // Returns IV as parameters and ciphertext
 func (s *DHSession) Encrypt(plaintext []byte) (parameters, ciphertext []byte, err error) {
-	block, err := aes.NewCipher(s.aesKey)
+	block, err := aes.NewCipher(s.aesKey.Bytes())
 	if err != nil {
 		return nil, nil, err
 	}
 
 	// PKCS7 padding
 	padLen := aes.BlockSize - (len(plaintext) % aes.BlockSize)
-	padded := make([]byte, len(plaintext)+padLen)
+	buf := secmem.New(len(plaintext) + padLen)
+	defer buf.Wipe()
+	padded := buf.Bytes()
 	copy(padded, plaintext)
 	for i := len(plaintext); i < len(padded); i++ {
 		padded[i] = byte(padLen)
@@ -117,7 +155,7 @@ func (s *DHSession) Encrypt(plaintext []byte) (parameters, ciphertext []byte, er
 
 // Decrypt decrypts ciphertext using AES-128-CBC with PKCS7 padding
 // parameters contains the IV
-func (s *DHSession) Decrypt(parameters, ciphertext []byte) (plaintext []byte, err error) {
+func (s *DHSession) Decrypt(parameters, ciphertext []byte) (*secmem.Buffer, error) {
 	if len(parameters) != aes.BlockSize {
 		return nil, fmt.Errorf("invalid IV length: %d", len(parameters))
 	}
@@ -125,20 +163,19 @@ func (s *DHSession) Decrypt(parameters, ciphertext []byte) (plaintext []byte, er
 		return nil, fmt.Errorf("invalid ciphertext length: %d", len(ciphertext))
 	}
 
-	block, err := aes.NewCipher(s.aesKey)
+	block, err := aes.NewCipher(s.aesKey.Bytes())
 	if err != nil {
 		return nil, err
 	}
 
 	// Decrypt
-	decrypted := make([]byte, len(ciphertext))
+	buf := secmem.New(len(ciphertext))
+	defer buf.Wipe()
+	decrypted := buf.Bytes()
 	mode := cipher.NewCBCDecrypter(block, parameters)
 	mode.CryptBlocks(decrypted, ciphertext)
 
 	// Remove PKCS7 padding
-	if len(decrypted) == 0 {
-		return nil, fmt.Errorf("empty decrypted data")
-	}
 	padLen := int(decrypted[len(decrypted)-1])
 	if padLen == 0 || padLen > aes.BlockSize || padLen > len(decrypted) {
 		return nil, fmt.Errorf("invalid padding: padLen=%d", padLen)
@@ -150,14 +187,11 @@ func (s *DHSession) Decrypt(parameters, ciphertext []byte) (plaintext []byte, er
 		}
 	}
 
-	return decrypted[:len(decrypted)-padLen], nil
+	return secmem.Copy(decrypted[:len(decrypted)-padLen]), nil
 }
 
-// Close is a no-op for DH sessions
+// Close wipes the AES key
 func (s *DHSession) Close() error {
-	// Zero out the AES key
-	for i := range s.aesKey {
-		s.aesKey[i] = 0
-	}
+	s.aesKey.Wipe()
 	return nil
 }
@@ -2,6 +2,7 @@ package crypto
 
 import (
 	dbtypes "github.com/nikicat/gopass-secret-service/internal/dbus"
+	"github.com/nikicat/gopass-secret-service/internal/secmem"
 )
 
 // PlainSession implements the "plain" algorithm (no encryption)
@@ -18,14 +19,15 @@ func (s *PlainSession) Algorithm() string {
 	return dbtypes.AlgorithmPlain
 }
 
-// Encrypt returns the plaintext as-is (no encryption)
+// Encrypt returns a copy of the plaintext (no encryption), so the caller can
+// wipe its own buffer before the reply is sent
 func (s *PlainSession) Encrypt(plaintext []byte) (parameters, ciphertext []byte, err error) {
-	return []byte{}, plaintext, nil
+	return []byte{}, append([]byte{}, plaintext...), nil
 }
 
-// Decrypt returns the ciphertext as-is (no decryption)
-func (s *PlainSession) Decrypt(parameters, ciphertext []byte) (plaintext []byte, err error) {
-	return ciphertext, nil
+// Decrypt returns a copy of the ciphertext (no decryption)
+func (s *PlainSession) Decrypt(parameters, ciphertext []byte) (*secmem.Buffer, error) {
+	return secmem.Copy(ciphertext), nil
 }
 
 // Close is a no-op for plain sessions
@@ -13,6 +13,84 @@ type Secret struct {
 	ContentType string
 }
 
+// SyncStatus is the git sync state of one store repository as returned by
+// GopassSecret1.SyncStatus. Times are Unix seconds, 0 for never.
+// Format: (sbxxs)
+type SyncStatus struct {
+	Dir       string
+	Pending   bool
+	LastPull  int64
+	LastPush  int64
+	LastError string
+}
+
+// Revision is one stored version of an item as returned by
+// GopassSecret1.Item.Revisions. Time is in Unix seconds.
+// Format: (sxs) - revision ID, time, commit message
+type Revision struct {
+	ID      string
+	Time    int64
+	Message string
+}
+
+// DuplicateItem is one item of a DuplicateGroup. Times are Unix seconds.
+// Format: (osxx) - item path, label, created, modified
+type DuplicateItem struct {
+	Path     dbus.ObjectPath
+	Label    string
+	Created  int64
+	Modified int64
+}
+
+// DuplicateGroup is a set of items with exactly the same attributes as
+// returned by GopassSecret1.FindDuplicates. Items are newest first.
+// Format: (a{ss}ba(osxx)) - attributes, values differ, items
+type DuplicateGroup struct {
+	Attributes   map[string]string
+	ValuesDiffer bool
+	Items        []DuplicateItem
+}
+
+// Condition is one attribute test of a GopassSecret1.SearchQuery. Op is one
+// of eq, prefix, glob, regex, exists, missing or host.
+// Format: (sss) - attribute, operator, value
+type Condition struct {
+	Key   string
+	Op    string
+	Value string
+}
+
+// ItemInfo is one item found by GopassSecret1.SearchDetails.
+// Format: (osa{ss}b) - item path, label, attributes, locked
+type ItemInfo struct {
+	Path       dbus.ObjectPath
+	Label      string
+	Attributes map[string]string
+	Locked     bool
+}
+
+// ItemSecret is one item returned by GopassSecret1.FetchSecrets, with its
+// secret encrypted for the caller's session.
+// Format: (osa{ss}(oayays)) - item path, label, attributes, secret
+type ItemSecret struct {
+	Path       dbus.ObjectPath
+	Label      string
+	Attributes map[string]string
+	Secret     Secret
+}
+
+// ItemWrite is one item to create or update with GopassSecret1.WriteItems.
+// Collection is a collection or alias path, and Secret is encrypted for the
+// session it names. Replace has the meaning it has for CreateItem.
+// Format: (osa{ss}(oayays)b) - collection, label, attributes, secret, replace
+type ItemWrite struct {
+	Collection dbus.ObjectPath
+	Label      string
+	Attributes map[string]string
+	Secret     Secret
+	Replace    bool
+}
+
 // SecretServiceInterface is the D-Bus interface name for the Secret Service
 const SecretServiceInterface = "org.freedesktop.Secret.Service"
 
@@ -22,6 +100,22 @@ const CollectionInterface = "org.freedesktop.Secret.Collection"
 // ItemInterface is the D-Bus interface name for items
 const ItemInterface = "org.freedesktop.Secret.Item"
 
+// GopassSecretInterface is the D-Bus interface name for this daemon's
+// extensions to the Secret Service API, exported on ServicePath
+const GopassSecretInterface = "io.github.nikicat.GopassSecret1"
+
+// GopassSecretItemInterface is the D-Bus interface name for this daemon's
+// extensions on item objects
+const GopassSecretItemInterface = "io.github.nikicat.GopassSecret1.Item"
+
+// ObjectManagerInterface is the standard D-Bus interface, exported on
+// ServicePath, listing every collection and item with its properties
+const ObjectManagerInterface = "org.freedesktop.DBus.ObjectManager"
+
+// CollectionVolatileProperty is a CreateCollection property that, when true,
+// keeps the new collection in the kernel keyring instead of the durable store
+const CollectionVolatileProperty = "io.github.nikicat.GopassSecret1.Volatile"
+
 // SessionInterface is the D-Bus interface name for sessions
 const SessionInterface = "org.freedesktop.Secret.Session"
 
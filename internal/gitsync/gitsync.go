@@ -0,0 +1,311 @@
+// Package gitsync keeps git-backed password stores in sync with their remotes:
+// it pushes shortly after local writes and pulls on startup and periodically.
+//
+// gopass commits every write locally but its API doesn't expose sync, so this
+// package drives the git CLI directly in each store's directory.
+package gitsync
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// gitTimeout bounds a single git invocation so an unreachable remote can't
+// wedge the scheduler.
+const gitTimeout = 2 * time.Minute
+
+// Options configures a Syncer.
+type Options struct {
+	// PushDelay is how long to wait after the last write before pushing, so a
+	// burst of writes results in a single push.
+	PushDelay time.Duration
+	// PullInterval is the period of background pulls; zero pulls only at
+	// startup and on demand.
+	PullInterval time.Duration
+	// OnPull is called after a pull brought in commits, with the absolute
+	// paths of the files they changed.
+	OnPull func(dir string, files []string)
+	// Logf reports sync failures; defaults to discarding them.
+	Logf func(format string, args ...any)
+}
+
+// Status is the sync state of one repository.
+type Status struct {
+	Dir string
+	// Pending is set while local writes wait to be pushed.
+	Pending  bool
+	LastPull time.Time
+	LastPush time.Time
+	// LastError is the error of the most recent failed operation, cleared by
+	// the next successful one.
+	LastError string
+}
+
+// Syncer schedules pulls and pushes for a set of repositories.
+type Syncer struct {
+	opts  Options
+	repos []*repo
+	byDir map[string]*repo
+	wake  chan struct{}
+}
+
+type repo struct {
+	dir string
+
+	opMu sync.Mutex // serializes git operations on the repository
+
+	mu     sync.Mutex
+	status Status
+	due    time.Time // when pending writes should be pushed
+}
+
+// New creates a Syncer for the given repository directories. Duplicate
+// directories are synced once.
+func New(dirs []string, opts Options) *Syncer {
+	if opts.Logf == nil {
+		opts.Logf = func(string, ...any) {}
+	}
+	s := &Syncer{
+		opts:  opts,
+		byDir: make(map[string]*repo),
+		wake:  make(chan struct{}, 1),
+	}
+	for _, dir := range dirs {
+		dir = filepath.Clean(dir)
+		if _, ok := s.byDir[dir]; ok {
+			continue
+		}
+		r := &repo{dir: dir, status: Status{Dir: dir}}
+		s.repos = append(s.repos, r)
+		s.byDir[dir] = r
+	}
+	return s
+}
+
+// Start pulls every repository and then runs the scheduler until ctx is
+// cancelled.
+func (s *Syncer) Start(ctx context.Context) {
+	go func() {
+		for _, r := range s.repos {
+			s.pull(ctx, r)
+		}
+		s.run(ctx)
+	}()
+}
+
+// Notify records a local write to the repository at dir and schedules a push
+// PushDelay from now. Unknown directories are ignored.
+func (s *Syncer) Notify(dir string) {
+	r, ok := s.byDir[filepath.Clean(dir)]
+	if !ok {
+		return
+	}
+	r.mu.Lock()
+	r.status.Pending = true
+	r.due = time.Now().Add(s.opts.PushDelay)
+	r.mu.Unlock()
+
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// SyncNow pulls and then pushes every repository, returning the combined
+// errors.
+func (s *Syncer) SyncNow(ctx context.Context) error {
+	var errs []error
+	for _, r := range s.repos {
+		if err := s.pull(ctx, r); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if err := s.push(ctx, r); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Status returns the state of every repository.
+func (s *Syncer) Status() []Status {
+	out := make([]Status, 0, len(s.repos))
+	for _, r := range s.repos {
+		r.mu.Lock()
+		out = append(out, r.status)
+		r.mu.Unlock()
+	}
+	return out
+}
+
+func (s *Syncer) run(ctx context.Context) {
+	var pullC <-chan time.Time
+	if s.opts.PullInterval > 0 {
+		ticker := time.NewTicker(s.opts.PullInterval)
+		defer ticker.Stop()
+		pullC = ticker.C
+	}
+	pushTimer := time.NewTimer(time.Hour)
+	pushTimer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-pullC:
+			for _, r := range s.repos {
+				s.pull(ctx, r)
+			}
+		case <-s.wake:
+		case <-pushTimer.C:
+		}
+
+		// Push whatever is due and re-arm the timer for the rest.
+		var next time.Time
+		for _, r := range s.repos {
+			r.mu.Lock()
+			pending, due := r.status.Pending, r.due
+			r.mu.Unlock()
+			if !pending {
+				continue
+			}
+			if !time.Now().Before(due) {
+				s.push(ctx, r)
+				continue
+			}
+			if next.IsZero() || due.Before(next) {
+				next = due
+			}
+		}
+		if !next.IsZero() {
+			pushTimer.Reset(time.Until(next))
+		}
+	}
+}
+
+// pull fetches and rebases local commits onto the upstream branch, then
+// reports the files that changed.
+func (s *Syncer) pull(ctx context.Context, r *repo) error {
+	r.opMu.Lock()
+	defer r.opMu.Unlock()
+
+	before, err := Git(ctx, r.dir, "rev-parse", "HEAD")
+	if err == nil {
+		_, err = Git(ctx, r.dir, "pull", "--rebase", "--quiet")
+	}
+	if err != nil {
+		return s.fail(r, "pull", err)
+	}
+	after, err := Git(ctx, r.dir, "rev-parse", "HEAD")
+	if err != nil {
+		return s.fail(r, "pull", err)
+	}
+
+	r.mu.Lock()
+	r.status.LastPull = time.Now()
+	r.status.LastError = ""
+	r.mu.Unlock()
+
+	if before == after || s.opts.OnPull == nil {
+		return nil
+	}
+	out, err := Git(ctx, r.dir, "diff", "--name-only", "-z", before, after)
+	if err != nil {
+		return s.fail(r, "pull", err)
+	}
+	var files []string
+	for _, name := range strings.Split(out, "\x00") {
+		if name != "" {
+			files = append(files, filepath.Join(r.dir, filepath.FromSlash(name)))
+		}
+	}
+	if len(files) > 0 {
+		s.opts.OnPull(r.dir, files)
+	}
+	return nil
+}
+
+// push sends local commits upstream. A push rejected because the remote moved
+// on is retried once after a pull.
+func (s *Syncer) push(ctx context.Context, r *repo) error {
+	r.mu.Lock()
+	r.status.Pending = false
+	r.mu.Unlock()
+
+	err := s.pushOnce(ctx, r)
+	if err != nil && strings.Contains(err.Error(), "[rejected]") {
+		if err = s.pull(ctx, r); err == nil {
+			err = s.pushOnce(ctx, r)
+		}
+	}
+	if err != nil {
+		// Keep the writes pending so the next write or SyncNow retries.
+		r.mu.Lock()
+		r.status.Pending = true
+		r.mu.Unlock()
+		return s.fail(r, "push", err)
+	}
+
+	r.mu.Lock()
+	r.status.LastPush = time.Now()
+	r.status.LastError = ""
+	r.mu.Unlock()
+	return nil
+}
+
+func (s *Syncer) pushOnce(ctx context.Context, r *repo) error {
+	r.opMu.Lock()
+	defer r.opMu.Unlock()
+	_, err := Git(ctx, r.dir, "push", "--quiet")
+	return err
+}
+
+func (s *Syncer) fail(r *repo, op string, err error) error {
+	err = fmt.Errorf("git %s in %s: %w", op, r.dir, err)
+	r.mu.Lock()
+	r.status.LastError = err.Error()
+	r.mu.Unlock()
+	s.opts.Logf("Warning: %v", err)
+	return err
+}
+
+// Git runs a git command in dir and returns its trimmed standard output. It
+// never prompts: credentials must come from an agent or helper. A command
+// still running after gitTimeout is killed.
+func Git(ctx context.Context, dir string, args ...string) (string, error) {
+	out, err := gitOutput(ctx, dir, args...)
+	return strings.TrimSpace(string(out)), err
+}
+
+// Blob returns the content of the file at path, relative to the top of the
+// repository in dir, as of revision. Unlike Git's output it is returned byte
+// for byte, so it may be binary.
+func Blob(ctx context.Context, dir, revision, path string) ([]byte, error) {
+	return gitOutput(ctx, dir, "cat-file", "blob", revision+":"+path)
+}
+
+func gitOutput(ctx context.Context, dir string, args ...string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, gitTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "git", append([]string{"-C", dir}, args...)...)
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("%w: %s", err, msg)
+		}
+		return nil, err
+	}
+	return stdout.Bytes(), nil
+}
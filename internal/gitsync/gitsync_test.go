@@ -0,0 +1,132 @@
+package gitsync
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+// run runs a git command in dir and fails the test on error.
+func run(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	out, err := Git(context.Background(), dir, args...)
+	if err != nil {
+		t.Fatalf("git %v: %v", args, err)
+	}
+	return out
+}
+
+// newClones creates a bare remote with one commit and n clones of it.
+func newClones(t *testing.T, n int) []string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	t.Setenv("GIT_AUTHOR_NAME", "test")
+	t.Setenv("GIT_AUTHOR_EMAIL", "test@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "test")
+	t.Setenv("GIT_COMMITTER_EMAIL", "test@example.com")
+	t.Setenv("GIT_CONFIG_GLOBAL", os.DevNull)
+
+	root := t.TempDir()
+	remote := filepath.Join(root, "remote.git")
+	run(t, root, "init", "--quiet", "--bare", "--initial-branch=main", remote)
+
+	seed := filepath.Join(root, "seed")
+	run(t, root, "clone", "--quiet", remote, seed)
+	run(t, seed, "checkout", "--quiet", "-b", "main")
+	commitFile(t, seed, ".gpg-id", "key")
+	run(t, seed, "push", "--quiet", "-u", "origin", "main")
+
+	clones := make([]string, n)
+	for i := range clones {
+		clones[i] = filepath.Join(root, "clone"+string(rune('a'+i)))
+		run(t, root, "clone", "--quiet", remote, clones[i])
+	}
+	return clones
+}
+
+func commitFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+	p := filepath.Join(dir, name)
+	if err := os.MkdirAll(filepath.Dir(p), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(p, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	run(t, dir, "add", name)
+	run(t, dir, "commit", "--quiet", "-m", "update "+name)
+}
+
+func TestSyncer_PushesAfterDelayAndReportsPulledFiles(t *testing.T) {
+	clones := newClones(t, 2)
+	a, b := clones[0], clones[1]
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	writer := New([]string{a}, Options{PushDelay: 50 * time.Millisecond})
+	writer.Start(ctx)
+
+	commitFile(t, a, "secret-service/default/x.gpg", "v1")
+	writer.Notify(a)
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		st := writer.Status()[0]
+		if !st.Pending && !st.LastPush.IsZero() {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("no push; status %+v", st)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	var pulled []string
+	reader := New([]string{b}, Options{OnPull: func(_ string, files []string) { pulled = files }})
+	if err := reader.SyncNow(ctx); err != nil {
+		t.Fatalf("SyncNow: %v", err)
+	}
+	want := filepath.Join(b, "secret-service", "default", "x.gpg")
+	if !slices.Equal(pulled, []string{want}) {
+		t.Errorf("pulled files = %v, want [%s]", pulled, want)
+	}
+}
+
+func TestSyncer_PushRetriesAfterRejection(t *testing.T) {
+	clones := newClones(t, 2)
+	a, b := clones[0], clones[1]
+	ctx := context.Background()
+
+	commitFile(t, a, "one.gpg", "a")
+	run(t, a, "push", "--quiet")
+
+	// b is behind and has its own commit: a plain push is rejected.
+	commitFile(t, b, "two.gpg", "b")
+	s := New([]string{b}, Options{})
+	if err := s.push(ctx, s.repos[0]); err != nil {
+		t.Fatalf("push: %v", err)
+	}
+	if st := s.Status()[0]; st.Pending || st.LastError != "" {
+		t.Errorf("status after push = %+v", st)
+	}
+	run(t, a, "pull", "--quiet")
+	if _, err := os.Stat(filepath.Join(a, "two.gpg")); err != nil {
+		t.Errorf("b's commit did not reach the remote: %v", err)
+	}
+}
+
+func TestSyncer_ReportsErrors(t *testing.T) {
+	dir := t.TempDir() // not a repository
+	s := New([]string{dir}, Options{})
+	if err := s.SyncNow(context.Background()); err == nil {
+		t.Fatal("SyncNow outside a repository should fail")
+	}
+	if st := s.Status()[0]; st.LastError == "" {
+		t.Errorf("LastError not set: %+v", st)
+	}
+}
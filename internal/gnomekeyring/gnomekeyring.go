@@ -0,0 +1,283 @@
+// Package gnomekeyring reads the binary .keyring files GNOME Keyring keeps in
+// ~/.local/share/keyrings, so their items can be imported into another store.
+//
+// A file holds the keyring's name and times in the clear, followed by its
+// items encrypted with AES-128-CBC under a key derived from the keyring
+// password with iterated SHA-256. Only the attribute hashes are stored in the
+// clear, so everything worth importing needs the password.
+package gnomekeyring
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+// magic starts every binary keyring file.
+const magic = "GnomeKeyring\n\r\x00\n"
+
+// Item types, stored with each item. Files written by older versions carry
+// no xdg:schema attribute, so the type is what tells them apart.
+const (
+	TypeGenericSecret          = 0
+	TypeNetworkPassword        = 1
+	TypeNote                   = 2
+	TypeChainedKeyringPassword = 3
+	TypeEncryptionKeyPassword  = 4
+	TypePKStorage              = 0x100
+)
+
+// schemas maps item types to the schema GNOME Keyring gives them over the
+// Secret Service API.
+var schemas = map[uint32]string{
+	TypeGenericSecret:          "org.freedesktop.Secret.Generic",
+	TypeNetworkPassword:        "org.gnome.keyring.NetworkPassword",
+	TypeNote:                   "org.gnome.keyring.Note",
+	TypeChainedKeyringPassword: "org.gnome.keyring.ChainedKeyring",
+	TypeEncryptionKeyPassword:  "org.gnome.keyring.EncryptionKey",
+	TypePKStorage:              "org.gnome.keyring.PkStorage",
+}
+
+// ErrBadPassword is returned when the keyring doesn't decrypt with the given
+// password.
+var ErrBadPassword = errors.New("incorrect keyring password")
+
+// Keyring is the content of a keyring file.
+type Keyring struct {
+	Name     string
+	Created  time.Time
+	Modified time.Time
+	Items    []Item
+}
+
+// Item is one secret of a keyring.
+type Item struct {
+	ID     uint32
+	Type   uint32
+	Label  string
+	Secret []byte
+	// Attributes hold the item's attributes, with integer ones formatted in
+	// decimal as libsecret does, and xdg:schema set from Type if it was
+	// missing.
+	Attributes map[string]string
+	Created    time.Time
+	Modified   time.Time
+}
+
+// ReadFile reads and decrypts the keyring file at path.
+func ReadFile(path string, password []byte) (*Keyring, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data, password)
+}
+
+// Parse decrypts a keyring file's content.
+func Parse(data, password []byte) (*Keyring, error) {
+	r := &reader{buf: data}
+	if string(r.bytes(len(magic))) != magic {
+		return nil, errors.New("not a GNOME Keyring file")
+	}
+	version := r.bytes(2)
+	algos := r.bytes(2)
+	if r.err != nil {
+		return nil, r.err
+	}
+	if version[0] != 0 || version[1] != 0 {
+		return nil, fmt.Errorf("unsupported keyring version %d.%d", version[0], version[1])
+	}
+	if algos[0] != 0 || algos[1] != 0 {
+		return nil, fmt.Errorf("unsupported keyring cipher %d or hash %d", algos[0], algos[1])
+	}
+
+	kr := &Keyring{}
+	kr.Name = r.string()
+	kr.Created = r.time()
+	kr.Modified = r.time()
+	r.uint32() // flags
+	r.uint32() // lock timeout
+	iterations := r.uint32()
+	salt := r.bytes(8)
+	for range 4 {
+		r.uint32() // reserved
+	}
+
+	// The clear part of each item holds its ID, type and attribute hashes;
+	// the rest is in the encrypted block, in the same order.
+	n := r.count()
+	items := make([]Item, 0, n)
+	for range n {
+		item := Item{ID: r.uint32(), Type: r.uint32()}
+		for range r.count() {
+			r.string()
+			if r.uint32() == 0 {
+				r.string()
+			} else {
+				r.uint32()
+			}
+		}
+		items = append(items, item)
+	}
+	encrypted := r.bytes(int(r.uint32()))
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	plain, err := decrypt(encrypted, password, salt, iterations)
+	if err != nil {
+		return nil, err
+	}
+	r = &reader{buf: plain}
+	for i := range items {
+		item := &items[i]
+		item.Label = r.string()
+		item.Secret = []byte(r.string())
+		item.Created = r.time()
+		item.Modified = r.time()
+		r.string() // reserved
+		for range 4 {
+			r.uint32() // reserved
+		}
+		item.Attributes = r.attributes()
+		if _, ok := item.Attributes["xdg:schema"]; !ok {
+			if schema, ok := schemas[item.Type]; ok {
+				item.Attributes["xdg:schema"] = schema
+			}
+		}
+		// Access control entries name the applications allowed to read
+		// the item; the Secret Service API has no equivalent.
+		for range r.count() {
+			r.uint32()
+			r.string()
+			r.string()
+			r.string()
+			r.uint32()
+		}
+		if r.err != nil {
+			return nil, fmt.Errorf("item %d: %w", item.ID, r.err)
+		}
+	}
+	kr.Items = items
+	return kr, nil
+}
+
+// decrypt decrypts the item block and checks the MD5 sum it starts with,
+// which is how a wrong password shows.
+func decrypt(data, password, salt []byte, iterations uint32) ([]byte, error) {
+	if len(data) < 2*aes.BlockSize || len(data)%aes.BlockSize != 0 {
+		return nil, fmt.Errorf("corrupt keyring: encrypted block of %d bytes", len(data))
+	}
+	if iterations == 0 {
+		iterations = 1
+	}
+	key, iv := deriveKey(password, salt, iterations)
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	plain := make([]byte, len(data))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(plain, data)
+
+	sum := md5.Sum(plain[md5.Size:])
+	if !bytes.Equal(sum[:], plain[:md5.Size]) {
+		return nil, ErrBadPassword
+	}
+	return plain[md5.Size:], nil
+}
+
+// deriveKey derives the AES key and IV: SHA-256 of the password and salt,
+// rehashed iterations-1 more times, split in half.
+func deriveKey(password, salt []byte, iterations uint32) (key, iv []byte) {
+	h := sha256.New()
+	h.Write(password)
+	h.Write(salt)
+	digest := h.Sum(nil)
+	for i := uint32(1); i < iterations; i++ {
+		sum := sha256.Sum256(digest)
+		digest = sum[:]
+	}
+	return digest[:16], digest[16:32]
+}
+
+// reader decodes the big-endian fields of a keyring file. The first error
+// sticks and makes every later read return zero values.
+type reader struct {
+	buf []byte
+	err error
+}
+
+func (r *reader) bytes(n int) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if n < 0 || n > len(r.buf) {
+		r.err = errors.New("corrupt keyring: unexpected end of data")
+		return nil
+	}
+	b := r.buf[:n]
+	r.buf = r.buf[n:]
+	return b
+}
+
+func (r *reader) uint32() uint32 {
+	b := r.bytes(4)
+	if b == nil {
+		return 0
+	}
+	return binary.BigEndian.Uint32(b)
+}
+
+// count reads a number of entries that follow, bounded by the data left so
+// a corrupt count can't make a caller loop for long.
+func (r *reader) count() uint32 {
+	n := r.uint32()
+	if uint64(n) > uint64(len(r.buf)) {
+		r.err = fmt.Errorf("corrupt keyring: count %d", n)
+		return 0
+	}
+	return n
+}
+
+// string reads a length-prefixed string; a length of 0xffffffff is NULL and
+// reads as "".
+func (r *reader) string() string {
+	n := r.uint32()
+	if n == 0xffffffff {
+		return ""
+	}
+	return string(r.bytes(int(n)))
+}
+
+// time reads seconds since the epoch stored as two 32-bit halves.
+func (r *reader) time() time.Time {
+	hi, lo := r.uint32(), r.uint32()
+	secs := int64(hi)<<32 | int64(lo)
+	if secs == 0 {
+		return time.Time{}
+	}
+	return time.Unix(secs, 0)
+}
+
+// attributes reads an attribute list with full values.
+func (r *reader) attributes() map[string]string {
+	n := r.count()
+	attrs := make(map[string]string, n)
+	for range n {
+		name := r.string()
+		if r.uint32() == 0 {
+			attrs[name] = r.string()
+		} else {
+			attrs[name] = strconv.FormatUint(uint64(r.uint32()), 10)
+		}
+	}
+	return attrs
+}
@@ -0,0 +1,204 @@
+package gnomekeyring
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/md5"
+	"encoding/binary"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/nikicat/gopass-secret-service/internal/store"
+)
+
+// writer encodes keyring fields the way GNOME Keyring writes them.
+type writer struct{ bytes.Buffer }
+
+func (w *writer) uint32(v uint32) { binary.Write(&w.Buffer, binary.BigEndian, v) }
+
+func (w *writer) string(s string) {
+	w.uint32(uint32(len(s)))
+	w.WriteString(s)
+}
+
+func (w *writer) time(t time.Time) {
+	secs := uint64(t.Unix())
+	w.uint32(uint32(secs >> 32))
+	w.uint32(uint32(secs))
+}
+
+type testItem struct {
+	typ            uint32
+	label, secret  string
+	attrs          map[string]string
+	intAttrs       map[string]uint32
+	created, mtime time.Time
+}
+
+// encode builds a keyring file holding items, encrypted with password.
+func encode(t *testing.T, name, password string, items []testItem) []byte {
+	t.Helper()
+	salt := []byte("saltsalt")
+	const iterations = 1000
+
+	var inner writer
+	for _, item := range items {
+		inner.string(item.label)
+		inner.string(item.secret)
+		inner.time(item.created)
+		inner.time(item.mtime)
+		inner.uint32(0xffffffff) // NULL reserved string
+		for range 4 {
+			inner.uint32(0)
+		}
+		inner.uint32(uint32(len(item.attrs) + len(item.intAttrs)))
+		for k, v := range item.attrs {
+			inner.string(k)
+			inner.uint32(0)
+			inner.string(v)
+		}
+		for k, v := range item.intAttrs {
+			inner.string(k)
+			inner.uint32(1)
+			inner.uint32(v)
+		}
+		inner.uint32(1) // one ACL entry
+		inner.uint32(3)
+		inner.string("app")
+		inner.string("/usr/bin/app")
+		inner.uint32(0xffffffff)
+		inner.uint32(0)
+	}
+	for (md5.Size+inner.Len())%aes.BlockSize != 0 {
+		inner.WriteByte(0)
+	}
+	sum := md5.Sum(inner.Bytes())
+	plain := append(sum[:], inner.Bytes()...)
+	key, iv := deriveKey([]byte(password), salt, iterations)
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	encrypted := make([]byte, len(plain))
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(encrypted, plain)
+
+	var w writer
+	w.WriteString(magic)
+	w.Write([]byte{0, 0, 0, 0})
+	w.string(name)
+	w.time(time.Unix(1600000000, 0))
+	w.time(time.Unix(1700000000, 0))
+	w.uint32(0)
+	w.uint32(0)
+	w.uint32(iterations)
+	w.Write(salt)
+	for range 4 {
+		w.uint32(0)
+	}
+	w.uint32(uint32(len(items)))
+	for i, item := range items {
+		w.uint32(uint32(i + 1))
+		w.uint32(item.typ)
+		w.uint32(uint32(len(item.attrs)))
+		for k := range item.attrs {
+			w.string(k)
+			w.uint32(0)
+			w.string("hash-of-" + k)
+		}
+	}
+	w.uint32(uint32(len(encrypted)))
+	w.Write(encrypted)
+	return w.Bytes()
+}
+
+var testItems = []testItem{
+	{
+		typ: TypeNetworkPassword, label: "GitHub", secret: "hunter2",
+		attrs:    map[string]string{"server": "github.com", "user": "me"},
+		intAttrs: map[string]uint32{"port": 443},
+		created:  time.Unix(1600000100, 0), mtime: time.Unix(1650000000, 0),
+	},
+	{
+		typ: TypeGenericSecret, label: "Wi-Fi", secret: "correct horse",
+		attrs:   map[string]string{"xdg:schema": "org.freedesktop.NetworkManager.Connection", "ssid": "home"},
+		created: time.Unix(1600000200, 0), mtime: time.Unix(1600000200, 0),
+	},
+}
+
+func TestParse(t *testing.T) {
+	data := encode(t, "Login", "pw", testItems)
+
+	kr, err := Parse(data, []byte("pw"))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if kr.Name != "Login" || !kr.Modified.Equal(time.Unix(1700000000, 0)) {
+		t.Errorf("keyring = %q modified %v", kr.Name, kr.Modified)
+	}
+	if len(kr.Items) != 2 {
+		t.Fatalf("got %d items, want 2", len(kr.Items))
+	}
+	gh := kr.Items[0]
+	if gh.Label != "GitHub" || string(gh.Secret) != "hunter2" || gh.Type != TypeNetworkPassword {
+		t.Errorf("item = %+v", gh)
+	}
+	if gh.Attributes["port"] != "443" || gh.Attributes["server"] != "github.com" ||
+		gh.Attributes["xdg:schema"] != "org.gnome.keyring.NetworkPassword" {
+		t.Errorf("attributes = %v", gh.Attributes)
+	}
+	if !gh.Created.Equal(testItems[0].created) || !gh.Modified.Equal(testItems[0].mtime) {
+		t.Errorf("times = %v, %v", gh.Created, gh.Modified)
+	}
+	if got := kr.Items[1].Attributes["xdg:schema"]; got != "org.freedesktop.NetworkManager.Connection" {
+		t.Errorf("stored schema replaced by %q", got)
+	}
+
+	if _, err := Parse(data, []byte("wrong")); !errors.Is(err, ErrBadPassword) {
+		t.Errorf("Parse with a wrong password = %v, want ErrBadPassword", err)
+	}
+	if _, err := Parse(data[:len(data)-20], []byte("pw")); err == nil {
+		t.Error("Parse of a truncated file succeeded")
+	}
+	if _, err := Parse([]byte("[keyring]\n"), nil); err == nil {
+		t.Error("Parse of a non-keyring file succeeded")
+	}
+}
+
+func TestImport(t *testing.T) {
+	ctx := context.Background()
+	s, err := store.NewAgeStoreWithPassphrase(t.TempDir(), "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	kr, err := Parse(encode(t, "Login", "pw", testItems), []byte("pw"))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	imported, skipped, err := Import(ctx, s, "login", kr)
+	if err != nil || imported != 2 || skipped != 0 {
+		t.Fatalf("Import = %d, %d, %v", imported, skipped, err)
+	}
+	if c, err := s.GetCollection(ctx, "login"); err != nil || c.Label != "Login" {
+		t.Errorf("collection = %+v, %v", c, err)
+	}
+	items, err := s.SearchItems(ctx, "login", map[string]string{"server": "github.com"})
+	if err != nil || len(items) != 1 {
+		t.Fatalf("SearchItems = %v, %v", items, err)
+	}
+	got, err := s.GetItem(ctx, "login", items[0].ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Label != "GitHub" || string(got.Secret) != "hunter2" ||
+		!got.Created.Equal(testItems[0].created) || !got.Modified.Equal(testItems[0].mtime) {
+		t.Errorf("imported item = %+v", got)
+	}
+
+	if imported, skipped, err := Import(ctx, s, "login", kr); err != nil || imported != 0 || skipped != 2 {
+		t.Errorf("second Import = %d, %d, %v; want everything skipped", imported, skipped, err)
+	}
+}
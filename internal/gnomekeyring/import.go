@@ -0,0 +1,38 @@
+package gnomekeyring
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/nikicat/gopass-secret-service/internal/store"
+)
+
+// Import adds the items of kr to collection in s, creating the collection
+// with the keyring's name as label if needed. Labels, attributes and times
+// are kept. Items already there (see store.FindItem) are skipped, so an
+// interrupted import can be run again.
+func Import(ctx context.Context, s store.Store, collection string, kr *Keyring) (imported, skipped int, err error) {
+	if _, err := s.GetCollection(ctx, collection); err != nil {
+		if err := s.CreateCollection(ctx, collection, kr.Name); err != nil {
+			return 0, 0, fmt.Errorf("create collection %s: %w", collection, err)
+		}
+	}
+	for _, item := range kr.Items {
+		created, err := store.CreateItemOnce(ctx, s, collection, &store.ItemData{
+			Label:      item.Label,
+			Secret:     item.Secret,
+			Attributes: item.Attributes,
+			Created:    item.Created,
+			Modified:   item.Modified,
+		})
+		if err != nil {
+			return imported, skipped, fmt.Errorf("import %q: %w", item.Label, err)
+		}
+		if !created {
+			skipped++
+			continue
+		}
+		imported++
+	}
+	return imported, skipped, nil
+}
@@ -0,0 +1,83 @@
+// Package secmem keeps secret bytes out of swap and core dumps, and wipes
+// them once they have been used.
+//
+// Go gives no control over copies the runtime or other libraries make, so
+// this is hygiene rather than a guarantee: the daemon wipes every buffer it
+// owns as soon as the secret in it has been handed on, and keeps the longer
+// lived ones in locked memory.
+package secmem
+
+import (
+	"runtime"
+
+	"golang.org/x/sys/unix"
+)
+
+// Buffer is a fixed-size byte buffer for a secret. Where the system allows
+// it, the buffer has its own memory mapping, locked so it is never swapped
+// out and excluded from core dumps; otherwise it falls back to the Go heap.
+// A Buffer must be wiped when done with.
+type Buffer struct {
+	b      []byte
+	mapped bool
+}
+
+// New returns a zeroed buffer of n bytes.
+func New(n int) *Buffer {
+	if n == 0 {
+		return &Buffer{b: []byte{}}
+	}
+	b, err := unix.Mmap(-1, 0, n, unix.PROT_READ|unix.PROT_WRITE, unix.MAP_PRIVATE|unix.MAP_ANONYMOUS)
+	if err != nil {
+		return &Buffer{b: make([]byte, n)}
+	}
+	// RLIMIT_MEMLOCK is often small; an unlocked mapping is still kept
+	// out of core dumps.
+	_ = unix.Mlock(b)
+	_ = unix.Madvise(b, unix.MADV_DONTDUMP)
+	return &Buffer{b: b, mapped: true}
+}
+
+// Copy returns a buffer holding a copy of src.
+func Copy(src []byte) *Buffer {
+	b := New(len(src))
+	copy(b.b, src)
+	return b
+}
+
+// Bytes returns the buffer's content, or nil for a nil or wiped buffer. The
+// slice must not be used after Wipe.
+func (b *Buffer) Bytes() []byte {
+	if b == nil {
+		return nil
+	}
+	return b.b
+}
+
+// Wipe zeroes the buffer and releases its memory. It is safe to call more
+// than once.
+func (b *Buffer) Wipe() {
+	if b == nil || b.b == nil {
+		return
+	}
+	Wipe(b.b)
+	if b.mapped {
+		_ = unix.Munlock(b.b)
+		_ = unix.Munmap(b.b)
+	}
+	b.b = nil
+}
+
+// Wipe zeroes p, for secrets held in ordinary slices.
+func Wipe(p []byte) {
+	clear(p)
+	// Keep the compiler from treating the stores as dead.
+	runtime.KeepAlive(p)
+}
+
+// DisableCoreDumps marks the process as not dumpable, so it leaves no core
+// file and other processes of the user can't ptrace it or read its memory
+// through /proc.
+func DisableCoreDumps() error {
+	return unix.Prctl(unix.PR_SET_DUMPABLE, 0, 0, 0, 0)
+}
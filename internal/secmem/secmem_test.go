@@ -0,0 +1,35 @@
+package secmem
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestBuffer(t *testing.T) {
+	b := Copy([]byte("hunter2"))
+	if got := b.Bytes(); !bytes.Equal(got, []byte("hunter2")) {
+		t.Fatalf("Bytes = %q, want hunter2", got)
+	}
+	b.Wipe()
+	if b.Bytes() != nil {
+		t.Errorf("Bytes after Wipe = %q, want nil", b.Bytes())
+	}
+	b.Wipe()
+
+	var nilBuf *Buffer
+	nilBuf.Wipe()
+	if nilBuf.Bytes() != nil {
+		t.Error("nil buffer has bytes")
+	}
+	if empty := New(0); len(empty.Bytes()) != 0 {
+		t.Errorf("New(0) has %d bytes", len(empty.Bytes()))
+	}
+}
+
+func TestWipe(t *testing.T) {
+	p := []byte("hunter2")
+	Wipe(p)
+	if !bytes.Equal(p, make([]byte, len(p))) {
+		t.Errorf("Wipe left %q", p)
+	}
+}
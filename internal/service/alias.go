@@ -0,0 +1,125 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"maps"
+	"slices"
+
+	"github.com/godbus/dbus/v5"
+
+	dbtypes "github.com/nikicat/gopass-secret-service/internal/dbus"
+	"github.com/nikicat/gopass-secret-service/internal/store"
+)
+
+// exportAlias makes an alias path serve a collection, replacing whatever
+// collection the alias pointed to before.
+func (s *Service) exportAlias(alias string, coll *Collection) {
+	s.aliasMu.Lock()
+	defer s.aliasMu.Unlock()
+	if s.aliases == nil {
+		s.aliases = make(map[string]string)
+	}
+	s.aliases[alias] = coll.name
+}
+
+// unexportAlias removes an alias path from the bus.
+func (s *Service) unexportAlias(alias string) {
+	s.aliasMu.Lock()
+	defer s.aliasMu.Unlock()
+	s.unexportAliasLocked(alias)
+}
+
+func (s *Service) unexportAliasLocked(alias string) {
+	delete(s.aliases, alias)
+}
+
+// unexportAliasesOf removes the alias paths of a collection that went away.
+// The aliases stay in the store's table, so they come back with the
+// collection.
+func (s *Service) unexportAliasesOf(collection string) {
+	s.aliasMu.Lock()
+	defer s.aliasMu.Unlock()
+	for alias, coll := range s.aliases {
+		if coll == collection {
+			s.unexportAliasLocked(alias)
+		}
+	}
+}
+
+// exportAliases exports every alias in the store's alias table whose
+// collection is exported. The default and session aliases are exported by
+// ensureDefaultCollection and ensureSessionCollection.
+func (s *Service) exportAliases() {
+	lister, ok := s.store.(store.AliasLister)
+	if !ok {
+		return
+	}
+	aliases, err := lister.Aliases(context.Background())
+	if err != nil {
+		if !errors.Is(err, store.ErrAliasesUnsupported) {
+			log.Printf("Warning: failed to list aliases: %v", err)
+		}
+		return
+	}
+	for alias, name := range aliases {
+		if alias == "default" || alias == "session" {
+			continue
+		}
+		coll, ok := s.collections.Get(name)
+		if !ok {
+			continue
+		}
+		s.exportAlias(alias, coll)
+	}
+}
+
+// ListAliases returns every exported alias with the path of its collection.
+func (e *gopassSecret) ListAliases() (map[string]dbus.ObjectPath, *dbus.Error) {
+	s := e.svc
+	s.aliasMu.Lock()
+	defer s.aliasMu.Unlock()
+	out := make(map[string]dbus.ObjectPath, len(s.aliases))
+	for alias, coll := range s.aliases {
+		out[alias] = dbtypes.CollectionPath(coll)
+	}
+	return out, nil
+}
+
+// aliasesOf returns the aliases of a collection, exported or only in the
+// store's alias table.
+func (s *Service) aliasesOf(ctx context.Context, collection string) []string {
+	set := make(map[string]bool)
+	if lister, ok := s.store.(store.AliasLister); ok {
+		if aliases, err := lister.Aliases(ctx); err == nil {
+			for alias, coll := range aliases {
+				if coll == collection {
+					set[alias] = true
+				}
+			}
+		}
+	}
+	s.aliasMu.Lock()
+	for alias, coll := range s.aliases {
+		if coll == collection {
+			set[alias] = true
+		}
+	}
+	s.aliasMu.Unlock()
+	return slices.Sorted(maps.Keys(set))
+}
+
+// retargetAliases points aliases at a renamed collection, in the store's
+// alias table and on the bus.
+func (s *Service) retargetAliases(ctx context.Context, aliases []string, coll *Collection) {
+	for _, alias := range aliases {
+		if alias != "session" {
+			if err := s.store.SetAlias(ctx, alias, coll.name); err != nil {
+				log.Printf("Warning: failed to move alias %s to %s: %v", alias, coll.name, err)
+				continue
+			}
+		}
+		s.exportAlias(alias, coll)
+	}
+}
@@ -0,0 +1,292 @@
+package service
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"log"
+	"maps"
+	"slices"
+
+	"github.com/godbus/dbus/v5"
+
+	dbtypes "github.com/nikicat/gopass-secret-service/internal/dbus"
+	"github.com/nikicat/gopass-secret-service/internal/secmem"
+	"github.com/nikicat/gopass-secret-service/internal/store"
+)
+
+// The batch methods serve tools that sync many secrets at once: with the
+// spec API they need SearchItems, a Properties.GetAll per item and
+// GetSecrets, i.e. a round trip per item.
+
+// queryResult is one item found by a query, with the collection it's in.
+type queryResult struct {
+	collection string
+	item       *store.ItemData
+	locked     bool
+}
+
+// runQuery returns the items matching conditions in collection, or in every
+// collection if it is empty, ordered by path.
+func (s *Service) runQuery(ctx context.Context, collection string, conditions []dbtypes.Condition) ([]queryResult, *dbus.Error) {
+	q, derr := compileConditions(conditions)
+	if derr != nil {
+		return nil, derr
+	}
+	results, err := store.SearchQuery(ctx, s.store, collection, q)
+	if err != nil {
+		return nil, ErrObjectNotFound(err.Error())
+	}
+
+	var out []queryResult
+	for collName, items := range results {
+		collData, _ := s.store.GetCollection(ctx, collName)
+		locked := collData != nil && collData.Locked
+		for _, item := range items {
+			out = append(out, queryResult{collection: collName, item: item, locked: locked})
+		}
+	}
+	slices.SortFunc(out, func(a, b queryResult) int {
+		return cmp.Compare(dbtypes.ItemPath(a.collection, a.item.ID), dbtypes.ItemPath(b.collection, b.item.ID))
+	})
+	return out, nil
+}
+
+// SearchDetails is SearchQuery returning each item's label, attributes and
+// whether its collection is locked along with its path.
+func (e *gopassSecret) SearchDetails(collection string, conditions []dbtypes.Condition) ([]dbtypes.ItemInfo, *dbus.Error) {
+	s := e.svc
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	results, derr := s.runQuery(context.Background(), collection, conditions)
+	if derr != nil {
+		return nil, derr
+	}
+	out := make([]dbtypes.ItemInfo, 0, len(results))
+	for _, r := range results {
+		out = append(out, dbtypes.ItemInfo{
+			Path:       dbtypes.ItemPath(r.collection, r.item.ID),
+			Label:      r.item.Label,
+			Attributes: nonNilAttributes(r.item.Attributes),
+			Locked:     r.locked,
+		})
+	}
+	return out, nil
+}
+
+// FetchSecrets returns the items matching conditions, like SearchDetails,
+// with their secrets encrypted for session as GetSecrets would. Items that
+// can't be read are left out.
+func (e *gopassSecret) FetchSecrets(collection string, conditions []dbtypes.Condition, session dbus.ObjectPath) ([]dbtypes.ItemSecret, *dbus.Error) {
+	s := e.svc
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	sess, ok := s.sessions.GetSession(session)
+	if !ok {
+		return nil, ErrSessionNotFound("session not found")
+	}
+
+	ctx := context.Background()
+	results, derr := s.runQuery(ctx, collection, conditions)
+	if derr != nil {
+		return nil, derr
+	}
+
+	// Search results may carry only metadata; read the secrets in parallel
+	// as GetSecrets does.
+	fetched := make([]*store.ItemData, len(results))
+	s.pool.Each(ctx, len(results), func(i int) {
+		if item, err := s.store.GetItem(ctx, results[i].collection, results[i].item.ID); err == nil {
+			fetched[i] = item
+		}
+	})
+
+	out := make([]dbtypes.ItemSecret, 0, len(results))
+	for i, r := range results {
+		item := fetched[i]
+		if item == nil {
+			continue
+		}
+		params, ciphertext, err := sess.Encrypt(item.Secret)
+		secmem.Wipe(item.Secret)
+		if err != nil {
+			continue
+		}
+		out = append(out, dbtypes.ItemSecret{
+			Path:       dbtypes.ItemPath(r.collection, item.ID),
+			Label:      item.Label,
+			Attributes: nonNilAttributes(item.Attributes),
+			Secret: dbtypes.Secret{
+				Session:     session,
+				Parameters:  params,
+				Value:       ciphertext,
+				ContentType: item.ContentType,
+			},
+		})
+	}
+	return out, nil
+}
+
+func nonNilAttributes(attrs map[string]string) map[string]string {
+	if attrs == nil {
+		return map[string]string{}
+	}
+	return attrs
+}
+
+// batchWrite is a write WriteItems has made, and what undoes it.
+type batchWrite struct {
+	collection string
+	id         string
+	created    bool
+	// item is the item as created, for ItemCreated.
+	item *store.ItemData
+	// previous is the item before an update, nil if it wasn't updated.
+	previous *store.ItemData
+}
+
+// WriteItems creates or updates items, each as CreateItem would: an item of
+// the collection with exactly the same attributes is updated if Replace is
+// set and left alone otherwise. It returns the items' paths in order.
+//
+// Either every item is written or none is: the collections are resolved and
+// the secrets decrypted before the first write, and if a write fails the ones
+// before it are undone.
+func (e *gopassSecret) WriteItems(items []dbtypes.ItemWrite) ([]dbus.ObjectPath, *dbus.Error) {
+	s := e.svc
+
+	collections := make([]string, len(items))
+	targets := make(map[string]*Collection)
+	plaintexts := make([]*secmem.Buffer, len(items))
+	defer func() {
+		for _, p := range plaintexts {
+			p.Wipe()
+		}
+	}()
+	for i, w := range items {
+		coll, derr := s.collectionAt(w.Collection)
+		if derr != nil {
+			return nil, derr
+		}
+		session, ok := s.sessions.GetSession(w.Secret.Session)
+		if !ok {
+			return nil, ErrSessionNotFound("session not found")
+		}
+		plaintext, err := session.Decrypt(w.Secret.Parameters, w.Secret.Value)
+		if err != nil {
+			return nil, ErrUnsupported(fmt.Sprintf("item %d: %v", i, err))
+		}
+		collections[i] = coll.name
+		targets[coll.name] = coll
+		plaintexts[i] = plaintext
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// CreateItem looks for an item with the same attributes and creates one
+	// under its collection's lock; take the same locks, in name order so
+	// concurrent batches can't deadlock.
+	for _, name := range slices.Sorted(maps.Keys(targets)) {
+		targets[name].mu.Lock()
+		defer targets[name].mu.Unlock()
+	}
+	s.items.writeMu.Lock()
+	defer s.items.writeMu.Unlock()
+
+	ctx := context.Background()
+	done := make([]batchWrite, 0, len(items))
+	defer func() {
+		for _, w := range done {
+			if w.previous != nil {
+				secmem.Wipe(w.previous.Secret)
+			}
+		}
+	}()
+	for i, w := range items {
+		bw, err := s.writeItem(ctx, collections[i], w, plaintexts[i].Bytes())
+		if err != nil {
+			s.undoWrites(ctx, done)
+			return nil, ErrUnsupported(fmt.Sprintf("item %d: %v", i, err))
+		}
+		done = append(done, bw)
+	}
+
+	paths := make([]dbus.ObjectPath, len(done))
+	grown := make(map[string]bool)
+	for i, w := range done {
+		path := dbtypes.ItemPath(w.collection, w.id)
+		paths[i] = path
+		switch {
+		case w.created:
+			s.items.Add(w.collection, w.id)
+			s.emitItemCreated(w.collection, w.id, w.item)
+			grown[w.collection] = true
+		case w.previous != nil:
+			s.emitItemChanged(w.collection, path)
+		}
+	}
+	for name := range grown {
+		if coll, ok := s.collections.Get(name); ok {
+			coll.refreshItems()
+		}
+	}
+	return paths, nil
+}
+
+// writeItem makes one write of WriteItems.
+func (s *Service) writeItem(ctx context.Context, collection string, w dbtypes.ItemWrite, plaintext []byte) (batchWrite, error) {
+	existing := s.itemWithAttributes(ctx, collection, w.Attributes)
+	if existing != nil && !w.Replace {
+		return batchWrite{collection: collection, id: existing.ID}, nil
+	}
+
+	if existing != nil {
+		previous, err := s.store.GetItem(ctx, collection, existing.ID)
+		if err != nil {
+			return batchWrite{}, err
+		}
+		updated := *previous
+		updated.Secret = plaintext
+		updated.ContentType = w.Secret.ContentType
+		if w.Label != "" {
+			updated.Label = w.Label
+		}
+		if err := s.store.UpdateItem(ctx, collection, existing.ID, &updated); err != nil {
+			secmem.Wipe(previous.Secret)
+			return batchWrite{}, err
+		}
+		return batchWrite{collection: collection, id: existing.ID, previous: previous}, nil
+	}
+
+	item := &store.ItemData{
+		ID:          newItemID(),
+		Label:       w.Label,
+		Secret:      plaintext,
+		ContentType: w.Secret.ContentType,
+		Attributes:  nonNilAttributes(w.Attributes),
+	}
+	id, err := s.store.CreateItem(ctx, collection, item)
+	if err != nil {
+		return batchWrite{}, err
+	}
+	return batchWrite{collection: collection, id: id, created: true, item: item}, nil
+}
+
+// undoWrites reverts the writes of a failed WriteItems, newest first.
+// Failures are only logged: the call is failing already.
+func (s *Service) undoWrites(ctx context.Context, done []batchWrite) {
+	for _, w := range slices.Backward(done) {
+		var err error
+		switch {
+		case w.created:
+			err = s.store.DeleteItem(ctx, w.collection, w.id)
+		case w.previous != nil:
+			err = s.store.UpdateItem(ctx, w.collection, w.id, w.previous)
+		}
+		if err != nil {
+			log.Printf("Warning: failed to undo write of %s/%s: %v", w.collection, w.id, err)
+		}
+	}
+}
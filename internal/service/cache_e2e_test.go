@@ -210,3 +210,63 @@ func TestE2E_CacheLifecycleOverDBus(t *testing.T) {
 		t.Fatalf("GetSecret after modify = %q, want value-v2 (stale cache through the D-Bus stack)", secret.Value)
 	}
 }
+
+// TestE2E_GetManagedObjectsReadsMetadata checks that listing every object
+// with its properties doesn't decrypt items whose metadata is cached.
+func TestE2E_GetManagedObjectsReadsMetadata(t *testing.T) {
+	ctx := context.Background()
+	conn, cleanup := startTestBus(t)
+	defer cleanup()
+
+	backend := newCountingBackend()
+	gs := store.NewGopassStoreWithBackend(backend, "test")
+	var ids []string
+	for _, label := range []string{"one", "two"} {
+		id, err := gs.CreateItem(ctx, "default", &store.ItemData{
+			Label:      label,
+			Secret:     []byte("secret-" + label),
+			Attributes: map[string]string{"service": label},
+		})
+		if err != nil {
+			t.Fatalf("CreateItem: %v", err)
+		}
+		ids = append(ids, id)
+	}
+	// Warm the metadata cache, as the daemon does at startup.
+	if _, err := gs.SearchItems(ctx, "default", nil); err != nil {
+		t.Fatalf("SearchItems: %v", err)
+	}
+	before := make(map[string]int)
+	for _, id := range ids {
+		before[id] = backend.decryptions("test/default/" + id)
+	}
+
+	svc := &Service{
+		conn:  conn,
+		store: gs,
+		cfg:   &config.Config{DefaultCollection: "default", Prefix: "test", Replace: true},
+	}
+	svc.sessions = NewSessionManager(conn)
+	svc.prompts = NewPromptManager(conn)
+	svc.collections = NewCollectionManager(svc)
+	svc.items = NewItemManager(svc)
+	if err := svc.Start(); err != nil {
+		t.Fatalf("start service: %v", err)
+	}
+	defer func() { _ = svc.Stop() }()
+
+	var objects map[dbus.ObjectPath]map[string]map[string]dbus.Variant
+	if err := conn.Object("org.freedesktop.secrets", dbtypes.ServicePath).
+		Call(dbtypes.ObjectManagerInterface+".GetManagedObjects", 0).Store(&objects); err != nil {
+		t.Fatalf("GetManagedObjects: %v", err)
+	}
+	for i, id := range ids {
+		path := dbtypes.ItemPath("default", id)
+		if label := objects[path][dbtypes.ItemInterface]["Label"].Value(); label != []string{"one", "two"}[i] {
+			t.Errorf("%s Label = %v", path, label)
+		}
+		if n := backend.decryptions("test/default/" + id); n != before[id] {
+			t.Errorf("GetManagedObjects decrypted %s: %d -> %d", id, before[id], n)
+		}
+	}
+}
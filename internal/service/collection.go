@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"sync"
@@ -13,7 +14,9 @@ import (
 	"github.com/nikicat/gopass-secret-service/internal/store"
 )
 
-// Collection represents a D-Bus Secret Service collection.
+// Collection represents a D-Bus Secret Service collection. It isn't exported
+// itself: the collection subtree handlers (dispatch.go) resolve it from the
+// path of each call, at its own path and at its aliases' paths.
 //
 // Properties (Items, Label, Locked, Created, Modified) are served by
 // collectionPropsHandler, which reads from the store on every Get rather than
@@ -137,164 +140,6 @@ func (c *Collection) Name() string {
 	return c.name
 }
 
-// Export exports the collection to D-Bus
-func (c *Collection) Export() error {
-	log.Printf("Collection.Export: exporting %s at path %s", c.name, c.path)
-	conn := c.svc.conn
-
-	// Export the collection interface
-	if err := conn.Export(c, c.path, dbtypes.CollectionInterface); err != nil {
-		log.Printf("Collection.Export: failed to export interface: %v", err)
-		return err
-	}
-	log.Printf("Collection.Export: interface exported")
-
-	// Live properties handler — reads from the store on every Get rather
-	// than caching at export time. See type comment.
-	if err := conn.Export(&collectionPropsHandler{coll: c}, c.path, "org.freedesktop.DBus.Properties"); err != nil {
-		conn.Export(nil, c.path, dbtypes.CollectionInterface)
-		return err
-	}
-
-	// Export introspection - must include Properties interface for clients
-	introXML := `<node>
-  <interface name="org.freedesktop.DBus.Properties">
-    <method name="Get">
-      <arg name="interface" type="s" direction="in"/>
-      <arg name="property" type="s" direction="in"/>
-      <arg name="value" type="v" direction="out"/>
-    </method>
-    <method name="Set">
-      <arg name="interface" type="s" direction="in"/>
-      <arg name="property" type="s" direction="in"/>
-      <arg name="value" type="v" direction="in"/>
-    </method>
-    <method name="GetAll">
-      <arg name="interface" type="s" direction="in"/>
-      <arg name="properties" type="a{sv}" direction="out"/>
-    </method>
-  </interface>
-  <interface name="org.freedesktop.Secret.Collection">
-    <method name="Delete">
-      <arg name="prompt" type="o" direction="out"/>
-    </method>
-    <method name="SearchItems">
-      <arg name="attributes" type="a{ss}" direction="in"/>
-      <arg name="results" type="ao" direction="out"/>
-    </method>
-    <method name="CreateItem">
-      <arg name="properties" type="a{sv}" direction="in"/>
-      <arg name="secret" type="(oayays)" direction="in"/>
-      <arg name="replace" type="b" direction="in"/>
-      <arg name="item" type="o" direction="out"/>
-      <arg name="prompt" type="o" direction="out"/>
-    </method>
-    <signal name="ItemCreated">
-      <arg name="item" type="o"/>
-    </signal>
-    <signal name="ItemDeleted">
-      <arg name="item" type="o"/>
-    </signal>
-    <signal name="ItemChanged">
-      <arg name="item" type="o"/>
-    </signal>
-    <property name="Items" type="ao" access="read"/>
-    <property name="Label" type="s" access="readwrite"/>
-    <property name="Locked" type="b" access="read"/>
-    <property name="Created" type="t" access="read"/>
-    <property name="Modified" type="t" access="read"/>
-  </interface>
-</node>`
-	if err := conn.Export(introspect(introXML), c.path, "org.freedesktop.DBus.Introspectable"); err != nil {
-		return err
-	}
-
-	// Export all items in this collection
-	return c.svc.items.ExportAllItems(c.name)
-}
-
-// Unexport removes the collection from D-Bus
-func (c *Collection) Unexport() {
-	conn := c.svc.conn
-	conn.Export(nil, c.path, dbtypes.CollectionInterface)
-	conn.Export(nil, c.path, "org.freedesktop.DBus.Properties")
-	conn.Export(nil, c.path, "org.freedesktop.DBus.Introspectable")
-
-	// Remove all items
-	c.svc.items.RemoveCollection(c.name)
-}
-
-// ExportAtPath exports the collection at an additional path (for aliases)
-func (c *Collection) ExportAtPath(path dbus.ObjectPath) error {
-	conn := c.svc.conn
-
-	// Export the collection interface at the alias path
-	if err := conn.Export(c, path, dbtypes.CollectionInterface); err != nil {
-		return err
-	}
-
-	// Same live properties handler as the canonical path uses.
-	if err := conn.Export(&collectionPropsHandler{coll: c}, path, "org.freedesktop.DBus.Properties"); err != nil {
-		conn.Export(nil, path, dbtypes.CollectionInterface)
-		return err
-	}
-
-	// Export introspection at alias path
-	introXML := `<node>
-  <interface name="org.freedesktop.DBus.Properties">
-    <method name="Get">
-      <arg name="interface" type="s" direction="in"/>
-      <arg name="property" type="s" direction="in"/>
-      <arg name="value" type="v" direction="out"/>
-    </method>
-    <method name="Set">
-      <arg name="interface" type="s" direction="in"/>
-      <arg name="property" type="s" direction="in"/>
-      <arg name="value" type="v" direction="in"/>
-    </method>
-    <method name="GetAll">
-      <arg name="interface" type="s" direction="in"/>
-      <arg name="properties" type="a{sv}" direction="out"/>
-    </method>
-  </interface>
-  <interface name="org.freedesktop.Secret.Collection">
-    <method name="Delete">
-      <arg name="prompt" type="o" direction="out"/>
-    </method>
-    <method name="SearchItems">
-      <arg name="attributes" type="a{ss}" direction="in"/>
-      <arg name="results" type="ao" direction="out"/>
-    </method>
-    <method name="CreateItem">
-      <arg name="properties" type="a{sv}" direction="in"/>
-      <arg name="secret" type="(oayays)" direction="in"/>
-      <arg name="replace" type="b" direction="in"/>
-      <arg name="item" type="o" direction="out"/>
-      <arg name="prompt" type="o" direction="out"/>
-    </method>
-    <signal name="ItemCreated">
-      <arg name="item" type="o"/>
-    </signal>
-    <signal name="ItemDeleted">
-      <arg name="item" type="o"/>
-    </signal>
-    <signal name="ItemChanged">
-      <arg name="item" type="o"/>
-    </signal>
-    <property name="Items" type="ao" access="read"/>
-    <property name="Label" type="s" access="readwrite"/>
-    <property name="Locked" type="b" access="read"/>
-    <property name="Created" type="t" access="read"/>
-    <property name="Modified" type="t" access="read"/>
-  </interface>
-</node>`
-	if err := conn.Export(introspect(introXML), path, "org.freedesktop.DBus.Introspectable"); err != nil {
-		return err
-	}
-
-	return nil
-}
-
 // Delete implements org.freedesktop.Secret.Collection.Delete
 func (c *Collection) Delete() (dbus.ObjectPath, *dbus.Error) {
 	c.mu.Lock()
@@ -302,13 +147,18 @@ func (c *Collection) Delete() (dbus.ObjectPath, *dbus.Error) {
 
 	ctx := context.Background()
 	if err := c.svc.store.DeleteCollection(ctx, c.name); err != nil {
+		if errors.Is(err, store.ErrReadOnly) {
+			return "/", ErrUnsupported(err.Error())
+		}
 		return "/", ErrObjectNotFound(err.Error())
 	}
 
-	// Remove from collection manager (unexports from D-Bus and removes from in-memory map)
+	// Remove from collection manager; its paths answer NoSuchObject from now on
+	items := c.svc.items.CollectionItems(c.name)
 	c.svc.collections.Remove(c.name)
 
 	// Emit CollectionDeleted signal and update Collections property
+	c.svc.emitItemsRemoved(c.name, items)
 	c.svc.emitCollectionDeleted(c.path)
 	c.svc.refreshCollections()
 
@@ -329,7 +179,6 @@ func (c *Collection) SearchItems(attributes map[string]string) ([]dbus.ObjectPat
 
 	paths := make([]dbus.ObjectPath, 0, len(items))
 	for _, item := range items {
-		c.svc.items.EnsureExported(c.name, item.ID)
 		paths = append(paths, dbtypes.ItemPath(c.name, item.ID))
 	}
 
@@ -348,10 +197,12 @@ func (c *Collection) CreateItem(properties map[string]dbus.Variant, secret dbtyp
 	}
 
 	// Decrypt secret
-	plaintext, err := session.Decrypt(secret.Parameters, secret.Value)
+	decrypted, err := session.Decrypt(secret.Parameters, secret.Value)
 	if err != nil {
 		return "/", "/", ErrUnsupported(err.Error())
 	}
+	defer decrypted.Wipe()
+	plaintext := decrypted.Bytes()
 
 	// Extract properties
 	label := ""
@@ -382,19 +233,7 @@ func (c *Collection) CreateItem(properties map[string]dbus.Variant, secret dbtyp
 	// Check for existing item with same attributes
 	// This prevents duplicates - a common practical requirement even though
 	// the spec technically allows duplicates when replace=false
-	var existingItem *store.ItemData
-	if len(attributes) > 0 {
-		existing, err := c.svc.store.SearchItems(ctx, c.name, attributes)
-		if err == nil && len(existing) > 0 {
-			// Find exact match (all attributes must match)
-			for _, item := range existing {
-				if attributesMatch(item.Attributes, attributes) {
-					existingItem = item
-					break
-				}
-			}
-		}
-	}
+	existingItem := c.svc.itemWithAttributes(ctx, c.name, attributes)
 
 	var itemID string
 	if existingItem != nil {
@@ -410,24 +249,12 @@ func (c *Collection) CreateItem(properties map[string]dbus.Variant, secret dbtyp
 			}
 			itemID = existingItem.ID
 
-			// Ensure the item is exported on D-Bus. Same reasoning as the
-			// replace=false branch below: a successful prior CreateItem only
-			// guarantees the item lives in the store, not that an *Item proxy
-			// is bound to its D-Bus path, since across a service restart the
-			// in-memory ItemManager is empty.
-			c.svc.items.EnsureExported(c.name, itemID)
-
 			// Emit ItemChanged
 			itemPath := dbtypes.ItemPath(c.name, itemID)
 			c.svc.emitItemChanged(c.name, itemPath)
 		} else {
 			// Return existing item without modification (prevents duplicates)
 			itemID = existingItem.ID
-
-			// Make sure the item is exported
-			if _, err := c.svc.items.GetOrCreate(c.name, itemID); err != nil {
-				return "/", "/", ErrUnsupported(err.Error())
-			}
 		}
 	} else {
 		// Create new item - use hex format without hyphens for D-Bus path compatibility
@@ -437,22 +264,16 @@ func (c *Collection) CreateItem(properties map[string]dbus.Variant, secret dbtyp
 			ContentType: secret.ContentType,
 			Attributes:  attributes,
 		}
-		rawID := uuid.New()
-		item.ID = fmt.Sprintf("i%x", rawID[:])
+		item.ID = newItemID()
 		id, err := c.svc.store.CreateItem(ctx, c.name, item)
 		if err != nil {
 			return "/", "/", ErrUnsupported(err.Error())
 		}
 		itemID = id
-
-		// Export the new item
-		if _, err := c.svc.items.GetOrCreate(c.name, itemID); err != nil {
-			return "/", "/", ErrUnsupported(err.Error())
-		}
+		c.svc.items.Add(c.name, itemID)
 
 		// Emit ItemCreated
-		itemPath := dbtypes.ItemPath(c.name, itemID)
-		c.svc.emitItemCreated(c.name, itemPath)
+		c.svc.emitItemCreated(c.name, itemID, item)
 
 		// Update Items property
 		c.refreshItems()
@@ -462,6 +283,31 @@ func (c *Collection) CreateItem(properties map[string]dbus.Variant, secret dbtyp
 	return itemPath, "/", nil // "/" means no prompt needed
 }
 
+// itemWithAttributes returns the item of collection with exactly attrs, or
+// nil if there is none (or attrs is empty).
+func (s *Service) itemWithAttributes(ctx context.Context, collection string, attrs map[string]string) *store.ItemData {
+	if len(attrs) == 0 {
+		return nil
+	}
+	existing, err := s.store.SearchItems(ctx, collection, attrs)
+	if err != nil {
+		return nil
+	}
+	for _, item := range existing {
+		if attributesMatch(item.Attributes, attrs) {
+			return item
+		}
+	}
+	return nil
+}
+
+// newItemID returns a random item ID: hex without hyphens, so it is valid in
+// a D-Bus path.
+func newItemID() string {
+	rawID := uuid.New()
+	return fmt.Sprintf("i%x", rawID[:])
+}
+
 func (c *Collection) setLabel(label string) *dbus.Error {
 	ctx := context.Background()
 	if err := c.svc.store.SetCollectionLabel(ctx, c.name, label); err != nil {
@@ -497,7 +343,6 @@ func (c *Collection) getItemPaths() []dbus.ObjectPath {
 
 	paths := make([]dbus.ObjectPath, 0, len(items))
 	for _, id := range items {
-		c.svc.items.EnsureExported(c.name, id)
 		paths = append(paths, dbtypes.ItemPath(c.name, id))
 	}
 	return paths
@@ -520,7 +365,9 @@ func (c *Collection) emitPropertiesChanged(changed map[string]dbus.Variant) {
 		dbtypes.CollectionInterface, changed, []string{})
 }
 
-// CollectionManager manages collections for the service
+// CollectionManager keeps the collections clients can see. Registering one
+// also records its items with the ItemManager, so later changes to them can
+// be reported.
 type CollectionManager struct {
 	collections map[string]*Collection
 	mu          sync.RWMutex
@@ -535,25 +382,22 @@ func NewCollectionManager(svc *Service) *CollectionManager {
 	}
 }
 
-// GetOrCreate returns an existing collection or creates a new one
+// GetOrCreate returns an existing collection or registers a new one
 func (m *CollectionManager) GetOrCreate(name string) (*Collection, error) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
 	if coll, ok := m.collections[name]; ok {
-		log.Printf("GetOrCreate: collection %s already exists", name)
 		return coll, nil
 	}
 
-	log.Printf("GetOrCreate: creating new collection %s", name)
-	coll := NewCollection(m.svc, name)
-	if err := coll.Export(); err != nil {
-		log.Printf("GetOrCreate: failed to export collection %s: %v", name, err)
+	if err := m.svc.items.Load(name); err != nil {
+		log.Printf("GetOrCreate: failed to list items of collection %s: %v", name, err)
 		return nil, err
 	}
-
+	coll := NewCollection(m.svc, name)
 	m.collections[name] = coll
-	log.Printf("GetOrCreate: successfully exported collection %s", name)
+	log.Printf("GetOrCreate: registered collection %s", name)
 	return coll, nil
 }
 
@@ -571,9 +415,10 @@ func (m *CollectionManager) Remove(name string) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	if coll, ok := m.collections[name]; ok {
-		coll.Unexport()
+	if _, ok := m.collections[name]; ok {
 		delete(m.collections, name)
+		m.svc.items.RemoveCollection(name)
+		m.svc.unexportAliasesOf(name)
 	}
 }
 
@@ -589,23 +434,21 @@ func (m *CollectionManager) All() []string {
 	return names
 }
 
-// ExportAll exports all collections from the store
-func (m *CollectionManager) ExportAll() error {
+// LoadAll registers all collections from the store
+func (m *CollectionManager) LoadAll() error {
 	ctx := context.Background()
 	names, err := m.svc.store.Collections(ctx)
 	if err != nil {
-		log.Printf("ExportAll: failed to get collections: %v", err)
+		log.Printf("LoadAll: failed to get collections: %v", err)
 		return err
 	}
 
-	log.Printf("ExportAll: found %d collections: %v", len(names), names)
+	log.Printf("LoadAll: found %d collections: %v", len(names), names)
 
 	for _, name := range names {
 		if _, err := m.GetOrCreate(name); err != nil {
-			log.Printf("ExportAll: failed to export collection %s: %v", name, err)
 			return err
 		}
-		log.Printf("ExportAll: exported collection %s", name)
 	}
 
 	return nil
@@ -0,0 +1,73 @@
+package service
+
+import (
+	"context"
+	"log"
+
+	"github.com/godbus/dbus/v5"
+
+	dbtypes "github.com/nikicat/gopass-secret-service/internal/dbus"
+	"github.com/nikicat/gopass-secret-service/internal/store"
+)
+
+// FindDuplicates returns the groups of items in collection, or in every
+// collection if it is empty, that have exactly the same attributes. The
+// items are exported so the caller can inspect them.
+func (e *gopassSecret) FindDuplicates(collection string) ([]dbtypes.DuplicateGroup, *dbus.Error) {
+	s := e.svc
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if collection != "" {
+		if _, ok := s.collections.Get(collection); !ok {
+			return nil, ErrObjectNotFound("no such collection: " + collection)
+		}
+	}
+	groups, err := store.FindDuplicates(context.Background(), s.store, collection)
+	if err != nil {
+		return nil, dbus.MakeFailedError(err)
+	}
+	out := make([]dbtypes.DuplicateGroup, 0, len(groups))
+	for _, g := range groups {
+		group := dbtypes.DuplicateGroup{Attributes: g.Attributes, ValuesDiffer: g.ValuesDiffer}
+		for _, item := range g.Items {
+			group.Items = append(group.Items, dbtypes.DuplicateItem{
+				Path:     dbtypes.ItemPath(g.Collection, item.ID),
+				Label:    item.Label,
+				Created:  item.Created.Unix(),
+				Modified: item.Modified.Unix(),
+			})
+		}
+		out = append(out, group)
+	}
+	return out, nil
+}
+
+// RemoveDuplicates deletes every other item with exactly the attributes of
+// the item keep, and returns the paths of the deleted items. Items deleted
+// before a failure stay deleted and are reported through ItemDeleted.
+func (e *gopassSecret) RemoveDuplicates(keep dbus.ObjectPath) ([]dbus.ObjectPath, *dbus.Error) {
+	s := e.svc
+	collection, id, err := dbtypes.ParseItemPath(keep)
+	if err != nil {
+		return nil, ErrObjectNotFound(err.Error())
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, derr := s.itemAt(keep); derr != nil {
+		return nil, derr
+	}
+	removed, err := store.RemoveDuplicates(context.Background(), s.store, collection, id)
+	paths := make([]dbus.ObjectPath, 0, len(removed))
+	for _, rid := range removed {
+		log.Printf("Deleted duplicate item %s/%s of %s", collection, rid, id)
+		s.applyItemChange(collection, rid, true)
+		paths = append(paths, dbtypes.ItemPath(collection, rid))
+	}
+	if err != nil {
+		return nil, storeError(err)
+	}
+	return paths, nil
+}
@@ -0,0 +1,365 @@
+package service
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/godbus/dbus/v5"
+
+	dbtypes "github.com/nikicat/gopass-secret-service/internal/dbus"
+)
+
+// Collections, aliases and items aren't exported one object at a time: with
+// many items that costs three exports per item and a walk over the whole
+// store at startup. Instead one handler per interface serves the subtrees
+// below CollectionBasePath and AliasBasePath. Each call resolves its object
+// from the message's path and gets NoSuchObject if there is none.
+
+// exportSubtrees registers the handlers serving every collection, alias and
+// item path.
+func (s *Service) exportSubtrees() error {
+	handlers := map[string]any{
+		dbtypes.CollectionInterface:           &collectionNodes{svc: s},
+		dbtypes.ItemInterface:                 &itemNodes{svc: s},
+		dbtypes.GopassSecretItemInterface:     &gopassSecretItemNodes{svc: s},
+		"org.freedesktop.DBus.Properties":     &propertiesNodes{svc: s},
+		"org.freedesktop.DBus.Introspectable": &introspectNodes{svc: s},
+	}
+	for _, root := range []dbus.ObjectPath{dbtypes.CollectionBasePath, dbtypes.AliasBasePath} {
+		for iface, h := range handlers {
+			if err := s.conn.ExportSubtree(h, root, iface); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// msgPath returns the object path a method call was sent to.
+func msgPath(msg dbus.Message) dbus.ObjectPath {
+	path, _ := msg.Headers[dbus.FieldPath].Value().(dbus.ObjectPath)
+	return path
+}
+
+func errNoSuchObject(path dbus.ObjectPath) *dbus.Error {
+	return ErrObjectNotFound("no such object: " + string(path))
+}
+
+// collectionAt returns the collection at a collection or alias path.
+func (s *Service) collectionAt(path dbus.ObjectPath) (*Collection, *dbus.Error) {
+	var name string
+	switch {
+	case dbtypes.IsCollectionPath(path):
+		name, _ = dbtypes.ParseCollectionPath(path)
+	case dbtypes.IsAliasPath(path):
+		alias, _ := dbtypes.ParseAliasPath(path)
+		s.aliasMu.Lock()
+		coll, ok := s.aliases[alias]
+		s.aliasMu.Unlock()
+		if !ok {
+			return nil, errNoSuchObject(path)
+		}
+		name = coll
+	default:
+		return nil, errNoSuchObject(path)
+	}
+	coll, ok := s.collections.Get(name)
+	if !ok {
+		return nil, errNoSuchObject(path)
+	}
+	return coll, nil
+}
+
+// itemAt returns the item at path. Items the daemon doesn't know yet, e.g.
+// ones added with the gopass CLI while the store isn't watched, are looked up
+// in the store.
+func (s *Service) itemAt(path dbus.ObjectPath) (*Item, *dbus.Error) {
+	if !dbtypes.IsItemPath(path) {
+		return nil, errNoSuchObject(path)
+	}
+	collection, id, _ := dbtypes.ParseItemPath(path)
+	if _, ok := s.collections.Get(collection); !ok {
+		return nil, errNoSuchObject(path)
+	}
+	if !s.items.Has(path) {
+		ids, err := s.store.Items(context.Background(), collection)
+		if err != nil || !slices.Contains(ids, id) {
+			return nil, errNoSuchObject(path)
+		}
+		s.items.Add(collection, id)
+	}
+	return NewItem(s, collection, id), nil
+}
+
+// collectionNodes serves org.freedesktop.Secret.Collection.
+type collectionNodes struct {
+	svc *Service
+}
+
+func (n *collectionNodes) Delete(msg dbus.Message) (dbus.ObjectPath, *dbus.Error) {
+	coll, derr := n.svc.collectionAt(msgPath(msg))
+	if derr != nil {
+		return "/", derr
+	}
+	return coll.Delete()
+}
+
+func (n *collectionNodes) SearchItems(msg dbus.Message, attributes map[string]string) ([]dbus.ObjectPath, *dbus.Error) {
+	coll, derr := n.svc.collectionAt(msgPath(msg))
+	if derr != nil {
+		return nil, derr
+	}
+	return coll.SearchItems(attributes)
+}
+
+func (n *collectionNodes) CreateItem(msg dbus.Message, properties map[string]dbus.Variant, secret dbtypes.Secret, replace bool) (dbus.ObjectPath, dbus.ObjectPath, *dbus.Error) {
+	coll, derr := n.svc.collectionAt(msgPath(msg))
+	if derr != nil {
+		return "/", "/", derr
+	}
+	return coll.CreateItem(properties, secret, replace)
+}
+
+// itemNodes serves org.freedesktop.Secret.Item.
+type itemNodes struct {
+	svc *Service
+}
+
+func (n *itemNodes) Delete(msg dbus.Message) (dbus.ObjectPath, *dbus.Error) {
+	item, derr := n.svc.itemAt(msgPath(msg))
+	if derr != nil {
+		return "/", derr
+	}
+	return item.Delete()
+}
+
+func (n *itemNodes) GetSecret(msg dbus.Message, session dbus.ObjectPath) (dbtypes.Secret, *dbus.Error) {
+	item, derr := n.svc.itemAt(msgPath(msg))
+	if derr != nil {
+		return dbtypes.Secret{}, derr
+	}
+	return item.GetSecret(session)
+}
+
+func (n *itemNodes) SetSecret(msg dbus.Message, secret dbtypes.Secret) *dbus.Error {
+	item, derr := n.svc.itemAt(msgPath(msg))
+	if derr != nil {
+		return derr
+	}
+	return item.SetSecret(secret)
+}
+
+// gopassSecretItemNodes serves io.github.nikicat.GopassSecret1.Item.
+type gopassSecretItemNodes struct {
+	svc *Service
+}
+
+func (n *gopassSecretItemNodes) Revisions(msg dbus.Message) ([]dbtypes.Revision, *dbus.Error) {
+	item, derr := n.svc.itemAt(msgPath(msg))
+	if derr != nil {
+		return nil, derr
+	}
+	return (&gopassSecretItem{item: item}).Revisions()
+}
+
+func (n *gopassSecretItemNodes) GetSecretAt(msg dbus.Message, revision string, session dbus.ObjectPath) (dbtypes.Secret, *dbus.Error) {
+	item, derr := n.svc.itemAt(msgPath(msg))
+	if derr != nil {
+		return dbtypes.Secret{}, derr
+	}
+	return (&gopassSecretItem{item: item}).GetSecretAt(revision, session)
+}
+
+func (n *gopassSecretItemNodes) Restore(msg dbus.Message, revision string) *dbus.Error {
+	item, derr := n.svc.itemAt(msgPath(msg))
+	if derr != nil {
+		return derr
+	}
+	return (&gopassSecretItem{item: item}).Restore(revision)
+}
+
+// propertiesNodes serves org.freedesktop.DBus.Properties through the live
+// handlers of the collection or item at the path.
+type propertiesNodes struct {
+	svc *Service
+}
+
+// propsHandler is what collectionPropsHandler and itemPropsHandler share.
+type propsHandler interface {
+	Get(iface, property string) (dbus.Variant, *dbus.Error)
+	GetAll(iface string) (map[string]dbus.Variant, *dbus.Error)
+	Set(iface, property string, value dbus.Variant) *dbus.Error
+}
+
+func (n *propertiesNodes) handler(path dbus.ObjectPath) (propsHandler, *dbus.Error) {
+	if dbtypes.IsItemPath(path) {
+		item, derr := n.svc.itemAt(path)
+		if derr != nil {
+			return nil, derr
+		}
+		return &itemPropsHandler{item: item}, nil
+	}
+	coll, derr := n.svc.collectionAt(path)
+	if derr != nil {
+		return nil, derr
+	}
+	return &collectionPropsHandler{coll: coll}, nil
+}
+
+func (n *propertiesNodes) Get(msg dbus.Message, iface, property string) (dbus.Variant, *dbus.Error) {
+	h, derr := n.handler(msgPath(msg))
+	if derr != nil {
+		return dbus.Variant{}, derr
+	}
+	return h.Get(iface, property)
+}
+
+func (n *propertiesNodes) GetAll(msg dbus.Message, iface string) (map[string]dbus.Variant, *dbus.Error) {
+	h, derr := n.handler(msgPath(msg))
+	if derr != nil {
+		return nil, derr
+	}
+	return h.GetAll(iface)
+}
+
+func (n *propertiesNodes) Set(msg dbus.Message, iface, property string, value dbus.Variant) *dbus.Error {
+	h, derr := n.handler(msgPath(msg))
+	if derr != nil {
+		return derr
+	}
+	return h.Set(iface, property, value)
+}
+
+// introspectNodes serves org.freedesktop.DBus.Introspectable, generating the
+// data of each node: the interfaces of the collection or item at the path,
+// and its children.
+type introspectNodes struct {
+	svc *Service
+}
+
+func (n *introspectNodes) Introspect(msg dbus.Message) (string, *dbus.Error) {
+	s := n.svc
+	path := msgPath(msg)
+	var children []string
+	body := ""
+	switch {
+	case path == dbtypes.CollectionBasePath:
+		children = s.collections.All()
+	case path == dbtypes.AliasBasePath:
+		s.aliasMu.Lock()
+		for alias := range s.aliases {
+			children = append(children, alias)
+		}
+		s.aliasMu.Unlock()
+	case dbtypes.IsItemPath(path):
+		if _, derr := s.itemAt(path); derr != nil {
+			return "", derr
+		}
+		body = itemIntrospectXML
+	default:
+		coll, derr := s.collectionAt(path)
+		if derr != nil {
+			return "", derr
+		}
+		body = collectionIntrospectXML
+		if dbtypes.IsCollectionPath(path) {
+			children, _ = s.store.Items(context.Background(), coll.name)
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString("<node>\n")
+	b.WriteString(body)
+	slices.Sort(children)
+	for _, child := range children {
+		b.WriteString(`  <node name="` + child + `"/>` + "\n")
+	}
+	b.WriteString("</node>")
+	return b.String(), nil
+}
+
+// propertiesIntrospectXML describes org.freedesktop.DBus.Properties, which
+// clients need to see to read collection and item properties.
+const propertiesIntrospectXML = `  <interface name="org.freedesktop.DBus.Properties">
+    <method name="Get">
+      <arg name="interface" type="s" direction="in"/>
+      <arg name="property" type="s" direction="in"/>
+      <arg name="value" type="v" direction="out"/>
+    </method>
+    <method name="Set">
+      <arg name="interface" type="s" direction="in"/>
+      <arg name="property" type="s" direction="in"/>
+      <arg name="value" type="v" direction="in"/>
+    </method>
+    <method name="GetAll">
+      <arg name="interface" type="s" direction="in"/>
+      <arg name="properties" type="a{sv}" direction="out"/>
+    </method>
+  </interface>
+`
+
+const collectionIntrospectXML = propertiesIntrospectXML + `  <interface name="org.freedesktop.Secret.Collection">
+    <method name="Delete">
+      <arg name="prompt" type="o" direction="out"/>
+    </method>
+    <method name="SearchItems">
+      <arg name="attributes" type="a{ss}" direction="in"/>
+      <arg name="results" type="ao" direction="out"/>
+    </method>
+    <method name="CreateItem">
+      <arg name="properties" type="a{sv}" direction="in"/>
+      <arg name="secret" type="(oayays)" direction="in"/>
+      <arg name="replace" type="b" direction="in"/>
+      <arg name="item" type="o" direction="out"/>
+      <arg name="prompt" type="o" direction="out"/>
+    </method>
+    <signal name="ItemCreated">
+      <arg name="item" type="o"/>
+    </signal>
+    <signal name="ItemDeleted">
+      <arg name="item" type="o"/>
+    </signal>
+    <signal name="ItemChanged">
+      <arg name="item" type="o"/>
+    </signal>
+    <property name="Items" type="ao" access="read"/>
+    <property name="Label" type="s" access="readwrite"/>
+    <property name="Locked" type="b" access="read"/>
+    <property name="Created" type="t" access="read"/>
+    <property name="Modified" type="t" access="read"/>
+  </interface>
+`
+
+const itemIntrospectXML = propertiesIntrospectXML + `  <interface name="org.freedesktop.Secret.Item">
+    <method name="Delete">
+      <arg name="prompt" type="o" direction="out"/>
+    </method>
+    <method name="GetSecret">
+      <arg name="session" type="o" direction="in"/>
+      <arg name="secret" type="(oayays)" direction="out"/>
+    </method>
+    <method name="SetSecret">
+      <arg name="secret" type="(oayays)" direction="in"/>
+    </method>
+    <property name="Locked" type="b" access="read"/>
+    <property name="Attributes" type="a{ss}" access="readwrite"/>
+    <property name="Label" type="s" access="readwrite"/>
+    <property name="Created" type="t" access="read"/>
+    <property name="Modified" type="t" access="read"/>
+  </interface>
+  <interface name="io.github.nikicat.GopassSecret1.Item">
+    <method name="Revisions">
+      <arg name="revisions" type="a(sxs)" direction="out"/>
+    </method>
+    <method name="GetSecretAt">
+      <arg name="revision" type="s" direction="in"/>
+      <arg name="session" type="o" direction="in"/>
+      <arg name="secret" type="(oayays)" direction="out"/>
+    </method>
+    <method name="Restore">
+      <arg name="revision" type="s" direction="in"/>
+    </method>
+    <property name="Expires" type="t" access="read"/>
+  </interface>
+`
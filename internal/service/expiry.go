@@ -0,0 +1,57 @@
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// startReaper deletes items whose expiry has passed every reap interval,
+// beginning right away. Volatile items don't depend on it: the kernel expires
+// their keys (see watchExpired).
+func (s *Service) startReaper() {
+	if s.cfg.ReapInterval <= 0 {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	s.stopReap = cancel
+	go func() {
+		ticker := time.NewTicker(s.cfg.ReapInterval)
+		defer ticker.Stop()
+		for {
+			s.reapExpired(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// reapExpired deletes every expired item through the store and emits
+// ItemDeleted for the ones that were exported.
+func (s *Service) reapExpired(ctx context.Context) {
+	results, err := s.store.SearchAllItems(ctx, nil)
+	if err != nil {
+		log.Printf("Warning: looking for expired items: %v", err)
+		return
+	}
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for collection, items := range results {
+		for _, item := range items {
+			if item.Expires.IsZero() || now.Before(item.Expires) {
+				continue
+			}
+			if err := s.store.DeleteItem(ctx, collection, item.ID); err != nil {
+				log.Printf("Warning: failed to delete expired item %s/%s: %v", collection, item.ID, err)
+				continue
+			}
+			log.Printf("Deleted expired item %s/%s", collection, item.ID)
+			s.applyItemChange(collection, item.ID, true)
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+
+	dbtypes "github.com/nikicat/gopass-secret-service/internal/dbus"
+	"github.com/nikicat/gopass-secret-service/internal/secmem"
+	"github.com/nikicat/gopass-secret-service/internal/store"
+)
+
+// gopassSecret implements io.github.nikicat.GopassSecret1, the daemon's own
+// additions to the Secret Service API, on the service object. It's a separate
+// type so exporting it doesn't also expose the spec methods under the
+// extension interface.
+type gopassSecret struct {
+	svc *Service
+}
+
+// Sync pulls and pushes every store repository right away.
+func (e *gopassSecret) Sync() *dbus.Error {
+	if e.svc.syncer == nil {
+		return ErrUnsupported("git sync is disabled")
+	}
+	if err := e.svc.syncer.SyncNow(context.Background()); err != nil {
+		return dbus.MakeFailedError(err)
+	}
+	return nil
+}
+
+// SyncStatus reports the git sync state of every store repository.
+func (e *gopassSecret) SyncStatus() ([]dbtypes.SyncStatus, *dbus.Error) {
+	if e.svc.syncer == nil {
+		return nil, ErrUnsupported("git sync is disabled")
+	}
+	var out []dbtypes.SyncStatus
+	for _, st := range e.svc.syncer.Status() {
+		out = append(out, dbtypes.SyncStatus{
+			Dir:       st.Dir,
+			Pending:   st.Pending,
+			LastPull:  unixOrZero(st.LastPull),
+			LastPush:  unixOrZero(st.LastPush),
+			LastError: st.LastError,
+		})
+	}
+	return out, nil
+}
+
+func unixOrZero(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.Unix()
+}
+
+// gopassSecretItem implements io.github.nikicat.GopassSecret1.Item on item
+// objects: access to the item's revision history.
+type gopassSecretItem struct {
+	item *Item
+}
+
+func (e *gopassSecretItem) history() (store.ItemHistory, *dbus.Error) {
+	h, ok := e.item.svc.store.(store.ItemHistory)
+	if !ok {
+		return nil, ErrUnsupported(store.ErrHistoryUnsupported.Error())
+	}
+	return h, nil
+}
+
+// Revisions lists the item's revisions, newest first.
+func (e *gopassSecretItem) Revisions() ([]dbtypes.Revision, *dbus.Error) {
+	h, derr := e.history()
+	if derr != nil {
+		return nil, derr
+	}
+	revs, err := h.ItemRevisions(context.Background(), e.item.collection, e.item.id)
+	if err != nil {
+		return nil, historyError(err)
+	}
+	out := make([]dbtypes.Revision, 0, len(revs))
+	for _, r := range revs {
+		out = append(out, dbtypes.Revision{ID: r.ID, Time: r.Time.Unix(), Message: r.Message})
+	}
+	return out, nil
+}
+
+// GetSecretAt returns the item's secret as it was at revision, encrypted for
+// the caller's session like GetSecret.
+func (e *gopassSecretItem) GetSecretAt(revision string, sessionPath dbus.ObjectPath) (dbtypes.Secret, *dbus.Error) {
+	session, ok := e.item.svc.sessions.GetSession(sessionPath)
+	if !ok {
+		return dbtypes.Secret{}, ErrSessionNotFound("session not found")
+	}
+	h, derr := e.history()
+	if derr != nil {
+		return dbtypes.Secret{}, derr
+	}
+	old, err := h.GetItemRevision(context.Background(), e.item.collection, e.item.id, revision)
+	if err != nil {
+		return dbtypes.Secret{}, historyError(err)
+	}
+	defer secmem.Wipe(old.Secret)
+
+	params, ciphertext, err := session.Encrypt(old.Secret)
+	if err != nil {
+		return dbtypes.Secret{}, ErrUnsupported(err.Error())
+	}
+	return dbtypes.Secret{
+		Session:     sessionPath,
+		Parameters:  params,
+		Value:       ciphertext,
+		ContentType: old.ContentType,
+	}, nil
+}
+
+// Restore makes the item's value, label and attributes at revision current.
+func (e *gopassSecretItem) Restore(revision string) *dbus.Error {
+	e.item.svc.items.writeMu.Lock()
+	defer e.item.svc.items.writeMu.Unlock()
+
+	h, derr := e.history()
+	if derr != nil {
+		return derr
+	}
+	if err := h.RestoreItemRevision(context.Background(), e.item.collection, e.item.id, revision); err != nil {
+		return historyError(err)
+	}
+	e.item.svc.emitItemChanged(e.item.collection, e.item.path)
+	return nil
+}
+
+func historyError(err error) *dbus.Error {
+	switch {
+	case errors.Is(err, store.ErrHistoryUnsupported):
+		return ErrUnsupported(err.Error())
+	case errors.Is(err, store.ErrUnknownRevision):
+		return ErrObjectNotFound(err.Error())
+	default:
+		return dbus.MakeFailedError(err)
+	}
+}
@@ -2,21 +2,23 @@ package service
 
 import (
 	"context"
-	"log"
+	"errors"
 	"sync"
 
 	"github.com/godbus/dbus/v5"
 
 	dbtypes "github.com/nikicat/gopass-secret-service/internal/dbus"
+	"github.com/nikicat/gopass-secret-service/internal/secmem"
+	"github.com/nikicat/gopass-secret-service/internal/store"
 )
 
-// Item represents a D-Bus Secret Service item
+// Item represents a D-Bus Secret Service item. Items aren't exported: the
+// item subtree handlers (dispatch.go) make one for each call to its path.
 type Item struct {
 	path       dbus.ObjectPath
 	collection string
 	id         string
 	svc        *Service
-	mu         sync.RWMutex
 }
 
 // NewItem creates a new Item instance
@@ -41,6 +43,12 @@ type itemPropsHandler struct {
 }
 
 func (h *itemPropsHandler) Get(iface, property string) (dbus.Variant, *dbus.Error) {
+	if iface == dbtypes.GopassSecretItemInterface {
+		if property != "Expires" {
+			return dbus.Variant{}, ErrUnsupported("unknown property: " + property)
+		}
+		return dbus.MakeVariant(h.expires()), nil
+	}
 	if iface != dbtypes.ItemInterface {
 		return dbus.Variant{}, ErrUnsupported("unknown interface: " + iface)
 	}
@@ -62,6 +70,7 @@ func (h *itemPropsHandler) Get(iface, property string) (dbus.Variant, *dbus.Erro
 			return dbus.Variant{}, ErrUnsupported("unknown property: " + property)
 		}
 	}
+	secmem.Wipe(data.Secret)
 
 	switch property {
 	case "Label":
@@ -83,11 +92,36 @@ func (h *itemPropsHandler) Get(iface, property string) (dbus.Variant, *dbus.Erro
 	}
 }
 
+// expires returns the item's expiry in Unix seconds, 0 for never (or when
+// the store is unavailable).
+func (h *itemPropsHandler) expires() uint64 {
+	return expiresOf(h.item.metadata())
+}
+
 func (h *itemPropsHandler) GetAll(iface string) (map[string]dbus.Variant, *dbus.Error) {
+	if iface == dbtypes.GopassSecretItemInterface {
+		return map[string]dbus.Variant{"Expires": dbus.MakeVariant(h.expires())}, nil
+	}
 	if iface != dbtypes.ItemInterface {
 		return nil, ErrUnsupported("unknown interface: " + iface)
 	}
+	return itemProperties(h.item.metadata()), nil
+}
 
+// metadata reads the item from the store with its secret wiped, or returns
+// nil if the store is unavailable.
+func (i *Item) metadata() *store.ItemData {
+	data, err := i.svc.store.GetItem(context.Background(), i.collection, i.id)
+	if err != nil {
+		return nil
+	}
+	secmem.Wipe(data.Secret)
+	return data
+}
+
+// itemProperties returns the org.freedesktop.Secret.Item properties of data,
+// with zero values if data is nil.
+func itemProperties(data *store.ItemData) map[string]dbus.Variant {
 	result := map[string]dbus.Variant{
 		"Label":      dbus.MakeVariant(""),
 		"Attributes": dbus.MakeVariant(map[string]string{}),
@@ -95,11 +129,8 @@ func (h *itemPropsHandler) GetAll(iface string) (map[string]dbus.Variant, *dbus.
 		"Created":    dbus.MakeVariant(uint64(0)),
 		"Modified":   dbus.MakeVariant(uint64(0)),
 	}
-
-	ctx := context.Background()
-	data, err := h.item.svc.store.GetItem(ctx, h.item.collection, h.item.id)
-	if err != nil {
-		return result, nil
+	if data == nil {
+		return result
 	}
 
 	attrs := data.Attributes
@@ -111,8 +142,15 @@ func (h *itemPropsHandler) GetAll(iface string) (map[string]dbus.Variant, *dbus.
 	result["Locked"] = dbus.MakeVariant(data.Locked)
 	result["Created"] = dbus.MakeVariant(uint64(data.Created.Unix()))
 	result["Modified"] = dbus.MakeVariant(uint64(data.Modified.Unix()))
+	return result
+}
 
-	return result, nil
+// expiresOf returns data's expiry in Unix seconds, 0 for never or nil data.
+func expiresOf(data *store.ItemData) uint64 {
+	if data == nil || data.Expires.IsZero() {
+		return 0
+	}
+	return uint64(data.Expires.Unix())
 }
 
 func (h *itemPropsHandler) Set(iface, property string, value dbus.Variant) *dbus.Error {
@@ -138,84 +176,19 @@ func (h *itemPropsHandler) Set(iface, property string, value dbus.Variant) *dbus
 	}
 }
 
-// Export exports the item to D-Bus
-func (i *Item) Export() error {
-	conn := i.svc.conn
-
-	// Export the item interface
-	if err := conn.Export(i, i.path, dbtypes.ItemInterface); err != nil {
-		return err
-	}
-
-	// Export a custom Properties handler that reads from the store on every access
-	handler := &itemPropsHandler{item: i}
-	if err := conn.Export(handler, i.path, "org.freedesktop.DBus.Properties"); err != nil {
-		conn.Export(nil, i.path, dbtypes.ItemInterface)
-		return err
-	}
-
-	// Export introspection - must include Properties interface for clients
-	introXML := `<node>
-  <interface name="org.freedesktop.DBus.Properties">
-    <method name="Get">
-      <arg name="interface" type="s" direction="in"/>
-      <arg name="property" type="s" direction="in"/>
-      <arg name="value" type="v" direction="out"/>
-    </method>
-    <method name="Set">
-      <arg name="interface" type="s" direction="in"/>
-      <arg name="property" type="s" direction="in"/>
-      <arg name="value" type="v" direction="in"/>
-    </method>
-    <method name="GetAll">
-      <arg name="interface" type="s" direction="in"/>
-      <arg name="properties" type="a{sv}" direction="out"/>
-    </method>
-  </interface>
-  <interface name="org.freedesktop.Secret.Item">
-    <method name="Delete">
-      <arg name="prompt" type="o" direction="out"/>
-    </method>
-    <method name="GetSecret">
-      <arg name="session" type="o" direction="in"/>
-      <arg name="secret" type="(oayays)" direction="out"/>
-    </method>
-    <method name="SetSecret">
-      <arg name="secret" type="(oayays)" direction="in"/>
-    </method>
-    <property name="Locked" type="b" access="read"/>
-    <property name="Attributes" type="a{ss}" access="readwrite"/>
-    <property name="Label" type="s" access="readwrite"/>
-    <property name="Created" type="t" access="read"/>
-    <property name="Modified" type="t" access="read"/>
-  </interface>
-</node>`
-	if err := conn.Export(introspect(introXML), i.path, "org.freedesktop.DBus.Introspectable"); err != nil {
-		return err
-	}
-
-	return nil
-}
-
-// Unexport removes the item from D-Bus
-func (i *Item) Unexport() {
-	conn := i.svc.conn
-	conn.Export(nil, i.path, dbtypes.ItemInterface)
-	conn.Export(nil, i.path, "org.freedesktop.DBus.Properties")
-	conn.Export(nil, i.path, "org.freedesktop.DBus.Introspectable")
-}
-
 // Delete implements org.freedesktop.Secret.Item.Delete
 func (i *Item) Delete() (dbus.ObjectPath, *dbus.Error) {
-	i.mu.Lock()
-	defer i.mu.Unlock()
+	i.svc.items.writeMu.Lock()
+	defer i.svc.items.writeMu.Unlock()
 
 	ctx := context.Background()
 	if err := i.svc.store.DeleteItem(ctx, i.collection, i.id); err != nil {
+		if errors.Is(err, store.ErrReadOnly) {
+			return "/", ErrUnsupported(err.Error())
+		}
 		return "/", ErrObjectNotFound(err.Error())
 	}
 
-	// Remove from item manager (this also unexports)
 	i.svc.items.Remove(i.path)
 
 	// Update collection's Items property
@@ -232,9 +205,6 @@ func (i *Item) Delete() (dbus.ObjectPath, *dbus.Error) {
 
 // GetSecret implements org.freedesktop.Secret.Item.GetSecret
 func (i *Item) GetSecret(sessionPath dbus.ObjectPath) (dbtypes.Secret, *dbus.Error) {
-	i.mu.RLock()
-	defer i.mu.RUnlock()
-
 	session, ok := i.svc.sessions.GetSession(sessionPath)
 	if !ok {
 		return dbtypes.Secret{}, ErrSessionNotFound("session not found")
@@ -245,6 +215,7 @@ func (i *Item) GetSecret(sessionPath dbus.ObjectPath) (dbtypes.Secret, *dbus.Err
 	if err != nil {
 		return dbtypes.Secret{}, ErrObjectNotFound(err.Error())
 	}
+	defer secmem.Wipe(item.Secret)
 
 	params, ciphertext, err := session.Encrypt(item.Secret)
 	if err != nil {
@@ -261,18 +232,19 @@ func (i *Item) GetSecret(sessionPath dbus.ObjectPath) (dbtypes.Secret, *dbus.Err
 
 // SetSecret implements org.freedesktop.Secret.Item.SetSecret
 func (i *Item) SetSecret(secret dbtypes.Secret) *dbus.Error {
-	i.mu.Lock()
-	defer i.mu.Unlock()
+	i.svc.items.writeMu.Lock()
+	defer i.svc.items.writeMu.Unlock()
 
 	session, ok := i.svc.sessions.GetSession(secret.Session)
 	if !ok {
 		return ErrSessionNotFound("session not found")
 	}
 
-	plaintext, err := session.Decrypt(secret.Parameters, secret.Value)
+	decrypted, err := session.Decrypt(secret.Parameters, secret.Value)
 	if err != nil {
 		return ErrUnsupported(err.Error())
 	}
+	defer decrypted.Wipe()
 
 	ctx := context.Background()
 	item, err := i.svc.store.GetItem(ctx, i.collection, i.id)
@@ -280,7 +252,8 @@ func (i *Item) SetSecret(secret dbtypes.Secret) *dbus.Error {
 		return ErrObjectNotFound(err.Error())
 	}
 
-	item.Secret = plaintext
+	secmem.Wipe(item.Secret)
+	item.Secret = decrypted.Bytes()
 	item.ContentType = secret.ContentType
 
 	if err := i.svc.store.UpdateItem(ctx, i.collection, i.id, item); err != nil {
@@ -294,11 +267,15 @@ func (i *Item) SetSecret(secret dbtypes.Secret) *dbus.Error {
 }
 
 func (i *Item) setAttributes(attrs map[string]string) *dbus.Error {
+	i.svc.items.writeMu.Lock()
+	defer i.svc.items.writeMu.Unlock()
+
 	ctx := context.Background()
 	item, err := i.svc.store.GetItem(ctx, i.collection, i.id)
 	if err != nil {
 		return ErrObjectNotFound(err.Error())
 	}
+	defer secmem.Wipe(item.Secret)
 
 	item.Attributes = attrs
 
@@ -311,11 +288,15 @@ func (i *Item) setAttributes(attrs map[string]string) *dbus.Error {
 }
 
 func (i *Item) setLabel(label string) *dbus.Error {
+	i.svc.items.writeMu.Lock()
+	defer i.svc.items.writeMu.Unlock()
+
 	ctx := context.Background()
 	item, err := i.svc.store.GetItem(ctx, i.collection, i.id)
 	if err != nil {
 		return ErrObjectNotFound(err.Error())
 	}
+	defer secmem.Wipe(item.Secret)
 
 	item.Label = label
 
@@ -327,102 +308,94 @@ func (i *Item) setLabel(label string) *dbus.Error {
 	return nil
 }
 
-// ItemManager manages items for the service
+// ItemManager keeps the IDs of the items clients know about, by collection,
+// so changes made behind the daemon's back can be told apart into created,
+// changed and deleted items.
 type ItemManager struct {
-	items map[dbus.ObjectPath]*Item
+	items map[string]map[string]bool // collection -> item IDs
 	mu    sync.RWMutex
 	svc   *Service
+
+	// writeMu serializes the read-modify-write updates of items.
+	writeMu sync.Mutex
 }
 
 // NewItemManager creates a new item manager
 func NewItemManager(svc *Service) *ItemManager {
 	return &ItemManager{
-		items: make(map[dbus.ObjectPath]*Item),
+		items: make(map[string]map[string]bool),
 		svc:   svc,
 	}
 }
 
-// GetOrCreate returns an existing item or creates a new one
-func (m *ItemManager) GetOrCreate(collection, id string) (*Item, error) {
-	path := dbtypes.ItemPath(collection, id)
-
+// Add records an item and reports whether it wasn't known before
+func (m *ItemManager) Add(collection, id string) bool {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	if item, ok := m.items[path]; ok {
-		return item, nil
+	if m.items[collection] == nil {
+		m.items[collection] = make(map[string]bool)
 	}
-
-	item := NewItem(m.svc, collection, id)
-	if err := item.Export(); err != nil {
-		return nil, err
+	if m.items[collection][id] {
+		return false
 	}
+	m.items[collection][id] = true
+	return true
+}
 
-	m.items[path] = item
-	return item, nil
+// Has reports whether the item at path is known
+func (m *ItemManager) Has(path dbus.ObjectPath) bool {
+	collection, id, err := dbtypes.ParseItemPath(path)
+	if err != nil {
+		return false
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.items[collection][id]
 }
 
-// Remove removes an item from the manager
+// Remove forgets an item
 func (m *ItemManager) Remove(path dbus.ObjectPath) {
+	collection, id, err := dbtypes.ParseItemPath(path)
+	if err != nil {
+		return
+	}
 	m.mu.Lock()
 	defer m.mu.Unlock()
-
-	if item, ok := m.items[path]; ok {
-		item.Unexport()
-		delete(m.items, path)
-	}
+	delete(m.items[collection], id)
 }
 
-// RemoveCollection removes all items for a collection
+// RemoveCollection forgets all items of a collection
 func (m *ItemManager) RemoveCollection(collection string) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
-
-	prefix := dbtypes.CollectionPath(collection) + "/"
-	for path, item := range m.items {
-		if len(string(path)) > len(prefix) && string(path)[:len(prefix)] == string(prefix) {
-			item.Unexport()
-			delete(m.items, path)
-		}
-	}
-}
-
-// GetItem returns an item by path
-func (m *ItemManager) GetItem(path dbus.ObjectPath) (*Item, bool) {
-	m.mu.RLock()
-	defer m.mu.RUnlock()
-
-	item, ok := m.items[path]
-	return item, ok
+	delete(m.items, collection)
 }
 
-// ExportAllItems exports all items for a collection
-func (m *ItemManager) ExportAllItems(collection string) error {
-	ctx := context.Background()
-	items, err := m.svc.store.Items(ctx, collection)
+// Load records the items of a collection as the store has them
+func (m *ItemManager) Load(collection string) error {
+	ids, err := m.svc.store.Items(context.Background(), collection)
 	if err != nil {
 		return err
 	}
-
-	for _, id := range items {
-		if _, err := m.GetOrCreate(collection, id); err != nil {
-			return err
-		}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	known := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		known[id] = true
 	}
-
+	m.items[collection] = known
 	return nil
 }
 
-// EnsureExported guarantees the item at (collection, id) is bound to its D-Bus
-// path before its path is handed to a client. Required wherever we surface a
-// path computed from the store rather than from m.items, since the store can
-// contain items the ItemManager has not yet exported — e.g. items created on
-// disk before the service started, items added via the CLI, or items left
-// non-exported by a previous CreateItem(replace=true) which only updated the
-// store. Export errors are logged but not propagated: dropping one path is
-// strictly better than failing the entire SearchItems call for the others.
-func (m *ItemManager) EnsureExported(collection, id string) {
-	if _, err := m.GetOrCreate(collection, id); err != nil {
-		log.Printf("EnsureExported: failed to export %s/%s: %v", collection, id, err)
+// CollectionItems returns the IDs of the known items in a collection
+func (m *ItemManager) CollectionItems(collection string) []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	ids := make([]string, 0, len(m.items[collection]))
+	for id := range m.items[collection] {
+		ids = append(ids, id)
 	}
+	return ids
 }
@@ -0,0 +1,115 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/godbus/dbus/v5"
+
+	dbtypes "github.com/nikicat/gopass-secret-service/internal/dbus"
+	"github.com/nikicat/gopass-secret-service/internal/store"
+)
+
+// MoveItem moves an item to another collection, given by its collection or
+// alias path, and returns the item's new path. Clients see the item deleted
+// from one collection and created in the other.
+func (e *gopassSecret) MoveItem(item, collection dbus.ObjectPath) (dbus.ObjectPath, *dbus.Error) {
+	s := e.svc
+	from, id, err := dbtypes.ParseItemPath(item)
+	if err != nil {
+		return "/", ErrObjectNotFound(err.Error())
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ctx := context.Background()
+	to, err := s.resolveCollectionName(ctx, collection)
+	if err != nil || dbtypes.IsItemPath(collection) {
+		return "/", ErrObjectNotFound("not a collection: " + string(collection))
+	}
+	if _, ok := s.collections.Get(to); !ok {
+		return "/", ErrObjectNotFound("no such collection: " + to)
+	}
+
+	newID, err := s.store.MoveItem(ctx, from, id, to)
+	if err != nil {
+		return "/", storeError(err)
+	}
+	if from == to {
+		return item, nil
+	}
+
+	s.applyItemChange(from, id, true)
+	s.applyItemChange(to, newID, false)
+	for _, name := range []string{from, to} {
+		if coll, ok := s.collections.Get(name); ok {
+			s.emitCollectionChanged(coll.Path())
+		}
+	}
+	return dbtypes.ItemPath(to, newID), nil
+}
+
+// RenameCollection renames a collection, given by its collection or alias
+// path, and returns its new path. The collection and its items are served
+// under the new name, and its aliases follow it.
+func (e *gopassSecret) RenameCollection(collection dbus.ObjectPath, name string) (dbus.ObjectPath, *dbus.Error) {
+	s := e.svc
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ctx := context.Background()
+	from, err := s.resolveCollectionName(ctx, collection)
+	if err != nil || dbtypes.IsItemPath(collection) {
+		return "/", ErrObjectNotFound("not a collection: " + string(collection))
+	}
+	old, ok := s.collections.Get(from)
+	if !ok {
+		return "/", ErrObjectNotFound("no such collection: " + from)
+	}
+	to := store.SanitizeName(name)
+	if to == from {
+		return old.Path(), nil
+	}
+	if _, ok := s.collections.Get(to); ok {
+		return "/", ErrExists("collection already exists: " + to)
+	}
+
+	if err := s.store.RenameCollection(ctx, from, to); err != nil {
+		return "/", storeError(err)
+	}
+
+	// Every object path below the collection changes with its name.
+	items := s.items.CollectionItems(from)
+	aliases := s.aliasesOf(ctx, from)
+	s.collections.Remove(from)
+	for _, id := range items {
+		s.emitItemDeleted(from, dbtypes.ItemPath(from, id))
+	}
+	s.emitCollectionDeleted(old.Path())
+
+	coll, err := s.collections.GetOrCreate(to)
+	if err != nil {
+		return "/", ErrUnsupported(err.Error())
+	}
+	s.emitCollectionCreated(coll.Path())
+	meta := s.itemsMetadata(ctx, to)
+	for _, id := range items {
+		s.emitItemCreated(to, id, meta[id])
+	}
+	s.retargetAliases(ctx, aliases, coll)
+	coll.refreshItems()
+	s.emitCollectionChanged(coll.Path())
+	s.refreshCollections()
+
+	return coll.Path(), nil
+}
+
+// storeError maps an error of a store write to a D-Bus error: writes a
+// read-only store refuses are unsupported, anything else failed.
+func storeError(err error) *dbus.Error {
+	if errors.Is(err, store.ErrReadOnly) {
+		return ErrUnsupported(err.Error())
+	}
+	return dbus.MakeFailedError(err)
+}
@@ -0,0 +1,107 @@
+package service
+
+import (
+	"context"
+	"log"
+
+	"github.com/godbus/dbus/v5"
+
+	dbtypes "github.com/nikicat/gopass-secret-service/internal/dbus"
+	"github.com/nikicat/gopass-secret-service/internal/store"
+)
+
+// Interface names reported for collections and items, in InterfacesRemoved.
+var (
+	collectionInterfaceNames = []string{dbtypes.CollectionInterface}
+	itemInterfaceNames       = []string{dbtypes.ItemInterface, dbtypes.GopassSecretItemInterface}
+)
+
+// objectManager implements org.freedesktop.DBus.ObjectManager on ServicePath,
+// so a browser can read every collection and item with its properties in one
+// call instead of a Properties round trip per object. Alias paths aren't
+// listed: they're the collections they point to under another name.
+type objectManager struct {
+	svc *Service
+}
+
+// GetManagedObjects implements org.freedesktop.DBus.ObjectManager.GetManagedObjects
+func (m *objectManager) GetManagedObjects() (map[dbus.ObjectPath]map[string]map[string]dbus.Variant, *dbus.Error) {
+	s := m.svc
+	objects := make(map[dbus.ObjectPath]map[string]map[string]dbus.Variant)
+
+	// Item properties come from the store's metadata, as searches read it:
+	// reading each item would decrypt every secret in the store.
+	ctx := context.Background()
+	for _, name := range s.collections.All() {
+		coll, ok := s.collections.Get(name)
+		if !ok {
+			continue
+		}
+		ifaces := collectionInterfaces(coll)
+		objects[coll.Path()] = ifaces
+		paths, _ := ifaces[dbtypes.CollectionInterface]["Items"].Value().([]dbus.ObjectPath)
+		if len(paths) == 0 {
+			continue
+		}
+		meta := s.itemsMetadata(ctx, name)
+		for _, path := range paths {
+			_, id, err := dbtypes.ParseItemPath(path)
+			if err != nil {
+				continue
+			}
+			// The caller now knows the item, so it must hear of its removal.
+			s.items.Add(name, id)
+			objects[path] = itemInterfaces(meta[id])
+		}
+	}
+	return objects, nil
+}
+
+// collectionInterfaces returns the interfaces and properties of coll as
+// GetManagedObjects and InterfacesAdded report them.
+func collectionInterfaces(coll *Collection) map[string]map[string]dbus.Variant {
+	props, _ := (&collectionPropsHandler{coll: coll}).GetAll(dbtypes.CollectionInterface)
+	return map[string]map[string]dbus.Variant{dbtypes.CollectionInterface: props}
+}
+
+// itemInterfaces returns the interfaces and properties of the item data, with
+// zero values if data is nil.
+func itemInterfaces(data *store.ItemData) map[string]map[string]dbus.Variant {
+	return map[string]map[string]dbus.Variant{
+		dbtypes.ItemInterface:             itemProperties(data),
+		dbtypes.GopassSecretItemInterface: {"Expires": dbus.MakeVariant(expiresOf(data))},
+	}
+}
+
+// itemsMetadata returns the items of collection without their secrets, by ID.
+// It searches rather than reading each item, which stores answer from their
+// metadata caches instead of decrypting every item.
+func (s *Service) itemsMetadata(ctx context.Context, collection string) map[string]*store.ItemData {
+	items, err := s.store.SearchItems(ctx, collection, nil)
+	if err != nil {
+		log.Printf("Warning: read metadata of %s: %v", collection, err)
+		return nil
+	}
+	byID := make(map[string]*store.ItemData, len(items))
+	for _, item := range items {
+		byID[item.ID] = item
+	}
+	return byID
+}
+
+func (s *Service) emitInterfacesAdded(path dbus.ObjectPath, ifaces map[string]map[string]dbus.Variant) {
+	s.conn.Emit(dbtypes.ServicePath, dbtypes.ObjectManagerInterface+".InterfacesAdded", path, ifaces)
+}
+
+func (s *Service) emitInterfacesRemoved(path dbus.ObjectPath, ifaces []string) {
+	s.conn.Emit(dbtypes.ServicePath, dbtypes.ObjectManagerInterface+".InterfacesRemoved", path, ifaces)
+}
+
+// emitItemsRemoved reports the items of a deleted collection as removed.
+// The Secret Service API has no ItemDeleted for them, but ObjectManager
+// clients would otherwise keep them.
+func (s *Service) emitItemsRemoved(collection string, ids []string) {
+	for _, id := range ids {
+		s.emitInterfacesRemoved(dbtypes.ItemPath(collection, id), itemInterfaceNames)
+	}
+}
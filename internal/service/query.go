@@ -0,0 +1,58 @@
+package service
+
+import (
+	"context"
+
+	"github.com/godbus/dbus/v5"
+
+	dbtypes "github.com/nikicat/gopass-secret-service/internal/dbus"
+	"github.com/nikicat/gopass-secret-service/internal/store"
+)
+
+// SearchQuery is SearchItems with operators other than equality (see
+// store.Condition), limited to collection unless it is empty.
+func (e *gopassSecret) SearchQuery(collection string, conditions []dbtypes.Condition) ([]dbus.ObjectPath, []dbus.ObjectPath, *dbus.Error) {
+	s := e.svc
+	q, derr := compileConditions(conditions)
+	if derr != nil {
+		return nil, nil, derr
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	ctx := context.Background()
+	results, err := store.SearchQuery(ctx, s.store, collection, q)
+	if err != nil {
+		return nil, nil, ErrObjectNotFound(err.Error())
+	}
+
+	var unlocked, locked []dbus.ObjectPath
+	for collName, items := range results {
+		collData, _ := s.store.GetCollection(ctx, collName)
+		isLocked := collData != nil && collData.Locked
+
+		for _, item := range items {
+			path := dbtypes.ItemPath(collName, item.ID)
+			if isLocked {
+				locked = append(locked, path)
+			} else {
+				unlocked = append(unlocked, path)
+			}
+		}
+	}
+	return unlocked, locked, nil
+}
+
+// compileConditions turns the conditions of a query call into a store.Query.
+func compileConditions(conditions []dbtypes.Condition) (*store.Query, *dbus.Error) {
+	conds := make([]store.Condition, 0, len(conditions))
+	for _, c := range conditions {
+		conds = append(conds, store.Condition{Key: c.Key, Op: store.Op(c.Op), Value: c.Value})
+	}
+	q, err := store.CompileQuery(conds)
+	if err != nil {
+		return nil, ErrUnsupported(err.Error())
+	}
+	return q, nil
+}
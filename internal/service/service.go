@@ -4,14 +4,21 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/godbus/dbus/v5"
 	"github.com/godbus/dbus/v5/prop"
+	"github.com/gopasspw/gopass/pkg/gopass"
 
 	"github.com/nikicat/gopass-secret-service/internal/config"
 	dbtypes "github.com/nikicat/gopass-secret-service/internal/dbus"
+	"github.com/nikicat/gopass-secret-service/internal/gitsync"
+	"github.com/nikicat/gopass-secret-service/internal/secmem"
 	"github.com/nikicat/gopass-secret-service/internal/store"
 )
 
@@ -27,13 +34,39 @@ type Service struct {
 	props       *prop.Properties
 	mu          sync.RWMutex
 
-	// sessionEnabled is true when the kernel keyring backed the session
-	// collection at startup. When false (e.g. in rootless containers where
-	// add_key returns ENOSYS) the daemon refuses to expose the session
-	// collection at all, rather than silently falling back to gopass storage
-	// and breaking the "session writes don't touch the durable store"
+	// volatile holds the session collection and every other volatile
+	// collection in the kernel keyring. It is nil when the keyring was
+	// unavailable at startup (e.g. in rootless containers where add_key
+	// returns ENOSYS); the daemon then refuses to expose volatile
+	// collections at all, rather than silently falling back to gopass storage
+	// and breaking the "volatile writes don't touch the durable store"
 	// contract.
-	sessionEnabled bool
+	volatile *store.KeyringStore
+
+	// stopWatch ends watching the store for external changes; nil when not
+	// watching. watching is set once the watch is running.
+	stopWatch context.CancelFunc
+	watching  bool
+
+	// aliases maps every alias exported under /aliases to its collection.
+	aliasMu sync.Mutex
+	aliases map[string]string
+
+	// stopExpired ends reporting volatile items whose TTL ran out.
+	stopExpired context.CancelFunc
+
+	// stopReap ends the periodic deletion of expired items.
+	stopReap context.CancelFunc
+
+	// syncer pushes and pulls the store's git repositories; nil unless sync
+	// is enabled. stopSync ends its background scheduling.
+	syncer   *gitsync.Syncer
+	stopSync context.CancelFunc
+
+	// pool bounds concurrent gopass decryptions; GetSecrets fans out over
+	// it. stopWarm ends the background cache warm-up.
+	pool     *store.DecryptPool
+	stopWarm context.CancelFunc
 }
 
 // New creates a new Secret Service
@@ -62,34 +95,57 @@ func New(ctx context.Context, cfg *config.Config) (*Service, error) {
 		}
 	}
 
-	// Create the durable (gopass) store.
-	gopassStore, err := store.NewGopassStore(ctx, cfg.Prefix)
+	// The sync scheduler reports pulls back to the service, which doesn't
+	// exist yet; svc is assigned before the scheduler is started.
+	var svc *Service
+	var syncer *gitsync.Syncer
+	if cfg.Sync.Enabled {
+		syncer = newSyncer(cfg, func(files []string) { svc.reloadPulled(files) })
+	}
+
+	// Create the durable stores: one gopass store per configured route, or
+	// the age store.
+	pool := store.NewDecryptPool(cfg.Decrypt.Workers)
+	var durable *store.MultiStore
+	if cfg.Backend == config.BackendAge {
+		durable, err = newAgeStore(cfg)
+	} else {
+		durable, err = newDurableStore(ctx, cfg, syncer, pool)
+	}
 	if err != nil {
 		conn.Close()
-		return nil, fmt.Errorf("failed to create gopass store: %w", err)
+		return nil, err
 	}
 
-	// Create the volatile session store backed by the Linux kernel keyring.
-	// If the kernel doesn't support add_key (e.g. CONFIG_KEYS=n or rootless
-	// containers in restrictive user namespaces) we leave the session
-	// collection unexposed entirely — falling back to gopass would silently
-	// break the contract that session writes never touch the durable store.
-	// The daemon still starts; default-collection clients keep working.
-	var combined store.Store = gopassStore
-	sessionEnabled := false
+	// Create the volatile store backed by the Linux kernel keyring. If the
+	// kernel doesn't support add_key (e.g. CONFIG_KEYS=n or rootless
+	// containers in restrictive user namespaces) we leave the session and
+	// volatile collections unexposed entirely — falling back to gopass would
+	// silently break the contract that their writes never touch the durable
+	// store. The daemon still starts; default-collection clients keep working.
 	keyringStore, err := store.NewKeyringStore()
 	if err != nil {
-		log.Printf("Warning: session collection disabled (kernel keyring unavailable): %v", err)
+		log.Printf("Warning: session and volatile collections disabled (kernel keyring unavailable): %v", err)
 	} else {
-		combined = store.NewMultiStore(gopassStore, keyringStore)
-		sessionEnabled = true
+		keyringStore.SetTTLRules(store.TTLRules(cfg.TTL))
+		// The volatile route goes first so no configured pattern can claim
+		// its collections. Has picks up the ones clients create under other
+		// names with the Volatile property.
+		patterns := []string{store.SessionCollectionName}
+		for _, v := range cfg.Volatile {
+			patterns = append(patterns, v.Collection)
+		}
+		volatileRoute := store.Route{Patterns: patterns, Store: keyringStore, Has: keyringStore.HasCollection}
+		durable.Routes = append([]store.Route{volatileRoute}, durable.Routes...)
 	}
 
-	svc := &Service{
-		conn:           conn,
-		store:          combined,
-		cfg:            cfg,
-		sessionEnabled: sessionEnabled,
+	svc = &Service{
+		conn:     conn,
+		store:    durable,
+		cfg:      cfg,
+		volatile: keyringStore,
+		syncer:   syncer,
+		pool:     pool,
 	}
 
 	// Initialize managers
@@ -101,6 +157,139 @@ func New(ctx context.Context, cfg *config.Config) (*Service, error) {
 	return svc, nil
 }
 
+// OpenStore opens the durable store cfg describes, the way the daemon does
+// but without git sync or volatile collections, for commands that write to it
+// directly. A running daemon picks the changes up through its store watch.
+func OpenStore(ctx context.Context, cfg *config.Config) (store.Store, error) {
+	if cfg.Backend == config.BackendAge {
+		return newAgeStore(cfg)
+	}
+	return newDurableStore(ctx, cfg, nil, store.NewDecryptPool(cfg.Decrypt.Workers))
+}
+
+// newDurableStore builds one GopassStore per distinct mount/prefix named in
+// cfg.Routes, all sharing a single gopass backend, and routes collections to
+// them. The root store under cfg.Prefix is the primary: it holds the alias
+// table and every collection no route claims. Writes are reported to syncer,
+// if any, so they get pushed. All of them decrypt through pool.
+func newDurableStore(ctx context.Context, cfg *config.Config, syncer *gitsync.Syncer, pool *store.DecryptPool) (*store.MultiStore, error) {
+	templates, err := store.NewPathTemplates(cfg.Naming.Default, cfg.Naming.Schemas)
+	if err != nil {
+		return nil, fmt.Errorf("invalid naming config: %w", err)
+	}
+
+	backend, err := store.NewGopassBackend(ctx, cfg.StorePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create gopass store: %w", err)
+	}
+
+	primary := store.NewGopassStoreWithBackend(backend, cfg.Prefix)
+	rootDir := store.GopassMountDir(cfg.StorePath, "")
+	primary.SetDir(filepath.Join(rootDir, cfg.Prefix))
+	primary.SetPathTemplates(templates)
+	primary.SetDecryptPool(pool)
+	if syncer != nil {
+		primary.SetWriteHook(func() { syncer.Notify(rootDir) })
+	}
+	byPrefix := map[string]*store.GopassStore{cfg.Prefix: primary}
+	routes := make([]store.Route, 0, len(cfg.Routes))
+	for _, r := range cfg.Routes {
+		prefix := cfg.RoutePrefix(r)
+		gs, ok := byPrefix[prefix]
+		if !ok {
+			gs = store.NewGopassStoreWithBackend(backend, prefix)
+			mountDir := store.GopassMountDir(cfg.StorePath, r.Mount)
+			gs.SetDir(filepath.Join(mountDir, cfg.MountPrefix(r)))
+			gs.SetPathTemplates(templates)
+			gs.SetDecryptPool(pool)
+			if syncer != nil {
+				gs.SetWriteHook(func() { syncer.Notify(mountDir) })
+			}
+			byPrefix[prefix] = gs
+		}
+		log.Printf("Routing collections %v to gopass prefix %s", r.Collections, prefix)
+		routes = append(routes, store.Route{Patterns: r.Collections, Store: gs})
+	}
+	// Native collections go before the configured routes so a pattern can't
+	// shadow them.
+	native := make([]store.Route, 0, len(cfg.Native))
+	for _, n := range cfg.Native {
+		native = append(native, store.Route{Patterns: []string{n.Collection}, Store: newNativeStore(cfg, backend, pool, n, byPrefix)})
+	}
+	routes = append(native, routes...)
+	if cfg.Index {
+		for prefix, gs := range byPrefix {
+			enableIndex(ctx, prefix, gs)
+		}
+	}
+	return store.NewMultiStore(primary, routes...), nil
+}
+
+// newAgeStore opens the age backend, which holds every collection itself.
+func newAgeStore(cfg *config.Config) (*store.MultiStore, error) {
+	var (
+		s   *store.AgeStore
+		err error
+	)
+	if cfg.Age.Identity != "" {
+		s, err = store.NewAgeStore(cfg.Age.Dir, cfg.Age.Identity)
+	} else {
+		passphrase := cfg.Age.Passphrase
+		if cfg.Age.PassphraseFile != "" {
+			var data []byte
+			if data, err = os.ReadFile(cfg.Age.PassphraseFile); err != nil {
+				return nil, fmt.Errorf("failed to read age passphrase: %w", err)
+			}
+			passphrase, _, _ = strings.Cut(string(data), "\n")
+		}
+		s, err = store.NewAgeStoreWithPassphrase(cfg.Age.Dir, passphrase)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create age store: %w", err)
+	}
+	return store.NewMultiStore(s), nil
+}
+
+// newNativeStore exposes the gopass entries under n's subtree as a read-only
+// collection. Secret-service prefixes nested inside the subtree are left out.
+// It gets no persistent index: its key would have to be written into the
+// user's own entries.
+func newNativeStore(cfg *config.Config, backend gopass.Store, pool *store.DecryptPool, n config.NativeCollection, byPrefix map[string]*store.GopassStore) *store.NativeStore {
+	prefix := n.Prefix()
+	gs := store.NewGopassStoreWithBackend(backend, prefix)
+	gs.SetDecryptPool(pool)
+	gs.SetDir(filepath.Join(store.GopassMountDir(cfg.StorePath, n.Mount), filepath.FromSlash(n.Path)))
+
+	var exclude []string
+	for p := range byPrefix {
+		if strings.HasPrefix(p, prefix+"/") {
+			exclude = append(exclude, p)
+		}
+	}
+	log.Printf("Exposing gopass entries under %s as read-only collection %s", prefix, n.Collection)
+	return store.NewNativeStore(gs, store.NativeOptions{
+		Collection: n.Collection,
+		Label:      n.Label,
+		Schema:     n.Schema,
+		Fields:     n.Fields,
+		Exclude:    exclude,
+	})
+}
+
+// enableIndex loads the persistent attribute index of gs. Failing to do so
+// only costs search latency, so it's logged rather than fatal.
+func enableIndex(ctx context.Context, prefix string, gs *store.GopassStore) {
+	file, err := store.IndexFile(gs.Dir())
+	if err == nil {
+		var n int
+		if n, err = gs.EnableIndex(ctx, file); err == nil {
+			log.Printf("Loaded %d indexed entries for gopass prefix %s", n, prefix)
+			return
+		}
+	}
+	log.Printf("Warning: attribute index disabled for gopass prefix %s: %v", prefix, err)
+}
+
 // Start starts the service and acquires the D-Bus name
 func (s *Service) Start() error {
 	// Export the service object
@@ -126,6 +315,21 @@ func (s *Service) Start() error {
 	}
 	s.props = props
 
+	// Export the daemon's own extension interface next to the spec one
+	if err := s.conn.Export(&gopassSecret{svc: s}, dbtypes.ServicePath, dbtypes.GopassSecretInterface); err != nil {
+		return fmt.Errorf("failed to export %s: %w", dbtypes.GopassSecretInterface, err)
+	}
+
+	// List collections and items in one call for browsers
+	if err := s.conn.Export(&objectManager{svc: s}, dbtypes.ServicePath, dbtypes.ObjectManagerInterface); err != nil {
+		return fmt.Errorf("failed to export %s: %w", dbtypes.ObjectManagerInterface, err)
+	}
+
+	// Serve collections, aliases and items
+	if err := s.exportSubtrees(); err != nil {
+		return fmt.Errorf("failed to export collections: %w", err)
+	}
+
 	// Export introspection
 	introXML := s.introspectionXML()
 	if err := s.conn.Export(introspect(introXML), dbtypes.ServicePath, "org.freedesktop.DBus.Introspectable"); err != nil {
@@ -149,9 +353,9 @@ func (s *Service) Start() error {
 
 	log.Printf("Acquired D-Bus name: %s", dbtypes.ServiceName)
 
-	// Export existing collections
-	if err := s.collections.ExportAll(); err != nil {
-		log.Printf("Warning: failed to export existing collections: %v", err)
+	// Register existing collections
+	if err := s.collections.LoadAll(); err != nil {
+		log.Printf("Warning: failed to load existing collections: %v", err)
 	}
 
 	// Refresh collections property
@@ -161,23 +365,44 @@ func (s *Service) Start() error {
 	if err := s.ensureDefaultCollection(); err != nil {
 		log.Printf("Warning: failed to ensure default collection: %v", err)
 	}
+	s.exportAliases()
 
 	// Export the session collection and alias only when the keyring backend
 	// is available. Without it, exposing /aliases/session would route writes
 	// to gopass and silently violate the session contract — so we skip the
 	// export entirely. Clients that don't use the session collection see no
 	// difference; ones that do get a clear "no such object" from D-Bus.
-	if s.sessionEnabled {
+	if s.volatile != nil {
 		if err := s.ensureSessionCollection(); err != nil {
 			log.Printf("Warning: failed to ensure session collection: %v", err)
 		}
+		s.ensureVolatileCollections()
+		s.watchExpired()
 	}
 
+	s.watchStore()
+	s.startSync()
+	s.startReaper()
+	s.startWarmUp()
+
 	return nil
 }
 
 // Stop stops the service
 func (s *Service) Stop() error {
+	if s.stopWarm != nil {
+		s.stopWarm()
+	}
+	if s.stopWatch != nil {
+		s.stopWatch()
+	}
+	if s.stopExpired != nil {
+		s.stopExpired()
+	}
+	if s.stopReap != nil {
+		s.stopReap()
+	}
+	s.stopSyncing()
 	s.sessions.CloseAll()
 	s.prompts.CloseAll()
 
@@ -242,9 +467,19 @@ func (s *Service) CreateCollection(properties map[string]dbus.Variant, alias str
 		return "/", "/", ErrExists("collection already exists")
 	}
 
+	// Volatile collections are created in the kernel keyring, which claims
+	// them from then on; everything else goes wherever the routes say.
+	target := s.store
+	if s.isVolatile(name, properties) {
+		if s.volatile == nil {
+			return "/", "/", ErrUnsupported("volatile collections are unavailable: no kernel keyring")
+		}
+		target = s.volatile
+	}
+
 	// Create collection in store
 	ctx := context.Background()
-	if err := s.store.CreateCollection(ctx, name, label); err != nil {
+	if err := target.CreateCollection(ctx, name, label); err != nil {
 		return "/", "/", ErrUnsupported(err.Error())
 	}
 
@@ -258,6 +493,8 @@ func (s *Service) CreateCollection(properties map[string]dbus.Variant, alias str
 	if alias != "" {
 		if err := s.store.SetAlias(ctx, alias, name); err != nil {
 			log.Printf("Warning: failed to set alias %s: %v", alias, err)
+		} else {
+			s.exportAlias(alias, coll)
 		}
 	}
 
@@ -287,7 +524,6 @@ func (s *Service) SearchItems(attributes map[string]string) ([]dbus.ObjectPath,
 		isLocked := collData != nil && collData.Locked
 
 		for _, item := range items {
-			s.items.EnsureExported(collName, item.ID)
 			path := dbtypes.ItemPath(collName, item.ID)
 			if isLocked {
 				locked = append(locked, path)
@@ -356,21 +592,29 @@ func (s *Service) GetSecrets(items []dbus.ObjectPath, session dbus.ObjectPath) (
 		return nil, ErrSessionNotFound("session not found")
 	}
 
+	// Decrypt the items in parallel, then encrypt them for the session one
+	// by one.
 	ctx := context.Background()
-	secrets := make(map[dbus.ObjectPath]dbtypes.Secret)
-
-	for _, path := range items {
-		collection, id, err := dbtypes.ParseItemPath(path)
+	fetched := make([]*store.ItemData, len(items))
+	s.pool.Each(ctx, len(items), func(i int) {
+		collection, id, err := dbtypes.ParseItemPath(items[i])
 		if err != nil {
-			continue
+			return
+		}
+		if item, err := s.store.GetItem(ctx, collection, id); err == nil {
+			fetched[i] = item
 		}
+	})
 
-		item, err := s.store.GetItem(ctx, collection, id)
-		if err != nil {
+	secrets := make(map[dbus.ObjectPath]dbtypes.Secret)
+	for i, path := range items {
+		item := fetched[i]
+		if item == nil {
 			continue
 		}
 
 		params, ciphertext, err := sess.Encrypt(item.Secret)
+		secmem.Wipe(item.Secret)
 		if err != nil {
 			continue
 		}
@@ -400,17 +644,23 @@ func (s *Service) ReadAlias(name string) (dbus.ObjectPath, *dbus.Error) {
 	return dbtypes.CollectionPath(collName), nil
 }
 
-// SetAlias implements org.freedesktop.Secret.Service.SetAlias
+// SetAlias implements org.freedesktop.Secret.Service.SetAlias. The alias is
+// exported at its /aliases path right away, or unexported when removed.
 func (s *Service) SetAlias(name string, collection dbus.ObjectPath) *dbus.Error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	if name == "" || !dbtypes.AliasPath(name).IsValid() {
+		return ErrUnsupported(fmt.Sprintf("invalid alias name %q", name))
+	}
+
 	ctx := context.Background()
 	if collection == "/" {
 		// Remove alias
 		if err := s.store.SetAlias(ctx, name, ""); err != nil {
 			return ErrUnsupported(err.Error())
 		}
+		s.unexportAlias(name)
 		return nil
 	}
 
@@ -418,10 +668,15 @@ func (s *Service) SetAlias(name string, collection dbus.ObjectPath) *dbus.Error
 	if err != nil {
 		return ErrObjectNotFound(err.Error())
 	}
+	coll, ok := s.collections.Get(collName)
+	if !ok {
+		return ErrObjectNotFound("no such collection: " + collName)
+	}
 
 	if err := s.store.SetAlias(ctx, name, collName); err != nil {
 		return ErrUnsupported(err.Error())
 	}
+	s.exportAlias(name, coll)
 
 	return nil
 }
@@ -452,24 +707,34 @@ func (s *Service) resolveCollectionName(ctx context.Context, path dbus.ObjectPat
 
 func (s *Service) emitCollectionCreated(path dbus.ObjectPath) {
 	s.conn.Emit(dbtypes.ServicePath, dbtypes.SecretServiceInterface+".CollectionCreated", path)
+	if coll, derr := s.collectionAt(path); derr == nil {
+		s.emitInterfacesAdded(path, collectionInterfaces(coll))
+	}
 }
 
 func (s *Service) emitCollectionDeleted(path dbus.ObjectPath) {
 	s.conn.Emit(dbtypes.ServicePath, dbtypes.SecretServiceInterface+".CollectionDeleted", path)
+	s.emitInterfacesRemoved(path, collectionInterfaceNames)
 }
 
 func (s *Service) emitCollectionChanged(path dbus.ObjectPath) {
 	s.conn.Emit(dbtypes.ServicePath, dbtypes.SecretServiceInterface+".CollectionChanged", path)
 }
 
-func (s *Service) emitItemCreated(collection string, path dbus.ObjectPath) {
-	collPath := dbtypes.CollectionPath(collection)
-	s.conn.Emit(collPath, dbtypes.CollectionInterface+".ItemCreated", path)
+// emitItemCreated announces the item id of collection. InterfacesAdded
+// reports the properties of data, which the caller already has or took from
+// itemsMetadata: reading the item here would decrypt it, with s.mu held. A
+// nil data reports empty properties.
+func (s *Service) emitItemCreated(collection, id string, data *store.ItemData) {
+	path := dbtypes.ItemPath(collection, id)
+	s.conn.Emit(dbtypes.CollectionPath(collection), dbtypes.CollectionInterface+".ItemCreated", path)
+	s.emitInterfacesAdded(path, itemInterfaces(data))
 }
 
 func (s *Service) emitItemDeleted(collection string, path dbus.ObjectPath) {
 	collPath := dbtypes.CollectionPath(collection)
 	s.conn.Emit(collPath, dbtypes.CollectionInterface+".ItemDeleted", path)
+	s.emitInterfacesRemoved(path, itemInterfaceNames)
 }
 
 func (s *Service) emitItemChanged(collection string, path dbus.ObjectPath) {
@@ -531,13 +796,6 @@ func (s *Service) ensureDefaultCollection() error {
 	return nil
 }
 
-// exportAlias exports a collection at an alias path
-func (s *Service) exportAlias(alias string, coll *Collection) {
-	if err := coll.ExportAtPath(dbtypes.AliasPath(alias)); err != nil {
-		log.Printf("Warning: failed to export alias %s: %v", alias, err)
-	}
-}
-
 func (s *Service) ensureSessionCollection() error {
 	ctx := context.Background()
 	if err := s.store.CreateCollection(ctx, store.SessionCollectionName, "Session"); err != nil {
@@ -553,6 +811,46 @@ func (s *Service) ensureSessionCollection() error {
 	return nil
 }
 
+// ensureVolatileCollections creates and exports the volatile collections the
+// config names exactly; patterns only apply to collections clients create.
+func (s *Service) ensureVolatileCollections() {
+	ctx := context.Background()
+	for _, v := range s.cfg.Volatile {
+		if v.IsPattern() {
+			continue
+		}
+		label := v.Label
+		if label == "" {
+			label = v.Collection
+		}
+		if err := s.store.CreateCollection(ctx, v.Collection, label); err != nil {
+			log.Printf("Warning: failed to create volatile collection %s: %v", v.Collection, err)
+			continue
+		}
+		if _, err := s.collections.GetOrCreate(v.Collection); err != nil {
+			log.Printf("Warning: failed to export volatile collection %s: %v", v.Collection, err)
+		}
+	}
+	s.refreshCollections()
+}
+
+// isVolatile reports whether a collection being created belongs in the kernel
+// keyring: either the client asked for it with the Volatile property or the
+// name matches a configured volatile pattern.
+func (s *Service) isVolatile(name string, properties map[string]dbus.Variant) bool {
+	if v, ok := properties[dbtypes.CollectionVolatileProperty]; ok {
+		if volatile, ok := v.Value().(bool); ok && volatile {
+			return true
+		}
+	}
+	for _, v := range s.cfg.Volatile {
+		if ok, _ := path.Match(v.Collection, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
 func (s *Service) introspectionXML() string {
 	return `<node>
   <interface name="org.freedesktop.Secret.Service">
@@ -607,5 +905,66 @@ func (s *Service) introspectionXML() string {
     </signal>
     <property name="Collections" type="ao" access="read"/>
   </interface>
+  <interface name="io.github.nikicat.GopassSecret1">
+    <method name="Sync"/>
+    <method name="SyncStatus">
+      <arg name="repositories" type="a(sbxxs)" direction="out"/>
+    </method>
+    <method name="ListAliases">
+      <arg name="aliases" type="a{so}" direction="out"/>
+    </method>
+    <method name="SearchQuery">
+      <arg name="collection" type="s" direction="in"/>
+      <arg name="conditions" type="a(sss)" direction="in"/>
+      <arg name="unlocked" type="ao" direction="out"/>
+      <arg name="locked" type="ao" direction="out"/>
+    </method>
+    <method name="FindDuplicates">
+      <arg name="collection" type="s" direction="in"/>
+      <arg name="groups" type="a(a{ss}ba(osxx))" direction="out"/>
+    </method>
+    <method name="RemoveDuplicates">
+      <arg name="keep" type="o" direction="in"/>
+      <arg name="removed" type="ao" direction="out"/>
+    </method>
+    <method name="MoveItem">
+      <arg name="item" type="o" direction="in"/>
+      <arg name="collection" type="o" direction="in"/>
+      <arg name="moved" type="o" direction="out"/>
+    </method>
+    <method name="RenameCollection">
+      <arg name="collection" type="o" direction="in"/>
+      <arg name="name" type="s" direction="in"/>
+      <arg name="renamed" type="o" direction="out"/>
+    </method>
+    <method name="SearchDetails">
+      <arg name="collection" type="s" direction="in"/>
+      <arg name="conditions" type="a(sss)" direction="in"/>
+      <arg name="items" type="a(osa{ss}b)" direction="out"/>
+    </method>
+    <method name="FetchSecrets">
+      <arg name="collection" type="s" direction="in"/>
+      <arg name="conditions" type="a(sss)" direction="in"/>
+      <arg name="session" type="o" direction="in"/>
+      <arg name="items" type="a(osa{ss}(oayays))" direction="out"/>
+    </method>
+    <method name="WriteItems">
+      <arg name="items" type="a(osa{ss}(oayays)b)" direction="in"/>
+      <arg name="written" type="ao" direction="out"/>
+    </method>
+  </interface>
+  <interface name="org.freedesktop.DBus.ObjectManager">
+    <method name="GetManagedObjects">
+      <arg name="objects" type="a{oa{sa{sv}}}" direction="out"/>
+    </method>
+    <signal name="InterfacesAdded">
+      <arg name="object" type="o"/>
+      <arg name="interfaces" type="a{sa{sv}}"/>
+    </signal>
+    <signal name="InterfacesRemoved">
+      <arg name="object" type="o"/>
+      <arg name="interfaces" type="as"/>
+    </signal>
+  </interface>
 </node>`
 }
@@ -2,15 +2,20 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"maps"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
 	"sync"
 	"testing"
 	"time"
+	"unsafe"
 
 	"github.com/godbus/dbus/v5"
+	"golang.org/x/sys/unix"
 
 	"github.com/nikicat/gopass-secret-service/internal/config"
 	dbtypes "github.com/nikicat/gopass-secret-service/internal/dbus"
@@ -23,6 +28,22 @@ type mockStore struct {
 	collections map[string]*store.CollectionData
 	aliases     map[string]string
 	items       map[string]map[string]*store.ItemData // collection -> id -> item
+
+	// handedOut and received are the secret slices GetItem returned and
+	// CreateItem/UpdateItem were given, which the service must wipe.
+	handedOut [][]byte
+	received  [][]byte
+
+	// failLabel makes CreateItem fail for items with this label.
+	failLabel string
+}
+
+// withSecretCopy returns a copy of item that doesn't share its secret, as
+// real stores don't.
+func withSecretCopy(item *store.ItemData) *store.ItemData {
+	c := *item
+	c.Secret = append([]byte(nil), item.Secret...)
+	return &c
 }
 
 func newMockStore() *mockStore {
@@ -81,6 +102,23 @@ func (m *mockStore) SetCollectionLabel(_ context.Context, name, label string) er
 	return fmt.Errorf("not found")
 }
 
+func (m *mockStore) RenameCollection(_ context.Context, from, to string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	c, ok := m.collections[from]
+	if !ok {
+		return fmt.Errorf("not found")
+	}
+	if _, ok := m.collections[to]; ok {
+		return fmt.Errorf("exists")
+	}
+	c.Name = to
+	m.collections[to], m.items[to] = c, m.items[from]
+	delete(m.collections, from)
+	delete(m.items, from)
+	return nil
+}
+
 func (m *mockStore) Items(_ context.Context, collection string) ([]string, error) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -97,7 +135,9 @@ func (m *mockStore) GetItem(_ context.Context, collection, id string) (*store.It
 	defer m.mu.Unlock()
 	if collItems, ok := m.items[collection]; ok {
 		if item, ok := collItems[id]; ok {
-			return item, nil
+			out := withSecretCopy(item)
+			m.handedOut = append(m.handedOut, out.Secret)
+			return out, nil
 		}
 	}
 	return nil, fmt.Errorf("not found")
@@ -109,7 +149,11 @@ func (m *mockStore) CreateItem(_ context.Context, collection string, item *store
 	if m.items[collection] == nil {
 		m.items[collection] = make(map[string]*store.ItemData)
 	}
-	m.items[collection][item.ID] = item
+	if m.failLabel != "" && item.Label == m.failLabel {
+		return "", fmt.Errorf("cannot store %q", item.Label)
+	}
+	m.received = append(m.received, item.Secret)
+	m.items[collection][item.ID] = withSecretCopy(item)
 	return item.ID, nil
 }
 
@@ -119,7 +163,8 @@ func (m *mockStore) UpdateItem(_ context.Context, collection, id string, item *s
 	if m.items[collection] == nil {
 		return fmt.Errorf("not found")
 	}
-	m.items[collection][id] = item
+	m.received = append(m.received, item.Secret)
+	m.items[collection][id] = withSecretCopy(item)
 	return nil
 }
 
@@ -132,6 +177,21 @@ func (m *mockStore) DeleteItem(_ context.Context, collection, id string) error {
 	return nil
 }
 
+func (m *mockStore) MoveItem(_ context.Context, from, id, to string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	item, ok := m.items[from][id]
+	if !ok {
+		return "", fmt.Errorf("not found")
+	}
+	if m.items[to] == nil {
+		m.items[to] = make(map[string]*store.ItemData)
+	}
+	m.items[to][id] = item
+	delete(m.items[from], id)
+	return id, nil
+}
+
 func (m *mockStore) SearchItems(_ context.Context, collection string, attrs map[string]string) ([]*store.ItemData, error) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -186,10 +246,20 @@ func (m *mockStore) GetAlias(_ context.Context, alias string) (string, error) {
 func (m *mockStore) SetAlias(_ context.Context, alias, collection string) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
+	if collection == "" {
+		delete(m.aliases, alias)
+		return nil
+	}
 	m.aliases[alias] = collection
 	return nil
 }
 
+func (m *mockStore) Aliases(_ context.Context) (map[string]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return maps.Clone(m.aliases), nil
+}
+
 func (m *mockStore) Close(_ context.Context) error { return nil }
 
 // startTestBus starts an isolated dbus-daemon and returns a connection and cleanup func.
@@ -205,15 +275,16 @@ func startTestBus(t *testing.T) (*dbus.Conn, func()) {
 		t.Fatalf("start dbus-daemon: %v", err)
 	}
 
-	// Wait for socket
+	// The socket file appears before dbus-daemon listens on it, so wait
+	// until a dial succeeds.
+	var conn *dbus.Conn
+	var err error
 	for range 50 {
-		if _, err := os.Stat(sock); err == nil {
+		if conn, err = dbus.Dial(addr); err == nil {
 			break
 		}
 		time.Sleep(100 * time.Millisecond)
 	}
-
-	conn, err := dbus.Dial(addr)
 	if err != nil {
 		_ = cmd.Process.Kill()
 		t.Fatalf("dial bus: %v", err)
@@ -310,6 +381,269 @@ func TestDeleteCollection_RemovesFromMap(t *testing.T) {
 	}
 }
 
+func TestCreateCollection_VolatileGoesToKeyring(t *testing.T) {
+	svc, ms, cleanup := newTestService(t)
+	defer cleanup()
+
+	props := map[string]dbus.Variant{
+		dbtypes.CollectionVolatileProperty: dbus.MakeVariant(true),
+	}
+	if _, _, dbusErr := svc.CreateCollection(props, "oidc"); dbusErr == nil {
+		t.Fatal("volatile CreateCollection succeeded without a keyring")
+	}
+
+	keyring, err := store.NewKeyringStore()
+	if err != nil {
+		t.Skipf("kernel keyring unavailable: %v", err)
+	}
+	svc.volatile = keyring
+	svc.store = store.NewMultiStore(ms, store.Route{Store: keyring, Has: keyring.HasCollection})
+
+	if _, _, dbusErr := svc.CreateCollection(props, "oidc"); dbusErr != nil {
+		t.Fatalf("CreateCollection: %v", dbusErr)
+	}
+	if !keyring.HasCollection("oidc") {
+		t.Fatal("volatile collection not created in the keyring")
+	}
+	if _, ok := ms.collections["oidc"]; ok {
+		t.Error("volatile collection created in the durable store")
+	}
+
+	coll, ok := svc.collections.Get("oidc")
+	if !ok {
+		t.Fatal("collection not exported")
+	}
+	if _, dbusErr := coll.Delete(); dbusErr != nil {
+		t.Fatalf("Delete: %v", dbusErr)
+	}
+	if keyring.HasCollection("oidc") {
+		t.Error("volatile collection still in the keyring after Delete")
+	}
+}
+
+func TestWatchExpired_UnexportsExpiredItems(t *testing.T) {
+	svc, ms, cleanup := newTestService(t)
+	defer cleanup()
+
+	keyring, err := store.NewKeyringStore()
+	if err != nil {
+		t.Skipf("kernel keyring unavailable: %v", err)
+	}
+	svc.volatile = keyring
+	svc.store = store.NewMultiStore(ms, store.Route{Store: keyring, Has: keyring.HasCollection})
+
+	ctx := context.Background()
+	id, err := keyring.CreateItem(ctx, store.SessionCollectionName, &store.ItemData{
+		Secret:     []byte("token"),
+		Attributes: map[string]string{store.TTLAttribute: "1"},
+	})
+	if err != nil {
+		t.Fatalf("CreateItem: %v", err)
+	}
+	if _, err := svc.collections.GetOrCreate(store.SessionCollectionName); err != nil {
+		t.Fatalf("export collection: %v", err)
+	}
+	path := dbtypes.ItemPath(store.SessionCollectionName, id)
+	svc.items.Add(store.SessionCollectionName, id)
+
+	svc.watchExpired()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		svc.mu.RLock()
+		exported := svc.items.Has(path)
+		svc.mu.RUnlock()
+		if !exported {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("expired item still exported")
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+func TestReapExpired_DeletesAndUnexportsExpiredItems(t *testing.T) {
+	svc, ms, cleanup := newTestService(t)
+	defer cleanup()
+
+	ms.mu.Lock()
+	ms.collections["default"] = &store.CollectionData{Name: "default", Label: "Default"}
+	ms.items["default"] = map[string]*store.ItemData{
+		"expired": {ID: "expired", Expires: time.Now().Add(-time.Minute)},
+		"fresh":   {ID: "fresh", Expires: time.Now().Add(time.Hour)},
+		"forever": {ID: "forever"},
+	}
+	ms.mu.Unlock()
+	for _, id := range []string{"expired", "fresh", "forever"} {
+		svc.items.Add("default", id)
+	}
+
+	obj := svc.conn.Object("org.freedesktop.secrets", dbtypes.ItemPath("default", "fresh"))
+	variant, err := obj.GetProperty(dbtypes.GopassSecretItemInterface + ".Expires")
+	if err != nil {
+		t.Fatalf("GetProperty Expires: %v", err)
+	}
+	if got, _ := variant.Value().(uint64); got != uint64(ms.items["default"]["fresh"].Expires.Unix()) {
+		t.Errorf("Expires = %v, want the item's expiry", variant.Value())
+	}
+
+	svc.reapExpired(context.Background())
+
+	if _, ok := ms.items["default"]["expired"]; ok {
+		t.Error("expired item not deleted from the store")
+	}
+	if _, derr := svc.itemAt(dbtypes.ItemPath("default", "expired")); derr == nil {
+		t.Error("expired item still exported")
+	}
+	for _, id := range []string{"fresh", "forever"} {
+		if _, derr := svc.itemAt(dbtypes.ItemPath("default", id)); derr != nil {
+			t.Errorf("item %s was reaped", id)
+		}
+	}
+}
+
+func TestRemoveDuplicates_DeletesAndUnexports(t *testing.T) {
+	svc, ms, cleanup := newTestService(t)
+	defer cleanup()
+
+	now := time.Now()
+	attrs := map[string]string{"service": "github"}
+	ms.mu.Lock()
+	ms.collections["default"] = &store.CollectionData{Name: "default", Label: "Default"}
+	ms.items["default"] = map[string]*store.ItemData{
+		"old":   {ID: "old", Secret: []byte("a"), Attributes: attrs, Modified: now.Add(-time.Hour)},
+		"new":   {ID: "new", Secret: []byte("b"), Attributes: attrs, Modified: now},
+		"other": {ID: "other", Secret: []byte("a"), Attributes: map[string]string{"service": "gitlab"}},
+	}
+	ms.mu.Unlock()
+
+	ext := &gopassSecret{svc}
+	groups, dbusErr := ext.FindDuplicates("")
+	if dbusErr != nil {
+		t.Fatalf("FindDuplicates: %v", dbusErr)
+	}
+	if len(groups) != 1 || len(groups[0].Items) != 2 || !groups[0].ValuesDiffer {
+		t.Fatalf("FindDuplicates = %+v, want one group of two differing items", groups)
+	}
+	newest, oldest := groups[0].Items[0].Path, groups[0].Items[1].Path
+	if newest != dbtypes.ItemPath("default", "new") {
+		t.Errorf("first item = %s, want the newest", newest)
+	}
+	if _, derr := svc.itemAt(oldest); derr != nil {
+		t.Error("FindDuplicates didn't export the items it returned")
+	}
+
+	removed, dbusErr := ext.RemoveDuplicates(newest)
+	if dbusErr != nil {
+		t.Fatalf("RemoveDuplicates: %v", dbusErr)
+	}
+	if len(removed) != 1 || removed[0] != oldest {
+		t.Errorf("RemoveDuplicates = %v, want [%s]", removed, oldest)
+	}
+	if _, derr := svc.itemAt(oldest); derr == nil {
+		t.Error("removed duplicate still exported")
+	}
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	if _, ok := ms.items["default"]["old"]; ok {
+		t.Error("removed duplicate still in the store")
+	}
+	if len(ms.items["default"]) != 2 {
+		t.Errorf("store has %d items, want 2", len(ms.items["default"]))
+	}
+}
+
+func TestRemoveDuplicates_ErrorNames(t *testing.T) {
+	svc, ms, cleanup := newTestService(t)
+	defer cleanup()
+
+	ms.mu.Lock()
+	ms.collections["default"] = &store.CollectionData{Name: "default", Label: "Default"}
+	ms.items["default"] = map[string]*store.ItemData{"bare": {ID: "bare", Secret: []byte("a")}}
+	ms.mu.Unlock()
+
+	ext := &gopassSecret{svc}
+	if _, derr := ext.FindDuplicates("missing"); derr == nil || derr.Name != ErrNoSuchObject {
+		t.Errorf("FindDuplicates of a missing collection: %v, want %s", derr, ErrNoSuchObject)
+	}
+	if _, derr := ext.RemoveDuplicates(dbtypes.ItemPath("default", "gone")); derr == nil || derr.Name != ErrNoSuchObject {
+		t.Errorf("RemoveDuplicates of a missing item: %v, want %s", derr, ErrNoSuchObject)
+	}
+	// The store refuses items without attributes; that's a failure, not a
+	// missing object.
+	if _, derr := ext.RemoveDuplicates(dbtypes.ItemPath("default", "bare")); derr == nil || derr.Name != "org.freedesktop.DBus.Error.Failed" {
+		t.Errorf("RemoveDuplicates of an item without attributes: %v, want Failed", derr)
+	}
+}
+
+func TestMoveItemAndRenameCollection(t *testing.T) {
+	svc, ms, cleanup := newTestService(t)
+	defer cleanup()
+
+	for _, name := range []string{"login", "work"} {
+		if _, _, dbusErr := svc.CreateCollection(map[string]dbus.Variant{
+			"org.freedesktop.Secret.Collection.Label": dbus.MakeVariant(name),
+		}, ""); dbusErr != nil {
+			t.Fatalf("CreateCollection(%s): %v", name, dbusErr)
+		}
+	}
+	ms.mu.Lock()
+	ms.items["login"] = map[string]*store.ItemData{
+		"a": {ID: "a", Label: "A", Secret: []byte("x")},
+	}
+	ms.mu.Unlock()
+	if dbusErr := svc.SetAlias("mine", dbtypes.CollectionPath("work")); dbusErr != nil {
+		t.Fatalf("SetAlias: %v", dbusErr)
+	}
+	svc.items.Add("login", "a")
+
+	ext := &gopassSecret{svc}
+	moved, dbusErr := ext.MoveItem(dbtypes.ItemPath("login", "a"), dbtypes.AliasPath("mine"))
+	if dbusErr != nil {
+		t.Fatalf("MoveItem: %v", dbusErr)
+	}
+	if moved != dbtypes.ItemPath("work", "a") {
+		t.Errorf("MoveItem = %s", moved)
+	}
+	if _, derr := svc.itemAt(dbtypes.ItemPath("login", "a")); derr == nil {
+		t.Error("moved item still exported at its old path")
+	}
+	if _, derr := svc.itemAt(moved); derr != nil {
+		t.Error("moved item not exported at its new path")
+	}
+	if _, dbusErr := ext.MoveItem(dbtypes.ItemPath("login", "a"), dbtypes.CollectionPath("missing")); dbusErr == nil {
+		t.Error("MoveItem to a missing collection succeeded")
+	}
+
+	if _, dbusErr := ext.RenameCollection(dbtypes.CollectionPath("work"), "login"); dbusErr == nil {
+		t.Error("RenameCollection onto an existing collection succeeded")
+	}
+	renamed, dbusErr := ext.RenameCollection(dbtypes.CollectionPath("work"), "job")
+	if dbusErr != nil {
+		t.Fatalf("RenameCollection: %v", dbusErr)
+	}
+	if renamed != dbtypes.CollectionPath("job") {
+		t.Errorf("RenameCollection = %s", renamed)
+	}
+	if _, ok := svc.collections.Get("work"); ok {
+		t.Error("renamed collection still exported under its old name")
+	}
+	if _, derr := svc.itemAt(dbtypes.ItemPath("job", "a")); derr != nil {
+		t.Error("item of the renamed collection not exported at its new path")
+	}
+	if _, derr := svc.itemAt(moved); derr == nil {
+		t.Error("item of the renamed collection still exported at its old path")
+	}
+	if aliases, _ := ext.ListAliases(); aliases["mine"] != renamed {
+		t.Errorf("alias points at %s, want %s", aliases["mine"], renamed)
+	}
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	if ms.aliases["mine"] != "job" {
+		t.Errorf("store alias = %q, want job", ms.aliases["mine"])
+	}
+}
+
 func TestDeleteCollection_AllowsRecreation(t *testing.T) {
 	svc, _, cleanup := newTestService(t)
 	defer cleanup()
@@ -409,6 +743,75 @@ func TestUnlock_AliasPath(t *testing.T) {
 	}
 }
 
+func TestSetAlias_ExportsAliasPath(t *testing.T) {
+	svc, ms, cleanup := newTestService(t)
+	defer cleanup()
+
+	collPath, _, dbusErr := svc.CreateCollection(map[string]dbus.Variant{
+		"org.freedesktop.Secret.Collection.Label": dbus.MakeVariant("Login"),
+	}, "login")
+	if dbusErr != nil {
+		t.Fatalf("CreateCollection: %v", dbusErr)
+	}
+
+	label := func(alias string) (string, error) {
+		v, err := svc.conn.Object("org.freedesktop.secrets", dbtypes.AliasPath(alias)).
+			GetProperty(dbtypes.CollectionInterface + ".Label")
+		if err != nil {
+			return "", err
+		}
+		return v.Value().(string), nil
+	}
+	listAliases := func() map[string]dbus.ObjectPath {
+		var aliases map[string]dbus.ObjectPath
+		if err := svc.conn.Object("org.freedesktop.secrets", dbtypes.ServicePath).
+			Call(dbtypes.GopassSecretInterface+".ListAliases", 0).Store(&aliases); err != nil {
+			t.Fatalf("ListAliases: %v", err)
+		}
+		return aliases
+	}
+
+	if dbusErr := svc.SetAlias("work", collPath); dbusErr != nil {
+		t.Fatalf("SetAlias: %v", dbusErr)
+	}
+	if got, err := label("work"); err != nil || got != "Login" {
+		t.Errorf("Label at the alias path = %q, %v", got, err)
+	}
+	if got := listAliases(); got["work"] != collPath || got["default"] != dbtypes.CollectionPath("default") {
+		t.Errorf("ListAliases = %v", got)
+	}
+	if dbusErr := svc.SetAlias("work", dbtypes.CollectionPath("missing")); dbusErr == nil {
+		t.Error("SetAlias to a missing collection succeeded")
+	}
+
+	if dbusErr := svc.SetAlias("work", "/"); dbusErr != nil {
+		t.Fatalf("SetAlias remove: %v", dbusErr)
+	}
+	if _, err := label("work"); err == nil {
+		t.Error("removed alias is still exported")
+	}
+	if _, ok := listAliases()["work"]; ok {
+		t.Error("ListAliases still lists the removed alias")
+	}
+
+	// Aliases in the store's table are exported at startup, and go away with
+	// their collection.
+	ms.mu.Lock()
+	ms.aliases["personal"] = "login"
+	ms.mu.Unlock()
+	svc.exportAliases()
+	if got, err := label("personal"); err != nil || got != "Login" {
+		t.Errorf("Label at the stored alias path = %q, %v", got, err)
+	}
+	coll, _ := svc.collections.Get("login")
+	if _, dbusErr := coll.Delete(); dbusErr != nil {
+		t.Fatalf("Delete: %v", dbusErr)
+	}
+	if _, err := label("personal"); err == nil {
+		t.Error("alias of a deleted collection is still exported")
+	}
+}
+
 func TestUnlock_CollectionPath(t *testing.T) {
 	svc, ms, cleanup := newTestService(t)
 	defer cleanup()
@@ -504,10 +907,7 @@ func TestItemProperties_ReflectStoreData(t *testing.T) {
 	ms.mu.Unlock()
 
 	// Directly export the item (simulates what ExportAllItems does)
-	_, err := svc.items.GetOrCreate("default", "i52f9c2333e2246e1bd6e533333f68788")
-	if err != nil {
-		t.Fatalf("GetOrCreate item: %v", err)
-	}
+	svc.items.Add("default", "i52f9c2333e2246e1bd6e533333f68788")
 
 	// Read D-Bus properties via the connection
 	itemPath := dbtypes.ItemPath("default", "i52f9c2333e2246e1bd6e533333f68788")
@@ -551,10 +951,7 @@ func TestItemProperties_EmptyWhenStoreFailsDuringExport(t *testing.T) {
 	ms.mu.Unlock()
 
 	// Export item — refreshProperties will call GetItem which returns "not found"
-	_, err := svc.items.GetOrCreate("default", "i52f9c2333e2246e1bd6e533333f68788")
-	if err != nil {
-		t.Fatalf("GetOrCreate item: %v", err)
-	}
+	svc.items.Add("default", "i52f9c2333e2246e1bd6e533333f68788")
 
 	// NOW add the item to the store (simulates gpg-agent becoming available)
 	ms.mu.Lock()
@@ -655,9 +1052,9 @@ func TestSearchItems_ExportsResultsOverDBus(t *testing.T) {
 	}
 	ms.mu.Unlock()
 
-	// Deliberately DO NOT call svc.items.GetOrCreate. This is the
-	// post-restart / external-write state: the store has the item, but the
-	// ItemManager does not. The fix in SearchItems must close that gap.
+	// Deliberately DO NOT call svc.items.Add. This is the post-restart /
+	// external-write state: the store has the item, but the ItemManager
+	// does not. The item subtree handler must look it up in the store.
 
 	sessionPath := openPlainSession(t, svc)
 	svcObj := svc.conn.Object("org.freedesktop.secrets", dbtypes.ServicePath)
@@ -699,6 +1096,43 @@ func TestSearchItems_ExportsResultsOverDBus(t *testing.T) {
 // branch in Collection.CreateItem updates the store and, before the fix, did
 // NOT register an Item proxy at the returned path — so the very next
 // Item.GetSecret would fail with UnknownInterface.
+func TestSearchQuery_OverDBus(t *testing.T) {
+	svc, ms, cleanup := newTestService(t)
+	defer cleanup()
+
+	ms.mu.Lock()
+	ms.collections["default"] = &store.CollectionData{Name: "default", Label: "Default"}
+	ms.items["default"] = map[string]*store.ItemData{
+		"gitlab": {ID: "gitlab", Attributes: map[string]string{"service": "https://gitlab.example.com", "username": "me"}},
+		"nouser": {ID: "nouser", Attributes: map[string]string{"service": "https://gitlab.example.com"}},
+		"github": {ID: "github", Attributes: map[string]string{"service": "https://github.com", "username": "me"}},
+	}
+	ms.mu.Unlock()
+
+	svcObj := svc.conn.Object("org.freedesktop.secrets", dbtypes.ServicePath)
+	var unlocked, locked []dbus.ObjectPath
+	if err := svcObj.Call(dbtypes.GopassSecretInterface+".SearchQuery", 0, "", []dbtypes.Condition{
+		{Key: "service", Op: "host", Value: "GitLab.example.com"},
+		{Key: "username", Op: "exists"},
+	}).Store(&unlocked, &locked); err != nil {
+		t.Fatalf("SearchQuery: %v", err)
+	}
+	want := dbtypes.ItemPath("default", "gitlab")
+	if len(unlocked) != 1 || unlocked[0] != want || len(locked) != 0 {
+		t.Errorf("SearchQuery = %v, %v; want [%s]", unlocked, locked, want)
+	}
+	if _, derr := svc.itemAt(want); derr != nil {
+		t.Error("result not exported")
+	}
+
+	err := svcObj.Call(dbtypes.GopassSecretInterface+".SearchQuery", 0, "", []dbtypes.Condition{
+		{Key: "service", Op: "regex", Value: "("},
+	}).Err
+	if err == nil {
+		t.Error("SearchQuery with an invalid regex succeeded")
+	}
+}
+
 func TestCreateItem_ReplaceTrueExportsExistingItem(t *testing.T) {
 	svc, ms, cleanup := newTestService(t)
 	defer cleanup()
@@ -762,6 +1196,92 @@ func TestCreateItem_ReplaceTrueExportsExistingItem(t *testing.T) {
 	}
 }
 
+// TestSecretsWipedAfterUse checks that the buffers a secret passes through
+// on its way out of the store (GetSecret) and into it (CreateItem) are zeroed,
+// or released for locked buffers, once the call is answered.
+func TestSecretsWipedAfterUse(t *testing.T) {
+	svc, ms, cleanup := newTestService(t)
+	defer cleanup()
+
+	const itemID = "i444444444444444444444444dddddddd"
+	ms.mu.Lock()
+	ms.collections["default"] = &store.CollectionData{Name: "default", Label: "Default"}
+	ms.items["default"] = map[string]*store.ItemData{
+		itemID: {ID: itemID, Label: "stored", Secret: []byte("stored-secret"), ContentType: "text/plain"},
+	}
+	ms.mu.Unlock()
+	svc.items.Add("default", itemID)
+
+	sessionPath := openPlainSession(t, svc)
+	itemObj := svc.conn.Object("org.freedesktop.secrets", dbtypes.ItemPath("default", itemID))
+	var secret dbtypes.Secret
+	if err := itemObj.Call(dbtypes.ItemInterface+".GetSecret", 0, sessionPath).Store(&secret); err != nil {
+		t.Fatalf("GetSecret: %v", err)
+	}
+	if string(secret.Value) != "stored-secret" {
+		t.Errorf("GetSecret = %q, want stored-secret", secret.Value)
+	}
+
+	collObj := svc.conn.Object("org.freedesktop.secrets", dbtypes.CollectionPath("default"))
+	properties := map[string]dbus.Variant{
+		"org.freedesktop.Secret.Item.Label":      dbus.MakeVariant("created"),
+		"org.freedesktop.Secret.Item.Attributes": dbus.MakeVariant(map[string]string{"service": "wipe-test"}),
+	}
+	secretIn := dbtypes.Secret{Session: sessionPath, Parameters: []byte{}, Value: []byte("created-secret"), ContentType: "text/plain"}
+	var itemPath, promptPath dbus.ObjectPath
+	if err := collObj.Call(dbtypes.CollectionInterface+".CreateItem", 0,
+		properties, secretIn, false).Store(&itemPath, &promptPath); err != nil {
+		t.Fatalf("CreateItem: %v", err)
+	}
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	if len(ms.handedOut) == 0 || len(ms.received) == 0 {
+		t.Fatalf("store saw %d reads and %d writes of secrets, want some of each", len(ms.handedOut), len(ms.received))
+	}
+	for i, b := range ms.handedOut {
+		if !isZero(b) {
+			t.Errorf("secret %d returned by GetItem wasn't wiped: %q", i, b)
+		}
+	}
+	for i, b := range ms.received {
+		if !wiped(b) {
+			t.Errorf("secret %d given to CreateItem/UpdateItem wasn't wiped: %q", i, b)
+		}
+	}
+	_, id, err := dbtypes.ParseItemPath(itemPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := ms.items["default"][id]; got == nil || string(got.Secret) != "created-secret" {
+		t.Errorf("stored item = %+v, want the created secret intact", got)
+	}
+}
+
+// wiped reports whether b is zeroed or no longer mapped, as a wiped
+// secmem.Buffer isn't. Reading the latter would fault.
+func wiped(b []byte) bool {
+	if len(b) == 0 {
+		return true
+	}
+	start := unsafe.Pointer(&b[0])
+	offset := uintptr(start) % uintptr(os.Getpagesize())
+	page := unsafe.Slice((*byte)(unsafe.Add(start, -int(offset))), 1)
+	if err := unix.Msync(page, unix.MS_ASYNC); errors.Is(err, unix.ENOMEM) {
+		return true
+	}
+	return isZero(b)
+}
+
+func isZero(b []byte) bool {
+	for _, c := range b {
+		if c != 0 {
+			return false
+		}
+	}
+	return true
+}
+
 // TestCollectionItemsProperty_LiveReadsStore covers the cached-property bug:
 // Collection.Items used to be set from prop.Export at construction time and
 // only refreshed on add/delete events, so items added to the store *between*
@@ -920,3 +1440,194 @@ func TestCollectionItemsProperty_ExportsAllItems(t *testing.T) {
 		t.Fatalf("Item.GetSecret over D-Bus after reading Items property: %v", err)
 	}
 }
+
+// TestSubtree_ResolvesObjectsPerCall checks that collection and item paths are
+// served without exporting each object: unknown ones answer NoSuchObject, and
+// introspection is generated for each node.
+func TestSubtree_ResolvesObjectsPerCall(t *testing.T) {
+	svc, ms, cleanup := newTestService(t)
+	defer cleanup()
+
+	const itemID = "i333333333333333333333333cccccccc"
+	ms.mu.Lock()
+	ms.items["default"] = map[string]*store.ItemData{
+		itemID: {ID: itemID, Label: "lazy", Secret: []byte("v"), ContentType: "text/plain"},
+	}
+	ms.mu.Unlock()
+	sessionPath := openPlainSession(t, svc)
+
+	for _, path := range []dbus.ObjectPath{
+		dbtypes.ItemPath("default", "inope"),
+		dbtypes.ItemPath("missing", itemID),
+	} {
+		err := svc.conn.Object("org.freedesktop.secrets", path).
+			Call(dbtypes.ItemInterface+".GetSecret", 0, sessionPath).Err
+		var derr dbus.Error
+		if !errors.As(err, &derr) || derr.Name != ErrNoSuchObject {
+			t.Errorf("GetSecret on %s: err = %v, want %s", path, err, ErrNoSuchObject)
+		}
+	}
+	if _, err := svc.conn.Object("org.freedesktop.secrets", dbtypes.CollectionPath("missing")).
+		GetProperty(dbtypes.CollectionInterface + ".Label"); err == nil {
+		t.Error("Label of a missing collection was served")
+	}
+
+	introspect := func(path dbus.ObjectPath) string {
+		t.Helper()
+		var xml string
+		if err := svc.conn.Object("org.freedesktop.secrets", path).
+			Call("org.freedesktop.DBus.Introspectable.Introspect", 0).Store(&xml); err != nil {
+			t.Fatalf("Introspect %s: %v", path, err)
+		}
+		return xml
+	}
+	if xml := introspect(dbtypes.CollectionBasePath); !strings.Contains(xml, `<node name="default"/>`) {
+		t.Errorf("collections node doesn't list default:\n%s", xml)
+	}
+	xml := introspect(dbtypes.CollectionPath("default"))
+	if !strings.Contains(xml, dbtypes.CollectionInterface) || !strings.Contains(xml, `<node name="`+itemID+`"/>`) {
+		t.Errorf("collection node lacks its interface or item:\n%s", xml)
+	}
+	if xml := introspect(dbtypes.ItemPath("default", itemID)); !strings.Contains(xml, dbtypes.ItemInterface) {
+		t.Errorf("item node lacks the item interface:\n%s", xml)
+	}
+}
+
+func TestObjectManager_ListsObjectsAndSignalsChanges(t *testing.T) {
+	svc, ms, cleanup := newTestService(t)
+	defer cleanup()
+
+	const itemID = "i444444444444444444444444dddddddd"
+	ms.mu.Lock()
+	ms.items["default"] = map[string]*store.ItemData{
+		itemID: {ID: itemID, Label: "listed", Secret: []byte("v"), Attributes: map[string]string{"service": "om"}},
+	}
+	ms.mu.Unlock()
+
+	if err := svc.conn.AddMatchSignal(dbus.WithMatchInterface(dbtypes.ObjectManagerInterface)); err != nil {
+		t.Fatalf("AddMatchSignal: %v", err)
+	}
+	signals := make(chan *dbus.Signal, 16)
+	svc.conn.Signal(signals)
+	next := func(name string) *dbus.Signal {
+		t.Helper()
+		select {
+		case sig := <-signals:
+			if sig.Name != dbtypes.ObjectManagerInterface+"."+name {
+				t.Fatalf("got signal %s, want %s", sig.Name, name)
+			}
+			return sig
+		case <-time.After(5 * time.Second):
+			t.Fatalf("no %s signal", name)
+			return nil
+		}
+	}
+
+	var objects map[dbus.ObjectPath]map[string]map[string]dbus.Variant
+	if err := svc.conn.Object("org.freedesktop.secrets", dbtypes.ServicePath).
+		Call(dbtypes.ObjectManagerInterface+".GetManagedObjects", 0).Store(&objects); err != nil {
+		t.Fatalf("GetManagedObjects: %v", err)
+	}
+	if _, ok := objects[dbtypes.CollectionPath("default")][dbtypes.CollectionInterface]; !ok {
+		t.Errorf("default collection not listed: %v", objects)
+	}
+	itemPath := dbtypes.ItemPath("default", itemID)
+	if label := objects[itemPath][dbtypes.ItemInterface]["Label"].Value(); label != "listed" {
+		t.Errorf("item Label = %v, want listed", label)
+	}
+	if _, ok := objects[itemPath][dbtypes.GopassSecretItemInterface]["Expires"]; !ok {
+		t.Error("item Expires not listed")
+	}
+
+	sessionPath := openPlainSession(t, svc)
+	coll, _ := svc.collections.Get("default")
+	ms.mu.Lock()
+	reads := len(ms.handedOut)
+	ms.mu.Unlock()
+	newPath, _, derr := coll.CreateItem(map[string]dbus.Variant{
+		"org.freedesktop.Secret.Item.Label": dbus.MakeVariant("added"),
+	}, dbtypes.Secret{Session: sessionPath, Value: []byte("x"), ContentType: "text/plain"}, false)
+	if derr != nil {
+		t.Fatalf("CreateItem: %v", derr)
+	}
+	sig := next("InterfacesAdded")
+	ifaces, _ := sig.Body[1].(map[string]map[string]dbus.Variant)
+	if sig.Body[0] != newPath || ifaces[dbtypes.ItemInterface]["Label"].Value() != "added" {
+		t.Errorf("InterfacesAdded = %v, want %s labelled added", sig.Body, newPath)
+	}
+	ms.mu.Lock()
+	if len(ms.handedOut) != reads {
+		t.Error("announcing the new item read it back from the store")
+	}
+	ms.mu.Unlock()
+
+	if _, derr := coll.Delete(); derr != nil {
+		t.Fatalf("Delete: %v", derr)
+	}
+	removed := map[dbus.ObjectPath]bool{}
+	for range 3 {
+		removed[next("InterfacesRemoved").Body[0].(dbus.ObjectPath)] = true
+	}
+	for _, path := range []dbus.ObjectPath{itemPath, newPath, dbtypes.CollectionPath("default")} {
+		if !removed[path] {
+			t.Errorf("no InterfacesRemoved for %s", path)
+		}
+	}
+}
+
+func TestBatchMethods_WriteFetchAndSearch(t *testing.T) {
+	svc, ms, cleanup := newTestService(t)
+	defer cleanup()
+
+	session := openPlainSession(t, svc)
+	svcObj := svc.conn.Object("org.freedesktop.secrets", dbtypes.ServicePath)
+	write := func(items ...dbtypes.ItemWrite) ([]dbus.ObjectPath, error) {
+		var paths []dbus.ObjectPath
+		err := svcObj.Call(dbtypes.GopassSecretInterface+".WriteItems", 0, items).Store(&paths)
+		return paths, err
+	}
+	item := func(label, user, secret string, replace bool) dbtypes.ItemWrite {
+		return dbtypes.ItemWrite{
+			Collection: dbtypes.CollectionPath("default"),
+			Label:      label,
+			Attributes: map[string]string{"service": "batch", "user": user},
+			Secret:     dbtypes.Secret{Session: session, Parameters: []byte{}, Value: []byte(secret), ContentType: "text/plain"},
+			Replace:    replace,
+		}
+	}
+
+	paths, err := write(item("a", "alice", "pw-a", false), item("b", "bob", "pw-b", false))
+	if err != nil || len(paths) != 2 {
+		t.Fatalf("WriteItems = %v, %v; want two paths", paths, err)
+	}
+
+	// The second write fails, so the update before it is undone.
+	ms.mu.Lock()
+	ms.failLabel = "broken"
+	ms.mu.Unlock()
+	if _, err := write(item("a2", "alice", "new-a", true), item("broken", "carol", "pw-c", false)); err == nil {
+		t.Fatal("WriteItems with a failing item succeeded")
+	}
+
+	var secrets []dbtypes.ItemSecret
+	if err := svcObj.Call(dbtypes.GopassSecretInterface+".FetchSecrets", 0, "",
+		[]dbtypes.Condition{{Key: "service", Op: "eq", Value: "batch"}}, session).Store(&secrets); err != nil {
+		t.Fatalf("FetchSecrets: %v", err)
+	}
+	got := make(map[string]string)
+	for _, s := range secrets {
+		got[s.Label] = string(s.Secret.Value)
+	}
+	if want := map[string]string{"a": "pw-a", "b": "pw-b"}; !maps.Equal(got, want) {
+		t.Errorf("FetchSecrets after failed batch = %v, want %v", got, want)
+	}
+
+	var infos []dbtypes.ItemInfo
+	if err := svcObj.Call(dbtypes.GopassSecretInterface+".SearchDetails", 0, "default",
+		[]dbtypes.Condition{{Key: "user", Op: "eq", Value: "bob"}}).Store(&infos); err != nil {
+		t.Fatalf("SearchDetails: %v", err)
+	}
+	if len(infos) != 1 || infos[0].Path != paths[1] || infos[0].Label != "b" || infos[0].Attributes["user"] != "bob" {
+		t.Errorf("SearchDetails = %+v, want item b at %s", infos, paths[1])
+	}
+}
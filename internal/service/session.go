@@ -9,6 +9,7 @@ import (
 
 	"github.com/nikicat/gopass-secret-service/internal/crypto"
 	dbtypes "github.com/nikicat/gopass-secret-service/internal/dbus"
+	"github.com/nikicat/gopass-secret-service/internal/secmem"
 )
 
 // Session represents a D-Bus session for encrypted communication
@@ -196,8 +197,9 @@ func (s *Session) Encrypt(plaintext []byte) (params, ciphertext []byte, err erro
 	return s.crypto.Encrypt(plaintext)
 }
 
-// Decrypt decrypts data using this session's crypto
-func (s *Session) Decrypt(params, ciphertext []byte) (plaintext []byte, err error) {
+// Decrypt decrypts data using this session's crypto into a locked buffer,
+// which the caller wipes
+func (s *Session) Decrypt(params, ciphertext []byte) (*secmem.Buffer, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
@@ -0,0 +1,73 @@
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/nikicat/gopass-secret-service/internal/config"
+	"github.com/nikicat/gopass-secret-service/internal/gitsync"
+	"github.com/nikicat/gopass-secret-service/internal/store"
+)
+
+// newSyncer creates the git sync scheduler for the repositories behind the
+// root store and every routed mount. onPull receives the files a pull changed.
+func newSyncer(cfg *config.Config, onPull func(files []string)) *gitsync.Syncer {
+	dirs := []string{store.GopassMountDir(cfg.StorePath, "")}
+	for _, r := range cfg.Routes {
+		dirs = append(dirs, store.GopassMountDir(cfg.StorePath, r.Mount))
+	}
+	return gitsync.New(dirs, gitsync.Options{
+		PushDelay:    cfg.Sync.PushDelay,
+		PullInterval: cfg.Sync.PullInterval,
+		OnPull:       func(_ string, files []string) { onPull(files) },
+		Logf:         log.Printf,
+	})
+}
+
+// startSync starts the sync scheduler, beginning with a pull of every
+// repository.
+func (s *Service) startSync() {
+	if s.syncer == nil {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	s.stopSync = cancel
+	s.syncer.Start(ctx)
+	log.Printf("Git sync enabled (push delay %s, pull interval %s)", s.cfg.Sync.PushDelay, s.cfg.Sync.PullInterval)
+}
+
+// stopSyncing stops the scheduler and pushes writes that are still waiting
+// for their push delay, so they aren't stranded until the next start.
+func (s *Service) stopSyncing() {
+	if s.stopSync == nil {
+		return
+	}
+	s.stopSync()
+	for _, st := range s.syncer.Status() {
+		if !st.Pending {
+			continue
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		// Errors are logged by the syncer.
+		_ = s.syncer.SyncNow(ctx)
+		cancel()
+		return
+	}
+}
+
+// reloadPulled re-reads the entries a pull changed and emits the matching
+// signals. With the watcher running the pulled files show up as external
+// changes anyway, so there's nothing to do.
+func (s *Service) reloadPulled(files []string) {
+	if s.watching {
+		return
+	}
+	r, ok := s.store.(store.Refresher)
+	if !ok {
+		return
+	}
+	if changes := r.Refresh(files); len(changes) > 0 {
+		s.applyStoreChanges(changes)
+	}
+}
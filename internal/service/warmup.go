@@ -0,0 +1,49 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/nikicat/gopass-secret-service/internal/store"
+)
+
+// warmUpLogInterval is how often warm-up progress is logged.
+const warmUpLogInterval = 5 * time.Second
+
+// startWarmUp decrypts, in the background, every entry whose metadata isn't
+// cached yet, so the first search doesn't have to. It's a no-op unless
+// decrypt.warm_up is set.
+func (s *Service) startWarmUp() {
+	if !s.cfg.Decrypt.WarmUp {
+		return
+	}
+	w, ok := s.store.(store.Warmer)
+	if !ok {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	s.stopWarm = cancel
+	go func() {
+		start := time.Now()
+		var last time.Time
+		entries := 0
+		err := w.Warm(ctx, func(done, total int) {
+			if done == total {
+				entries += total
+			}
+			if now := time.Now(); done == total || now.Sub(last) >= warmUpLogInterval {
+				last = now
+				log.Printf("Warming up cache: %d/%d entries decrypted", done, total)
+			}
+		})
+		switch {
+		case errors.Is(err, context.Canceled):
+		case err != nil:
+			log.Printf("Warning: cache warm-up: %v", err)
+		default:
+			log.Printf("Cache warm-up done: %d entries decrypted in %s", entries, time.Since(start).Round(time.Millisecond))
+		}
+	}()
+}
@@ -0,0 +1,172 @@
+package service
+
+import (
+	"context"
+	"log"
+
+	"github.com/nikicat/gopass-secret-service/internal/config"
+	dbtypes "github.com/nikicat/gopass-secret-service/internal/dbus"
+	"github.com/nikicat/gopass-secret-service/internal/store"
+)
+
+// watchStore starts reflecting out-of-process store changes (gopass CLI edits,
+// git pulls) as D-Bus objects and signals. It's a no-op when watching is
+// disabled or the store can't be watched.
+func (s *Service) watchStore() {
+	if !s.cfg.Watch || s.cfg.Backend == config.BackendAge {
+		return
+	}
+	w, ok := s.store.(store.Watcher)
+	if !ok {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	// On error some stores may still be watched, so keep cancel either way.
+	if err := w.Watch(ctx, s.applyStoreChanges); err != nil {
+		log.Printf("Warning: not watching store for external changes: %v", err)
+	} else {
+		s.watching = true
+	}
+	s.stopWatch = cancel
+}
+
+// watchExpired unexports volatile items when their TTL runs out and emits
+// ItemDeleted. Unlike watchStore it doesn't depend on the watch setting: the
+// kernel reaping a key isn't an external change but part of the contract.
+func (s *Service) watchExpired() {
+	ctx, cancel := context.WithCancel(context.Background())
+	s.volatile.WatchExpired(ctx, s.applyStoreChanges)
+	s.stopExpired = cancel
+}
+
+// applyStoreChanges brings the exported objects in line with changes made to
+// the store behind the daemon's back and emits the matching signals.
+func (s *Service) applyStoreChanges(changes []store.Change) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, c := range changes {
+		switch {
+		case c.IsResync():
+			s.resyncCollections()
+		case c.ItemID == "":
+			s.applyCollectionChange(c.Collection, c.Removed)
+		default:
+			s.applyItemChange(c.Collection, c.ItemID, c.Removed)
+		}
+	}
+}
+
+// resyncCollections re-reads every collection, used when individual changes
+// were lost.
+func (s *Service) resyncCollections() {
+	ctx := context.Background()
+	names, err := s.store.Collections(ctx)
+	if err != nil {
+		log.Printf("Warning: resync after store change: %v", err)
+		return
+	}
+	present := make(map[string]bool, len(names))
+	for _, name := range names {
+		present[name] = true
+		s.applyCollectionChange(name, false)
+	}
+	for _, name := range s.collections.All() {
+		if !present[name] {
+			s.applyCollectionChange(name, true)
+		}
+	}
+}
+
+func (s *Service) applyCollectionChange(name string, removed bool) {
+	coll, exported := s.collections.Get(name)
+	if removed {
+		if exported {
+			log.Printf("Collection %s removed outside the daemon", name)
+			items := s.items.CollectionItems(name)
+			s.collections.Remove(name)
+			s.emitItemsRemoved(name, items)
+			s.emitCollectionDeleted(coll.Path())
+			s.refreshCollections()
+		}
+		return
+	}
+
+	if !exported {
+		var err error
+		if coll, err = s.collections.GetOrCreate(name); err != nil {
+			log.Printf("Warning: load externally created collection %s: %v", name, err)
+			return
+		}
+		log.Printf("Collection %s created outside the daemon", name)
+		s.emitCollectionCreated(coll.Path())
+		s.refreshCollections()
+		s.exportAliases()
+	}
+
+	// Items can appear or vanish without per-item events, e.g. when a whole
+	// collection directory is replaced; diff against the store.
+	ids, err := s.store.Items(context.Background(), name)
+	if err != nil {
+		log.Printf("Warning: list items of %s after store change: %v", name, err)
+		return
+	}
+	current := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		current[id] = true
+	}
+	for _, id := range s.items.CollectionItems(name) {
+		if !current[id] {
+			path := dbtypes.ItemPath(name, id)
+			s.items.Remove(path)
+			s.emitItemDeleted(name, path)
+		}
+	}
+	var added []string
+	for _, id := range ids {
+		if s.items.Add(name, id) {
+			added = append(added, id)
+		}
+	}
+	if len(added) > 0 {
+		meta := s.itemsMetadata(context.Background(), name)
+		for _, id := range added {
+			s.emitItemCreated(name, id, meta[id])
+		}
+	}
+	if exported {
+		s.emitCollectionChanged(coll.Path())
+	}
+	coll.refreshItems()
+}
+
+func (s *Service) applyItemChange(collection, id string, removed bool) {
+	path := dbtypes.ItemPath(collection, id)
+	known := s.items.Has(path)
+
+	if removed {
+		if !known {
+			return
+		}
+		s.items.Remove(path)
+		s.emitItemDeleted(collection, path)
+		if coll, ok := s.collections.Get(collection); ok {
+			coll.refreshItems()
+		}
+		return
+	}
+
+	if _, ok := s.collections.Get(collection); !ok {
+		// The item's collection is new as well; exporting it picks the item up.
+		s.applyCollectionChange(collection, false)
+		return
+	}
+	if !s.items.Add(collection, id) {
+		s.emitItemChanged(collection, path)
+		return
+	}
+	s.emitItemCreated(collection, id, s.itemsMetadata(context.Background(), collection)[id])
+	if coll, ok := s.collections.Get(collection); ok {
+		coll.refreshItems()
+	}
+}
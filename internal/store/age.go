@@ -0,0 +1,685 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"filippo.io/age"
+	"github.com/google/uuid"
+
+	"github.com/nikicat/gopass-secret-service/internal/secmem"
+)
+
+// AgeStore implements Store without gopass: every collection is a single
+// age-encrypted JSON file in one directory, and the alias table is another
+// one (_aliases.age). It only needs an age identity, so it also works where
+// no GPG or gopass setup exists.
+//
+// Unlike GopassStore there is no per-item file, so each operation decrypts
+// the whole collection; nothing decrypted is kept between operations.
+type AgeStore struct {
+	dir        string
+	identities []age.Identity
+	recipients []age.Recipient
+
+	mu     sync.Mutex // serializes read-modify-write cycles of the files
+	locked map[string]bool
+}
+
+const (
+	ageExt          = ".age"
+	ageAliasesName  = "_aliases"
+	ageIdentityName = "_identity.age"
+)
+
+// ageCollection is the plaintext of a collection file.
+type ageCollection struct {
+	Label    string              `json:"label"`
+	Created  time.Time           `json:"created"`
+	Modified time.Time           `json:"modified"`
+	Items    map[string]*ageItem `json:"items"`
+}
+
+type ageItem struct {
+	Label       string            `json:"label"`
+	Secret      []byte            `json:"secret"`
+	ContentType string            `json:"content_type"`
+	Attributes  map[string]string `json:"attributes"`
+	Created     time.Time         `json:"created"`
+	Modified    time.Time         `json:"modified"`
+	Expires     time.Time         `json:"expires,omitzero"`
+}
+
+// NewAgeStore returns a store in dir that encrypts to the X25519 identities
+// read from identityFile (as written by age-keygen).
+func NewAgeStore(dir, identityFile string) (*AgeStore, error) {
+	f, err := os.Open(identityFile)
+	if err != nil {
+		return nil, fmt.Errorf("age identity: %w", err)
+	}
+	defer f.Close()
+	ids, err := age.ParseIdentities(f)
+	if err != nil {
+		return nil, fmt.Errorf("age identity %s: %w", identityFile, err)
+	}
+	return newAgeStore(dir, ids)
+}
+
+// NewAgeStoreWithPassphrase returns a store in dir protected by a passphrase.
+// Running scrypt for every operation would be far too slow, so the store
+// keeps a generated X25519 identity in dir, encrypted with the passphrase,
+// and uses it for the collection files.
+func NewAgeStoreWithPassphrase(dir, passphrase string) (*AgeStore, error) {
+	if passphrase == "" {
+		return nil, errors.New("age passphrase is empty")
+	}
+	file := filepath.Join(dir, ageIdentityName)
+	data, err := os.ReadFile(file)
+	if errors.Is(err, os.ErrNotExist) {
+		return createPassphraseIdentity(dir, file, passphrase)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("age identity: %w", err)
+	}
+
+	scrypt, err := age.NewScryptIdentity(passphrase)
+	if err != nil {
+		return nil, err
+	}
+	plain, err := ageDecrypt(data, scrypt)
+	if err != nil {
+		return nil, fmt.Errorf("unlock %s: %w", file, err)
+	}
+	ids, err := age.ParseIdentities(bytes.NewReader(plain))
+	if err != nil {
+		return nil, fmt.Errorf("age identity %s: %w", file, err)
+	}
+	return newAgeStore(dir, ids)
+}
+
+func createPassphraseIdentity(dir, file, passphrase string) (*AgeStore, error) {
+	id, err := age.GenerateX25519Identity()
+	if err != nil {
+		return nil, err
+	}
+	scrypt, err := age.NewScryptRecipient(passphrase)
+	if err != nil {
+		return nil, err
+	}
+	data, err := ageEncrypt([]byte(id.String()+"\n"), scrypt)
+	if err != nil {
+		return nil, err
+	}
+	if err := writeFileAtomic(file, data); err != nil {
+		return nil, fmt.Errorf("store age identity: %w", err)
+	}
+	return newAgeStore(dir, []age.Identity{id})
+}
+
+func newAgeStore(dir string, ids []age.Identity) (*AgeStore, error) {
+	s := &AgeStore{dir: dir, identities: ids, locked: make(map[string]bool)}
+	for _, id := range ids {
+		x, ok := id.(*age.X25519Identity)
+		if !ok {
+			return nil, fmt.Errorf("unsupported age identity type %T", id)
+		}
+		s.recipients = append(s.recipients, x.Recipient())
+	}
+	if len(s.recipients) == 0 {
+		return nil, errors.New("no age identity")
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *AgeStore) file(name string) string {
+	return filepath.Join(s.dir, name+ageExt)
+}
+
+// read decrypts a collection file; it returns os.ErrNotExist for a missing
+// collection. The caller holds mu and wipes the collection when done.
+func (s *AgeStore) read(name string) (*ageCollection, error) {
+	data, err := os.ReadFile(s.file(name))
+	if err != nil {
+		return nil, err
+	}
+	plain, err := ageDecrypt(data, s.identities...)
+	if err != nil {
+		return nil, fmt.Errorf("collection %s: %w", name, err)
+	}
+	defer secmem.Wipe(plain)
+	var c ageCollection
+	if err := json.Unmarshal(plain, &c); err != nil {
+		return nil, fmt.Errorf("collection %s: %w", name, err)
+	}
+	if c.Items == nil {
+		c.Items = make(map[string]*ageItem)
+	}
+	return &c, nil
+}
+
+// write encrypts and replaces a collection file. The caller holds mu.
+func (s *AgeStore) write(name string, c *ageCollection) error {
+	plain, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+	defer secmem.Wipe(plain)
+	data, err := ageEncrypt(plain, s.recipients...)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(s.file(name), data)
+}
+
+// collection reads a collection, turning a missing file into the error
+// GopassStore returns.
+func (s *AgeStore) collection(name string) (*ageCollection, error) {
+	c, err := s.read(name)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("collection not found: %s", name)
+	}
+	return c, err
+}
+
+// validCollection rejects names that can't be a collection file, including
+// the reserved ones starting with "_".
+func validCollection(name string) error {
+	if name == "" || SanitizeName(name) != name || strings.HasPrefix(name, "_") || strings.HasPrefix(name, ".") {
+		return fmt.Errorf("invalid collection name %q", name)
+	}
+	return nil
+}
+
+// Collections returns all collection names
+func (s *AgeStore) Collections(ctx context.Context) ([]string, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, e := range entries {
+		name, ok := strings.CutSuffix(e.Name(), ageExt)
+		if !ok || !e.Type().IsRegular() || validCollection(name) != nil {
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// GetCollection returns collection data by name
+func (s *AgeStore) GetCollection(ctx context.Context, name string) (*CollectionData, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, err := s.collection(name)
+	defer c.wipe()
+	if err != nil {
+		return nil, err
+	}
+	return &CollectionData{
+		Name:     name,
+		Label:    c.Label,
+		Created:  c.Created,
+		Modified: c.Modified,
+		Locked:   s.locked[name],
+	}, nil
+}
+
+// CreateCollection creates a new collection. Creating one that exists only
+// updates its label, like in GopassStore.
+func (s *AgeStore) CreateCollection(ctx context.Context, name, label string) error {
+	name = SanitizeName(name)
+	if err := validCollection(name); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	c, err := s.read(name)
+	if errors.Is(err, os.ErrNotExist) {
+		c = &ageCollection{Created: now, Items: make(map[string]*ageItem)}
+	} else if err != nil {
+		return err
+	}
+	defer c.wipe()
+	c.Label = label
+	c.Modified = now
+	return s.write(name, c)
+}
+
+// DeleteCollection deletes a collection and all its items
+func (s *AgeStore) DeleteCollection(ctx context.Context, name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := os.Remove(s.file(name)); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("collection not found: %s", name)
+		}
+		return err
+	}
+	delete(s.locked, name)
+	return nil
+}
+
+// SetCollectionLabel updates a collection's label
+func (s *AgeStore) SetCollectionLabel(ctx context.Context, name, label string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, err := s.collection(name)
+	defer c.wipe()
+	if err != nil {
+		return err
+	}
+	c.Label = label
+	c.Modified = time.Now()
+	return s.write(name, c)
+}
+
+// RenameCollection renames the collection's file
+func (s *AgeStore) RenameCollection(ctx context.Context, from, to string) error {
+	if err := validCollection(to); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := os.Stat(s.file(to)); err == nil {
+		return fmt.Errorf("collection already exists: %s", to)
+	}
+	if err := os.Rename(s.file(from), s.file(to)); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("collection not found: %s", from)
+		}
+		return err
+	}
+	s.locked[to] = s.locked[from]
+	delete(s.locked, from)
+	return nil
+}
+
+// Items returns all item IDs in a collection
+func (s *AgeStore) Items(ctx context.Context, collection string) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, err := s.read(collection)
+	defer c.wipe()
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, 0, len(c.Items))
+	for id := range c.Items {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
+
+// GetItem returns an item by collection and ID
+func (s *AgeStore) GetItem(ctx context.Context, collection, id string) (*ItemData, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, err := s.read(collection)
+	defer c.wipe()
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	if c == nil || c.Items[id] == nil {
+		return nil, fmt.Errorf("item not found: %s/%s", collection, id)
+	}
+	return c.Items[id].data(id, true), nil
+}
+
+// CreateItem creates a new item in a collection, creating the collection if
+// needed
+func (s *AgeStore) CreateItem(ctx context.Context, collection string, item *ItemData) (string, error) {
+	if err := validCollection(collection); err != nil {
+		return "", err
+	}
+	if item.ID == "" {
+		rawID := uuid.New()
+		item.ID = fmt.Sprintf("i%x", rawID[:])
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	c, err := s.read(collection)
+	if errors.Is(err, os.ErrNotExist) {
+		c = &ageCollection{Label: collection, Created: now, Modified: now, Items: make(map[string]*ageItem)}
+	} else if err != nil {
+		return "", err
+	}
+	defer c.wipe()
+
+	if item.Created.IsZero() {
+		item.Created = now
+	}
+	if item.Modified.IsZero() {
+		item.Modified = now
+	}
+	if item.ContentType == "" {
+		item.ContentType = "text/plain"
+	}
+	if err := applyTTL(item, nil); err != nil {
+		return "", err
+	}
+	c.Items[item.ID] = newAgeItem(item)
+	if err := s.write(collection, c); err != nil {
+		return "", err
+	}
+	return item.ID, nil
+}
+
+// UpdateItem updates an existing item
+func (s *AgeStore) UpdateItem(ctx context.Context, collection, id string, item *ItemData) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, err := s.read(collection)
+	defer c.wipe()
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if c == nil || c.Items[id] == nil {
+		return fmt.Errorf("item not found: %s/%s", collection, id)
+	}
+	existing := c.Items[id]
+
+	// Preserve creation time
+	item.ID = id
+	item.Created = existing.Created
+	item.Modified = time.Now()
+	if item.ContentType == "" {
+		item.ContentType = existing.ContentType
+	}
+	if err := applyTTL(item, nil); err != nil {
+		return err
+	}
+	c.Items[id] = newAgeItem(item)
+	return s.write(collection, c)
+}
+
+// DeleteItem deletes an item
+func (s *AgeStore) DeleteItem(ctx context.Context, collection, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, err := s.read(collection)
+	defer c.wipe()
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if c == nil || c.Items[id] == nil {
+		return fmt.Errorf("item not found: %s/%s", collection, id)
+	}
+	delete(c.Items, id)
+	return s.write(collection, c)
+}
+
+// MoveItem moves an item to another collection, creating it if needed. The
+// item is written to its new collection before it's removed from the old one,
+// so a failure in between leaves a copy rather than losing it.
+func (s *AgeStore) MoveItem(ctx context.Context, from, id, to string) (string, error) {
+	if err := validCollection(to); err != nil {
+		return "", err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	src, err := s.read(from)
+	defer src.wipe()
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return "", err
+	}
+	if src == nil || src.Items[id] == nil {
+		return "", fmt.Errorf("item not found: %s/%s", from, id)
+	}
+	if from == to {
+		return id, nil
+	}
+
+	now := time.Now()
+	dst, err := s.read(to)
+	if errors.Is(err, os.ErrNotExist) {
+		dst = &ageCollection{Label: to, Created: now, Modified: now, Items: make(map[string]*ageItem)}
+	} else if err != nil {
+		return "", err
+	}
+	defer dst.wipe()
+	if dst.Items[id] != nil {
+		return "", fmt.Errorf("item already exists: %s/%s", to, id)
+	}
+	dst.Items[id] = src.Items[id]
+	if err := s.write(to, dst); err != nil {
+		return "", err
+	}
+	delete(src.Items, id)
+	return id, s.write(from, src)
+}
+
+// SearchItems searches for items matching the given attributes. Like
+// GopassStore, results carry no secret.
+func (s *AgeStore) SearchItems(ctx context.Context, collection string, attributes map[string]string) ([]*ItemData, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.search(collection, attributes)
+}
+
+func (s *AgeStore) search(collection string, attributes map[string]string) ([]*ItemData, error) {
+	c, err := s.read(collection)
+	defer c.wipe()
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, 0, len(c.Items))
+	for id := range c.Items {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	var results []*ItemData
+	for _, id := range ids {
+		if item := c.Items[id].data(id, false); matchesAttributes(item, attributes) {
+			results = append(results, item)
+		}
+	}
+	return results, nil
+}
+
+// SearchAllItems searches across all collections
+func (s *AgeStore) SearchAllItems(ctx context.Context, attributes map[string]string) (map[string][]*ItemData, error) {
+	collections, err := s.Collections(ctx)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	results := make(map[string][]*ItemData)
+	for _, coll := range collections {
+		items, err := s.search(coll, attributes)
+		if err != nil {
+			continue
+		}
+		if len(items) > 0 {
+			results[coll] = items
+		}
+	}
+	return results, nil
+}
+
+// LockCollection locks a collection
+func (s *AgeStore) LockCollection(ctx context.Context, name string) error {
+	s.mu.Lock()
+	s.locked[name] = true
+	s.mu.Unlock()
+	return nil
+}
+
+// UnlockCollection unlocks a collection
+func (s *AgeStore) UnlockCollection(ctx context.Context, name string) error {
+	s.mu.Lock()
+	s.locked[name] = false
+	s.mu.Unlock()
+	return nil
+}
+
+// aliases reads the alias table; a missing table is empty. The caller holds
+// mu.
+func (s *AgeStore) aliases() (map[string]string, error) {
+	data, err := os.ReadFile(s.file(ageAliasesName))
+	if errors.Is(err, os.ErrNotExist) {
+		return make(map[string]string), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	plain, err := ageDecrypt(data, s.identities...)
+	if err != nil {
+		return nil, fmt.Errorf("aliases: %w", err)
+	}
+	aliases := make(map[string]string)
+	if err := json.Unmarshal(plain, &aliases); err != nil {
+		return nil, fmt.Errorf("aliases: %w", err)
+	}
+	return aliases, nil
+}
+
+// GetAlias returns the collection name for an alias
+func (s *AgeStore) GetAlias(ctx context.Context, alias string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	aliases, err := s.aliases()
+	if err != nil {
+		return "", err
+	}
+	if result := aliases[alias]; result != "" {
+		return result, nil
+	}
+	// Handle default alias specially
+	if alias == "default" {
+		return "default", nil
+	}
+	return "", fmt.Errorf("alias not found: %s", alias)
+}
+
+// Aliases implements AliasLister.
+func (s *AgeStore) Aliases(ctx context.Context) (map[string]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.aliases()
+}
+
+// SetAlias sets an alias for a collection; an empty collection removes it
+func (s *AgeStore) SetAlias(ctx context.Context, alias, collection string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	aliases, err := s.aliases()
+	if err != nil {
+		return err
+	}
+	if collection == "" {
+		delete(aliases, alias)
+	} else {
+		aliases[alias] = collection
+	}
+	plain, err := json.Marshal(aliases)
+	if err != nil {
+		return err
+	}
+	data, err := ageEncrypt(plain, s.recipients...)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(s.file(ageAliasesName), data)
+}
+
+// Close closes the store. Nothing is held open between operations.
+func (s *AgeStore) Close(ctx context.Context) error {
+	return nil
+}
+
+// wipe zeroes the secrets of a collection read from disk. c may be nil.
+func (c *ageCollection) wipe() {
+	if c == nil {
+		return
+	}
+	for _, it := range c.Items {
+		secmem.Wipe(it.Secret)
+	}
+}
+
+func newAgeItem(item *ItemData) *ageItem {
+	attrs := make(map[string]string, len(item.Attributes))
+	for k, v := range item.Attributes {
+		attrs[k] = v
+	}
+	return &ageItem{
+		Label:       item.Label,
+		Secret:      append([]byte(nil), item.Secret...),
+		ContentType: item.ContentType,
+		Attributes:  attrs,
+		Created:     item.Created,
+		Modified:    item.Modified,
+		Expires:     item.Expires,
+	}
+}
+
+func (it *ageItem) data(id string, withSecret bool) *ItemData {
+	item := &ItemData{
+		ID:          id,
+		Label:       it.Label,
+		ContentType: it.ContentType,
+		Attributes:  make(map[string]string, len(it.Attributes)),
+		Created:     it.Created,
+		Modified:    it.Modified,
+		Expires:     it.Expires,
+	}
+	for k, v := range it.Attributes {
+		item.Attributes[k] = v
+	}
+	if withSecret {
+		item.Secret = append([]byte(nil), it.Secret...)
+	}
+	return item
+}
+
+func ageEncrypt(plain []byte, recipients ...age.Recipient) ([]byte, error) {
+	var buf bytes.Buffer
+	w, err := age.Encrypt(&buf, recipients...)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(plain); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func ageDecrypt(data []byte, identities ...age.Identity) ([]byte, error) {
+	r, err := age.Decrypt(bytes.NewReader(data), identities...)
+	if err != nil {
+		return nil, err
+	}
+	return io.ReadAll(r)
+}
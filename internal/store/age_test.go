@@ -0,0 +1,107 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"filippo.io/age"
+)
+
+func newTestAgeStore(t *testing.T) (*AgeStore, string) {
+	t.Helper()
+	dir := t.TempDir()
+	id, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyFile := filepath.Join(t.TempDir(), "key.txt")
+	if err := os.WriteFile(keyFile, []byte("# test key\n"+id.String()+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	s, err := NewAgeStore(dir, keyFile)
+	if err != nil {
+		t.Fatalf("NewAgeStore: %v", err)
+	}
+	return s, keyFile
+}
+
+func TestAgeStore_Suite(t *testing.T) {
+	testDurableStore(t, func(t *testing.T) Store {
+		s, _ := newTestAgeStore(t)
+		return s
+	})
+}
+
+func TestAgeStore_FilesAreEncryptedAndPersist(t *testing.T) {
+	ctx := context.Background()
+	s, keyFile := newTestAgeStore(t)
+	id, err := s.CreateItem(ctx, "default", &ItemData{
+		Label:      "visible-label",
+		Secret:     []byte("plaintext-secret"),
+		Attributes: map[string]string{"service": "visible-attribute"},
+	})
+	if err != nil {
+		t.Fatalf("CreateItem: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(s.dir, "default.age"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, leak := range []string{"plaintext-secret", "visible-label", "visible-attribute"} {
+		if bytes.Contains(data, []byte(leak)) {
+			t.Errorf("collection file contains %q in the clear", leak)
+		}
+	}
+
+	reopened, err := NewAgeStore(s.dir, keyFile)
+	if err != nil {
+		t.Fatalf("NewAgeStore: %v", err)
+	}
+	item, err := reopened.GetItem(ctx, "default", id)
+	if err != nil || string(item.Secret) != "plaintext-secret" {
+		t.Errorf("after reopening: %v, %v", item, err)
+	}
+}
+
+func TestAgeStore_Passphrase(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	s, err := NewAgeStoreWithPassphrase(dir, "correct horse")
+	if err != nil {
+		t.Fatalf("NewAgeStoreWithPassphrase: %v", err)
+	}
+	id, err := s.CreateItem(ctx, "default", &ItemData{Secret: []byte("pw")})
+	if err != nil {
+		t.Fatalf("CreateItem: %v", err)
+	}
+
+	// The generated identity isn't a collection.
+	if names, _ := s.Collections(ctx); len(names) != 1 || names[0] != "default" {
+		t.Errorf("Collections = %v, want [default]", names)
+	}
+
+	if _, err := NewAgeStoreWithPassphrase(dir, "wrong"); err == nil {
+		t.Error("wrong passphrase unlocked the store")
+	}
+	reopened, err := NewAgeStoreWithPassphrase(dir, "correct horse")
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	if item, err := reopened.GetItem(ctx, "default", id); err != nil || string(item.Secret) != "pw" {
+		t.Errorf("after reopening: %v, %v", item, err)
+	}
+}
+
+func TestAgeStore_RejectsReservedCollectionNames(t *testing.T) {
+	ctx := context.Background()
+	s, _ := newTestAgeStore(t)
+	for _, name := range []string{"_aliases", ".hidden", ""} {
+		if _, err := s.CreateItem(ctx, name, &ItemData{Secret: []byte("x")}); err == nil {
+			t.Errorf("CreateItem in %q succeeded", name)
+		}
+	}
+}
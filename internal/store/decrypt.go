@@ -0,0 +1,169 @@
+package store
+
+import (
+	"context"
+	"sync"
+
+	"github.com/gopasspw/gopass/pkg/gopass"
+)
+
+// DecryptPool bounds how many gopass entries are decrypted at once. Every
+// GopassStore on one gopass backend shares a pool: they all end up at the same
+// gpg-agent, and a smartcard behind it handles one operation at a time.
+type DecryptPool struct {
+	sem chan struct{}
+}
+
+// NewDecryptPool returns a pool allowing workers concurrent decryptions (at
+// least one).
+func NewDecryptPool(workers int) *DecryptPool {
+	return &DecryptPool{sem: make(chan struct{}, max(workers, 1))}
+}
+
+// Workers returns how many decryptions may run at once.
+func (p *DecryptPool) Workers() int {
+	if p == nil {
+		return 1
+	}
+	return cap(p.sem)
+}
+
+// acquire waits for a free slot and returns the function releasing it. A nil
+// pool doesn't limit anything.
+func (p *DecryptPool) acquire(ctx context.Context) (func(), error) {
+	if p == nil {
+		return func() {}, nil
+	}
+	select {
+	case p.sem <- struct{}{}:
+		return func() { <-p.sem }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Each calls fn for 0 <= i < n from up to Workers goroutines and returns when
+// all calls have. Indices not yet started when ctx is cancelled are skipped.
+// fn is expected to acquire the pool itself for the decryption it does, so
+// the limit holds across concurrent callers.
+func (p *DecryptPool) Each(ctx context.Context, n int, fn func(i int)) {
+	workers := min(p.Workers(), n)
+	if workers <= 1 {
+		for i := range n {
+			if ctx.Err() != nil {
+				return
+			}
+			fn(i)
+		}
+		return
+	}
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Go(func() {
+			for i := range next {
+				fn(i)
+			}
+		})
+	}
+	for i := range n {
+		if ctx.Err() != nil {
+			break
+		}
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+}
+
+// SetDecryptPool makes the store decrypt through pool. Without one, entries
+// are decrypted one at a time.
+func (s *GopassStore) SetDecryptPool(pool *DecryptPool) {
+	s.pool = pool
+}
+
+// decrypt reads the entry at path through the decryption pool.
+func (s *GopassStore) decrypt(ctx context.Context, path string) (gopass.Secret, error) {
+	release, err := s.pool.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return s.store.Get(ctx, path, "latest")
+}
+
+// warmMeta fills the metadata cache for paths, decrypting them in parallel,
+// and returns the paths that couldn't be read. progress, if set, is called
+// after each path, from any of the workers.
+func (s *GopassStore) warmMeta(ctx context.Context, paths []string, progress func()) map[string]bool {
+	var mu sync.Mutex
+	failed := make(map[string]bool)
+	s.pool.Each(ctx, len(paths), func(i int) {
+		if _, err := s.metaFor(ctx, paths[i]); err != nil {
+			mu.Lock()
+			failed[paths[i]] = true
+			mu.Unlock()
+		}
+		if progress != nil {
+			progress()
+		}
+	})
+	return failed
+}
+
+// Warmer is implemented by stores that can fill their caches ahead of the
+// first request.
+type Warmer interface {
+	// Warm reads what the first searches would otherwise have to,
+	// reporting progress as entries are done out of total. It stops early
+	// when ctx is cancelled.
+	Warm(ctx context.Context, progress func(done, total int)) error
+}
+
+// Warm implements Warmer by decrypting every item whose metadata isn't
+// cached yet, e.g. because the attribute index is off or out of date.
+func (s *GopassStore) Warm(ctx context.Context, progress func(done, total int)) error {
+	if err := s.ensureListing(ctx); err != nil {
+		return err
+	}
+	var cold []string
+	for _, name := range s.listedFirstSegments() {
+		for _, p := range s.coldUnder(name) {
+			if s.isItemPath(p) {
+				cold = append(cold, p)
+			}
+		}
+	}
+	if len(cold) == 0 {
+		return nil
+	}
+
+	var mu sync.Mutex
+	done := 0
+	s.warmMeta(ctx, cold, func() {
+		mu.Lock()
+		defer mu.Unlock()
+		done++
+		if progress != nil {
+			progress(done, len(cold))
+		}
+	})
+	s.saveIndexQuietly()
+	return ctx.Err()
+}
+
+// Warm implements Warmer by warming every underlying store that supports it,
+// one after another: stores on one gopass backend share its decryption pool
+// anyway.
+func (m *MultiStore) Warm(ctx context.Context, progress func(done, total int)) error {
+	for _, s := range m.stores() {
+		w, ok := s.(Warmer)
+		if !ok {
+			continue
+		}
+		if err := w.Warm(ctx, progress); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestDecryptPool_BoundsConcurrency(t *testing.T) {
+	ctx := context.Background()
+	pool := NewDecryptPool(2)
+
+	var mu sync.Mutex
+	running, peak, calls := 0, 0, 0
+	pool.Each(ctx, 10, func(int) {
+		release, err := pool.acquire(ctx)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer release()
+		mu.Lock()
+		running++
+		peak = max(peak, running)
+		calls++
+		mu.Unlock()
+		time.Sleep(5 * time.Millisecond)
+		mu.Lock()
+		running--
+		mu.Unlock()
+	})
+	if calls != 10 {
+		t.Errorf("calls = %d, want 10", calls)
+	}
+	if peak > 2 {
+		t.Errorf("%d decryptions ran at once, want at most 2", peak)
+	}
+
+	if got := NewDecryptPool(0).Workers(); got != 1 {
+		t.Errorf("NewDecryptPool(0).Workers() = %d, want 1", got)
+	}
+}
+
+func TestGopassStore_WarmFillsCache(t *testing.T) {
+	ctx := context.Background()
+	fake := newFakeGopassStore()
+	s := newTestGopassStore(fake)
+	s.SetDecryptPool(NewDecryptPool(3))
+	const n = 8
+	for i := range n {
+		fake.putSecret(s.mapper.ItemPath("default", fmt.Sprintf("item_%d", i)), "pw", map[string]string{"n": fmt.Sprint(i)})
+	}
+
+	var last, lastTotal int
+	if err := s.Warm(ctx, func(done, total int) { last, lastTotal = done, total }); err != nil {
+		t.Fatalf("Warm: %v", err)
+	}
+	if last != n || lastTotal != n {
+		t.Errorf("last progress = %d/%d, want %d/%d", last, lastTotal, n, n)
+	}
+
+	res, err := s.SearchItems(ctx, "default", map[string]string{"n": "3"})
+	if err != nil || len(res) != 1 || res[0].ID != "item_3" {
+		t.Fatalf("SearchItems = %v, %v; want item_3", res, err)
+	}
+	for p, c := range fake.getCount {
+		if c != 1 {
+			t.Errorf("%s decrypted %d times, want once", p, c)
+		}
+	}
+
+	// Nothing left to warm.
+	called := false
+	if err := s.Warm(ctx, func(int, int) { called = true }); err != nil || called {
+		t.Errorf("second Warm reported progress (err %v)", err)
+	}
+}
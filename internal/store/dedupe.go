@@ -0,0 +1,189 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/nikicat/gopass-secret-service/internal/secmem"
+)
+
+// DuplicateGroup is a set of items in one collection with exactly the same
+// attributes. Clients can't tell them apart by lookup, so all but one are
+// redundant.
+type DuplicateGroup struct {
+	Collection string
+	Attributes map[string]string
+	// Items are the group's items as a search returns them, newest first.
+	Items []*ItemData
+	// ValuesDiffer is set when the items don't all hold the same secret.
+	ValuesDiffer bool
+}
+
+// FindDuplicates groups the items of collection, or of every collection if
+// it is empty, by their exact attribute set and returns the groups with more
+// than one item. Items without attributes are never duplicates.
+func FindDuplicates(ctx context.Context, s Store, collection string) ([]DuplicateGroup, error) {
+	var results map[string][]*ItemData
+	if collection == "" {
+		var err error
+		if results, err = s.SearchAllItems(ctx, nil); err != nil {
+			return nil, err
+		}
+	} else {
+		items, err := s.SearchItems(ctx, collection, nil)
+		if err != nil {
+			return nil, err
+		}
+		results = map[string][]*ItemData{collection: items}
+	}
+
+	var groups []DuplicateGroup
+	for coll, items := range results {
+		byAttrs := make(map[string][]*ItemData)
+		for _, item := range items {
+			if len(item.Attributes) == 0 {
+				continue
+			}
+			key := attributeKey(item.Attributes)
+			byAttrs[key] = append(byAttrs[key], item)
+		}
+		for _, group := range byAttrs {
+			if len(group) < 2 {
+				continue
+			}
+			sortNewestFirst(group)
+			differ, err := valuesDiffer(ctx, s, coll, group)
+			if err != nil {
+				return nil, err
+			}
+			groups = append(groups, DuplicateGroup{
+				Collection:   coll,
+				Attributes:   group[0].Attributes,
+				Items:        group,
+				ValuesDiffer: differ,
+			})
+		}
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		if groups[i].Collection != groups[j].Collection {
+			return groups[i].Collection < groups[j].Collection
+		}
+		return attributeKey(groups[i].Attributes) < attributeKey(groups[j].Attributes)
+	})
+	return groups, nil
+}
+
+// RemoveDuplicates deletes every other item of collection with exactly the
+// attributes of the item keep, and returns the IDs it deleted.
+func RemoveDuplicates(ctx context.Context, s Store, collection, keep string) ([]string, error) {
+	// Only the attributes are needed, so they come from a search, which
+	// doesn't decrypt the item.
+	all, err := s.SearchItems(ctx, collection, nil)
+	if err != nil {
+		return nil, err
+	}
+	i := slices.IndexFunc(all, func(item *ItemData) bool { return item.ID == keep })
+	if i < 0 {
+		return nil, fmt.Errorf("item not found: %s/%s", collection, keep)
+	}
+	kept := all[i]
+	if len(kept.Attributes) == 0 {
+		return nil, fmt.Errorf("item %s/%s has no attributes", collection, keep)
+	}
+	matches, err := s.SearchItems(ctx, collection, kept.Attributes)
+	if err != nil {
+		return nil, err
+	}
+	var removed []string
+	for _, item := range matches {
+		if item.ID == keep || !maps.Equal(item.Attributes, kept.Attributes) {
+			continue
+		}
+		if err := s.DeleteItem(ctx, collection, item.ID); err != nil {
+			return removed, fmt.Errorf("delete %s/%s: %w", collection, item.ID, err)
+		}
+		removed = append(removed, item.ID)
+	}
+	return removed, nil
+}
+
+// CreateItemOnce creates item in collection unless FindItem finds it there
+// already, and reports whether it created it. Copies between stores use it so
+// they can be run again.
+func CreateItemOnce(ctx context.Context, s Store, collection string, item *ItemData) (bool, error) {
+	existing, err := FindItem(ctx, s, collection, item)
+	if err != nil || existing != nil {
+		return false, err
+	}
+	if _, err := s.CreateItem(ctx, collection, item); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// FindItem returns the item of collection that item would duplicate, or nil
+// if there is none. Items are the same if they have exactly the same
+// attributes; items without attributes can only be told apart by their
+// labels, so those must match too. Imports and restores all use this rule.
+func FindItem(ctx context.Context, s Store, collection string, item *ItemData) (*ItemData, error) {
+	matches, err := s.SearchItems(ctx, collection, item.Attributes)
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range matches {
+		if maps.Equal(m.Attributes, item.Attributes) && (len(item.Attributes) > 0 || m.Label == item.Label) {
+			return m, nil
+		}
+	}
+	return nil, nil
+}
+
+// attributeKey is a canonical form of an attribute set, for grouping.
+func attributeKey(attrs map[string]string) string {
+	var b strings.Builder
+	for _, k := range slices.Sorted(maps.Keys(attrs)) {
+		fmt.Fprintf(&b, "%q=%q\n", k, attrs[k])
+	}
+	return b.String()
+}
+
+func sortNewestFirst(items []*ItemData) {
+	sort.Slice(items, func(i, j int) bool {
+		a, b := items[i], items[j]
+		if !a.Modified.Equal(b.Modified) {
+			return a.Modified.After(b.Modified)
+		}
+		if !a.Created.Equal(b.Created) {
+			return a.Created.After(b.Created)
+		}
+		return a.ID < b.ID
+	})
+}
+
+// valuesDiffer reads the secrets of items, which searches don't return, and
+// reports whether they aren't all the same.
+func valuesDiffer(ctx context.Context, s Store, collection string, items []*ItemData) (bool, error) {
+	var first []byte
+	for i, item := range items {
+		full, err := s.GetItem(ctx, collection, item.ID)
+		if err != nil {
+			return false, fmt.Errorf("read %s/%s: %w", collection, item.ID, err)
+		}
+		if i == 0 {
+			first = full.Secret
+			defer secmem.Wipe(first)
+			continue
+		}
+		differ := !bytes.Equal(full.Secret, first)
+		secmem.Wipe(full.Secret)
+		if differ {
+			return true, nil
+		}
+	}
+	return false, nil
+}
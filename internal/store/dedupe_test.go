@@ -0,0 +1,131 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestFindAndRemoveDuplicates(t *testing.T) {
+	ctx := context.Background()
+	s := newTestGopassStore(newFakeGopassStore())
+	create := func(coll, secret string, attrs map[string]string) string {
+		t.Helper()
+		id, err := s.CreateItem(ctx, coll, &ItemData{Label: secret, Secret: []byte(secret), Attributes: attrs})
+		if err != nil {
+			t.Fatalf("CreateItem: %v", err)
+		}
+		return id
+	}
+	github := map[string]string{"service": "github", "user": "me"}
+	a := create("default", "one", github)
+	b := create("default", "two", github)
+	create("default", "one", map[string]string{"service": "github", "user": "me", "host": "x"})
+	create("default", "empty", nil)
+	create("default", "empty", nil)
+	create("work", "same", github)
+	create("work", "same", github)
+
+	groups, err := FindDuplicates(ctx, s, "")
+	if err != nil {
+		t.Fatalf("FindDuplicates: %v", err)
+	}
+	if len(groups) != 2 {
+		t.Fatalf("FindDuplicates = %d groups, want 2: %+v", len(groups), groups)
+	}
+	if g := groups[0]; g.Collection != "default" || len(g.Items) != 2 || !g.ValuesDiffer {
+		t.Errorf("default group = %+v", g)
+	}
+	if g := groups[1]; g.Collection != "work" || len(g.Items) != 2 || g.ValuesDiffer {
+		t.Errorf("work group = %+v", g)
+	}
+	if groups, _ := FindDuplicates(ctx, s, "work"); len(groups) != 1 {
+		t.Errorf("FindDuplicates(work) = %+v, want one group", groups)
+	}
+
+	removed, err := RemoveDuplicates(ctx, s, "default", a)
+	if err != nil {
+		t.Fatalf("RemoveDuplicates: %v", err)
+	}
+	if len(removed) != 1 || removed[0] != b {
+		t.Errorf("removed %v, want [%s]", removed, b)
+	}
+	if ids, _ := s.Items(ctx, "default"); len(ids) != 4 {
+		t.Errorf("default has %d items left, want 4", len(ids))
+	}
+	if groups, _ := FindDuplicates(ctx, s, "default"); len(groups) != 0 {
+		t.Errorf("duplicates left after RemoveDuplicates: %+v", groups)
+	}
+}
+
+func TestSortNewestFirst(t *testing.T) {
+	now := time.Now()
+	items := []*ItemData{
+		{ID: "old", Created: now, Modified: now.Add(-time.Hour)},
+		{ID: "b", Created: now, Modified: now},
+		{ID: "new", Created: now, Modified: now.Add(time.Hour)},
+		{ID: "a", Created: now, Modified: now},
+		{ID: "recreated", Created: now.Add(time.Minute), Modified: now},
+	}
+	sortNewestFirst(items)
+	var got []string
+	for _, item := range items {
+		got = append(got, item.ID)
+	}
+	want := []string{"new", "recreated", "a", "b", "old"}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("order = %v, want %v", got, want)
+		}
+	}
+}
+
+func TestCreateItemOnce(t *testing.T) {
+	ctx := context.Background()
+	s := newTestGopassStore(newFakeGopassStore())
+	attrs := map[string]string{"service": "github", "user": "me"}
+	created := time.Unix(1600000000, 0)
+
+	ok, err := CreateItemOnce(ctx, s, "default", &ItemData{Label: "GitHub", Secret: []byte("one"), Attributes: attrs, Created: created})
+	if err != nil || !ok {
+		t.Fatalf("first CreateItemOnce = %v, %v", ok, err)
+	}
+	ok, err = CreateItemOnce(ctx, s, "default", &ItemData{Label: "Other", Secret: []byte("two"), Attributes: attrs})
+	if err != nil || ok {
+		t.Errorf("CreateItemOnce with the same attributes = %v, %v; want skipped", ok, err)
+	}
+	ok, err = CreateItemOnce(ctx, s, "default", &ItemData{Label: "GitHub", Secret: []byte("one"),
+		Attributes: map[string]string{"service": "github", "user": "me", "host": "x"}})
+	if err != nil || !ok {
+		t.Errorf("CreateItemOnce with more attributes = %v, %v; want created", ok, err)
+	}
+
+	items, err := s.SearchItems(ctx, "default", attrs)
+	if err != nil || len(items) != 2 {
+		t.Fatalf("SearchItems = %v, %v", items, err)
+	}
+	for _, item := range items {
+		if len(item.Attributes) == 2 && !item.Created.Equal(created) {
+			t.Errorf("created = %v, want %v", item.Created, created)
+		}
+	}
+}
+
+func TestCreateItemOnceWithoutAttributes(t *testing.T) {
+	ctx := context.Background()
+	s := newTestGopassStore(newFakeGopassStore())
+
+	for _, tc := range []struct {
+		label string
+		want  bool
+	}{
+		{"Wi-Fi", true},
+		{"Router", true},
+		{"Wi-Fi", false},
+	} {
+		ok, err := CreateItemOnce(ctx, s, "default", &ItemData{Label: tc.label, Secret: []byte("pw")})
+		if err != nil || ok != tc.want {
+			t.Errorf("CreateItemOnce(%q) = %v, %v; want %v", tc.label, ok, err, tc.want)
+		}
+	}
+}
@@ -0,0 +1,206 @@
+package store
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"mime"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gopasspw/gopass/pkg/gopass"
+	"github.com/gopasspw/gopass/pkg/gopass/secrets"
+)
+
+// Item entries are gopass AKV secrets: the first line is the gopass
+// "password", followed by "key: value" metadata lines. That layout only holds
+// a single line of text, so the secret value is written in one of these
+// encodings, recorded under encodingKey:
+//
+//   - encodingText: UTF-8 text. The first line is the password, and any
+//     further lines follow the metadata verbatim, so `gopass show` still
+//     displays the secret readably. bodyLinesKey records how many trailing
+//     lines belong to the secret, since gopass would otherwise read a body
+//     line like "type: service_account" as metadata.
+//   - encodingBase64: arbitrary bytes, base64-encoded in the body with an
+//     empty password line.
+//
+// Entries without encodingKey predate the encoding and hold the secret in the
+// password line only; they are read exactly as before.
+const (
+	encodingKey    = "_ss_encoding"
+	bodyLinesKey   = "_ss_body_lines"
+	encodingText   = "text/v1"
+	encodingBase64 = "base64/v1"
+
+	// base64LineLen wraps base64 bodies like PEM so entries stay diffable.
+	base64LineLen = 64
+)
+
+// isTextContentType reports whether a secret of this content type is meant to
+// be human readable and may be stored as text.
+func isTextContentType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	if strings.HasPrefix(mediaType, "text/") {
+		return true
+	}
+	switch mediaType {
+	case "application/json", "application/xml", "application/x-pem-file", "application/yaml":
+		return true
+	}
+	return strings.HasSuffix(mediaType, "+json") || strings.HasSuffix(mediaType, "+xml")
+}
+
+// storableAsText reports whether secret survives the text encoding byte for
+// byte. gopass splits entries into lines and drops carriage returns, so
+// those (and anything that isn't valid UTF-8) force base64.
+func storableAsText(secret []byte) bool {
+	return utf8.Valid(secret) && !bytes.ContainsAny(secret, "\r\x00")
+}
+
+// encodeEntry builds the gopass entry for an item: the encoded secret, the
+// _ss_ metadata and the user attributes (sorted for consistency).
+func encodeEntry(item *ItemData) (gopass.Secret, error) {
+	sec := secrets.NewAKV()
+
+	encoding := encodingBase64
+	if isTextContentType(item.ContentType) && storableAsText(item.Secret) {
+		encoding = encodingText
+	}
+
+	var body string
+	meta := []struct{ k, v string }{
+		{labelKey, item.Label},
+		{createdKey, item.Created.Format(time.RFC3339)},
+		{modifiedKey, item.Modified.Format(time.RFC3339)},
+		{contentTypeKey, item.ContentType},
+		{encodingKey, encoding},
+	}
+	if !item.Expires.IsZero() {
+		meta = append(meta, struct{ k, v string }{expiresKey, item.Expires.Format(time.RFC3339)})
+	}
+	switch encoding {
+	case encodingText:
+		// gopass takes strings, so these copies can't be wiped.
+		lines := strings.Split(string(item.Secret), "\n")
+		sec.SetPassword(lines[0])
+		if rest := lines[1:]; len(rest) > 0 {
+			meta = append(meta, struct{ k, v string }{bodyLinesKey, strconv.Itoa(len(rest))})
+			body = strings.Join(rest, "\n") + "\n"
+		}
+	case encodingBase64:
+		sec.SetPassword("")
+		encoded := base64.StdEncoding.EncodeToString(item.Secret)
+		var b strings.Builder
+		for len(encoded) > 0 {
+			n := min(base64LineLen, len(encoded))
+			b.WriteString(encoded[:n])
+			b.WriteString("\n")
+			encoded = encoded[n:]
+		}
+		body = b.String()
+	}
+
+	for _, kv := range meta {
+		if err := sec.Set(kv.k, kv.v); err != nil {
+			return nil, fmt.Errorf("set %s: %w", kv.k, err)
+		}
+	}
+
+	keys := make([]string, 0, len(item.Attributes))
+	for k := range item.Attributes {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if err := sec.Set(k, item.Attributes[k]); err != nil {
+			return nil, fmt.Errorf("set attr %s: %w", k, err)
+		}
+	}
+
+	// The body goes last so the metadata block above stays contiguous.
+	if _, err := sec.Write([]byte(body)); err != nil {
+		return nil, fmt.Errorf("write body: %w", err)
+	}
+	return sec, nil
+}
+
+// decodeEntry returns the secret value of an item entry.
+func decodeEntry(sec gopass.Secret) ([]byte, error) {
+	encoding, ok := sec.Get(encodingKey)
+	if !ok {
+		return []byte(sec.Password()), nil
+	}
+
+	switch encoding {
+	case encodingText:
+		body, err := textBodyLines(sec)
+		if err != nil {
+			return nil, err
+		}
+		if len(body) == 0 {
+			return []byte(sec.Password()), nil
+		}
+		return []byte(sec.Password() + "\n" + strings.Join(body, "\n")), nil
+	case encodingBase64:
+		encoded := strings.Join(strings.Fields(sec.Body()), "")
+		secret, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("decode base64 secret: %w", err)
+		}
+		return secret, nil
+	default:
+		return nil, fmt.Errorf("unsupported secret encoding %q", encoding)
+	}
+}
+
+// textBodyLines returns the trailing lines of a text-encoded entry that belong
+// to the secret, and nil for single-line secrets.
+func textBodyLines(sec gopass.Secret) ([]string, error) {
+	v, ok := sec.Get(bodyLinesKey)
+	if !ok {
+		return nil, nil
+	}
+	n, err := strconv.Atoi(v)
+	lines := rawLines(sec)
+	if err != nil || n < 0 || n > len(lines)-1 {
+		return nil, fmt.Errorf("corrupt entry: %s %q for %d lines", bodyLinesKey, v, len(lines))
+	}
+	return lines[len(lines)-n:], nil
+}
+
+// rawLines splits an entry's raw content into lines. gopass terminates every
+// line, including the last, with a newline.
+func rawLines(sec gopass.Secret) []string {
+	return strings.Split(strings.TrimSuffix(string(sec.Bytes()), "\n"), "\n")
+}
+
+// metaFromSecret extracts a decrypted entry's metadata key/value pairs. By
+// construction it copies only gopass Keys() — never Password() or Body() — so
+// the result is safe to cache without retaining the secret value. For text
+// entries with a multi-line secret only the header is parsed, so a secret
+// line that happens to look like "key: value" never leaks into the metadata.
+func metaFromSecret(sec gopass.Secret) map[string]string {
+	if enc, _ := sec.Get(encodingKey); enc == encodingText {
+		if body, err := textBodyLines(sec); err == nil && len(body) > 0 {
+			lines := rawLines(sec)
+			header := strings.Join(lines[:len(lines)-len(body)], "\n") + "\n"
+			sec = secrets.ParseAKV([]byte(header))
+		}
+	}
+
+	keys := sec.Keys()
+	m := make(map[string]string, len(keys))
+	for _, k := range keys {
+		if v, ok := sec.Get(k); ok {
+			m[k] = v
+		}
+	}
+	return m
+}
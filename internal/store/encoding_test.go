@@ -0,0 +1,150 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gopasspw/gopass/pkg/gopass"
+	"github.com/gopasspw/gopass/pkg/gopass/secrets"
+)
+
+// reparse round-trips an entry through its serialized form, the way gopass
+// writes it to disk and parses it back on the next Get.
+func reparse(sec gopass.Secret) gopass.Secret {
+	return secrets.ParseAKV(sec.Bytes())
+}
+
+func TestEncodeEntry_RoundTrip(t *testing.T) {
+	binary := make([]byte, 300)
+	for i := range binary {
+		binary[i] = byte(i)
+	}
+
+	tests := []struct {
+		name        string
+		contentType string
+		secret      []byte
+		encoding    string
+	}{
+		{"single line", "text/plain", []byte("hunter2"), encodingText},
+		{"empty", "text/plain", []byte(""), encodingText},
+		{"multi-line PEM", "text/plain", []byte("-----BEGIN KEY-----\nMIIB\n-----END KEY-----\n"), encodingText},
+		{"json with kv-looking lines", "application/json", []byte("{\n  \"type\": \"service_account\",\n  \"_ss_label\": \"x\"\n}"), encodingText},
+		{"trailing blank lines", "text/plain; charset=utf-8", []byte("a\n\n\n"), encodingText},
+		{"carriage returns", "text/plain", []byte("a\r\nb\r\n"), encodingBase64},
+		{"invalid utf-8 text", "text/plain", []byte{0xff, 0xfe, 'a'}, encodingBase64},
+		{"binary", "application/octet-stream", binary, encodingBase64},
+		{"binary empty", "application/octet-stream", []byte{}, encodingBase64},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			item := &ItemData{
+				Label:       "label",
+				Secret:      tt.secret,
+				ContentType: tt.contentType,
+				Attributes:  map[string]string{"service": "svc", "username": "u"},
+				Created:     time.Now(),
+				Modified:    time.Now(),
+			}
+			sec, err := encodeEntry(item)
+			if err != nil {
+				t.Fatalf("encodeEntry: %v", err)
+			}
+			sec = reparse(sec)
+
+			if enc, _ := sec.Get(encodingKey); enc != tt.encoding {
+				t.Errorf("encoding = %q, want %q", enc, tt.encoding)
+			}
+			got, err := decodeEntry(sec)
+			if err != nil {
+				t.Fatalf("decodeEntry: %v", err)
+			}
+			if !bytes.Equal(got, tt.secret) {
+				t.Errorf("secret = %q, want %q", got, tt.secret)
+			}
+
+			meta := metaFromSecret(sec)
+			want := map[string]string{"service": "svc", "username": "u", labelKey: "label"}
+			for k, v := range want {
+				if meta[k] != v {
+					t.Errorf("meta[%q] = %q, want %q", k, meta[k], v)
+				}
+			}
+			for k := range meta {
+				if strings.Contains(k, "\"") {
+					t.Errorf("secret line leaked into metadata as key %q", k)
+				}
+			}
+		})
+	}
+}
+
+func TestEncodeEntry_TextStaysReadable(t *testing.T) {
+	item := &ItemData{
+		Secret:      []byte("line one\nline two"),
+		ContentType: "text/plain",
+		Attributes:  map[string]string{},
+	}
+	sec, err := encodeEntry(item)
+	if err != nil {
+		t.Fatalf("encodeEntry: %v", err)
+	}
+	raw := string(sec.Bytes())
+	if !strings.HasPrefix(raw, "line one\n") || !strings.HasSuffix(raw, "\nline two\n") {
+		t.Errorf("text secret not stored verbatim:\n%s", raw)
+	}
+}
+
+func TestDecodeEntry_LegacyEntry(t *testing.T) {
+	// Entries written before the encoding existed have no _ss_encoding key and
+	// only the first line is the secret.
+	sec := secrets.ParseAKV([]byte("old-password\n_ss_label: Old\nservice: legacy\nsome trailing note\n"))
+	got, err := decodeEntry(sec)
+	if err != nil {
+		t.Fatalf("decodeEntry: %v", err)
+	}
+	if string(got) != "old-password" {
+		t.Errorf("legacy secret = %q, want old-password", got)
+	}
+}
+
+func TestDecodeEntry_RejectsUnknownEncoding(t *testing.T) {
+	sec := secrets.ParseAKV([]byte("x\n_ss_encoding: rot13/v9\n"))
+	if _, err := decodeEntry(sec); err == nil {
+		t.Errorf("decodeEntry with unknown encoding should fail")
+	}
+}
+
+func TestGopassStore_MultiLineSecretRoundTrip(t *testing.T) {
+	inner := newFakeGopassStore()
+	s := newTestGopassStore(inner)
+	ctx := context.Background()
+
+	secret := []byte("{\n  \"client_email\": \"bot@example.com\"\n}\n")
+	id, err := s.CreateItem(ctx, "default", &ItemData{
+		Label:       "sa",
+		Secret:      secret,
+		ContentType: "application/json",
+		Attributes:  map[string]string{"service": "gcp"},
+	})
+	if err != nil {
+		t.Fatalf("CreateItem: %v", err)
+	}
+	// Simulate the entry being written to disk and read back.
+	path := s.mapper.ItemPath("default", id)
+	inner.data[path] = reparse(inner.data[path])
+
+	got, err := s.GetItem(ctx, "default", id)
+	if err != nil {
+		t.Fatalf("GetItem: %v", err)
+	}
+	if !bytes.Equal(got.Secret, secret) {
+		t.Errorf("secret = %q, want %q", got.Secret, secret)
+	}
+	if len(got.Attributes) != 1 || got.Attributes["service"] != "gcp" {
+		t.Errorf("attributes = %v, want only service=gcp", got.Attributes)
+	}
+}
@@ -3,7 +3,10 @@ package store
 import (
 	"context"
 	"fmt"
+	"os"
+	"slices"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -19,6 +22,7 @@ const (
 	labelKey        = "_ss_label"
 	createdKey      = "_ss_created"
 	modifiedKey     = "_ss_modified"
+	expiresKey      = "_ss_expires"
 	contentTypeKey  = "_ss_content_type"
 	collLabelKey    = "_ss_coll_label"
 	collCreatedKey  = "_ss_coll_created"
@@ -38,19 +42,103 @@ type GopassStore struct {
 	// dominates its latency, so caching metadata turns a per-lookup O(N)
 	// decryption of the whole collection into a single decryption per entry.
 	// The actual secret value is always re-decrypted on demand in GetItem and
-	// never retained. Entries are invalidated on every local mutation; there is
-	// no TTL, so out-of-process changes require a daemon restart.
+	// never retained. Entries are invalidated on every local mutation and, when
+	// Watch is running, on every out-of-process change to the store directory.
+	// With EnableIndex the cache is also persisted across restarts (index).
+	// The listing and postings (listing.go) spare lookups the scans over the
+	// whole store.
 	cacheMu   sync.RWMutex
 	metaCache map[string]map[string]string
+	index     *attrIndex
+	listing   listing
+	postings  map[string]map[string]struct{}
+
+	// dir is the on-disk directory of the prefix (see SetDir) and
+	// recentWrites the paths we changed ourselves, keyed to the time of the
+	// write; both serve Watch. recentWrites is guarded by cacheMu.
+	dir          string
+	recentWrites map[string]time.Time
+
+	// onWrite is called after every successful write (see SetWriteHook).
+	onWrite func()
+
+	// pool bounds concurrent decryptions (see SetDecryptPool); nil
+	// decrypts one entry at a time.
+	pool *DecryptPool
+
+	// templates name new items after their attributes (see
+	// SetPathTemplates); nil keeps UUID names. createMu is held from
+	// rendering a templated path until its entry is listed, so concurrent
+	// creates can't pick the same free path.
+	templates *PathTemplates
+	createMu  sync.Mutex
+
+	// aliasMu serializes SetAlias, whose read-modify-write of the alias
+	// table would otherwise lose concurrent updates.
+	aliasMu sync.Mutex
+
+	// decryptGPG decrypts an old revision of a GPG entry; replaced in tests.
+	decryptGPG func(ctx context.Context, ciphertext []byte) ([]byte, error)
 }
 
 // NewGopassStore creates a new GoPass-backed store
-func NewGopassStore(ctx context.Context, prefix string) (*GopassStore, error) {
-	store, err := api.New(ctx)
+func NewGopassStore(ctx context.Context, storePath, prefix string) (*GopassStore, error) {
+	backend, err := NewGopassBackend(ctx, storePath)
+	if err != nil {
+		return nil, err
+	}
+	return NewGopassStoreWithBackend(backend, prefix), nil
+}
+
+// NewGopassBackend opens the gopass root store, including all of its mounts.
+// A non-empty storePath overrides mounts.path from the gopass config for this
+// process only. The returned backend may be shared by several GopassStores
+// (one per route); closing it more than once is a no-op.
+func NewGopassBackend(ctx context.Context, storePath string) (gopass.Store, error) {
+	if storePath != "" {
+		if err := overrideGopassConfig("mounts.path", storePath); err != nil {
+			return nil, err
+		}
+	}
+	backend, err := api.New(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialize gopass: %w", err)
 	}
-	return NewGopassStoreWithBackend(store, prefix), nil
+	return &sharedBackend{Store: backend}, nil
+}
+
+// overrideGopassConfig sets a gopass config key at the environment level,
+// which takes precedence over every config file. gopass reads these overrides
+// from GOPASS_CONFIG_KEY_n/GOPASS_CONFIG_VALUE_n up to GOPASS_CONFIG_COUNT, so
+// we append to whatever the user already exported.
+func overrideGopassConfig(key, value string) error {
+	n := 0
+	if v := os.Getenv("GOPASS_CONFIG_COUNT"); v != "" {
+		var err error
+		if n, err = strconv.Atoi(v); err != nil {
+			return fmt.Errorf("invalid GOPASS_CONFIG_COUNT %q: %w", v, err)
+		}
+	}
+	if err := os.Setenv(fmt.Sprintf("GOPASS_CONFIG_KEY_%d", n), key); err != nil {
+		return err
+	}
+	if err := os.Setenv(fmt.Sprintf("GOPASS_CONFIG_VALUE_%d", n), value); err != nil {
+		return err
+	}
+	return os.Setenv("GOPASS_CONFIG_COUNT", strconv.Itoa(n+1))
+}
+
+// sharedBackend makes Close idempotent so every GopassStore built on the
+// same backend can close it unconditionally.
+type sharedBackend struct {
+	gopass.Store
+	closeOnce sync.Once
+	closeErr  error
+}
+
+func (b *sharedBackend) Close(ctx context.Context) error {
+	b.closeOnce.Do(func() { b.closeErr = b.Store.Close(ctx) })
+	return b.closeErr
 }
 
 // NewGopassStoreWithBackend builds a store over an arbitrary gopass.Store
@@ -58,25 +146,20 @@ func NewGopassStore(ctx context.Context, prefix string) (*GopassStore, error) {
 // fake backend; production code uses NewGopassStore.
 func NewGopassStoreWithBackend(backend gopass.Store, prefix string) *GopassStore {
 	return &GopassStore{
-		store:     backend,
-		mapper:    NewMapper(prefix),
-		locked:    make(map[string]bool),
-		metaCache: make(map[string]map[string]string),
-	}
-}
-
-// metaFromSecret extracts a decrypted entry's metadata key/value pairs. By
-// construction it copies only gopass Keys() — never Password() or Body() — so
-// the result is safe to cache without retaining the secret value.
-func metaFromSecret(sec gopass.Secret) map[string]string {
-	keys := sec.Keys()
-	m := make(map[string]string, len(keys))
-	for _, k := range keys {
-		if v, ok := sec.Get(k); ok {
-			m[k] = v
-		}
+		store:        backend,
+		mapper:       NewMapper(prefix),
+		locked:       make(map[string]bool),
+		metaCache:    make(map[string]map[string]string),
+		postings:     make(map[string]map[string]struct{}),
+		recentWrites: make(map[string]time.Time),
+		decryptGPG:   gpgDecrypt,
 	}
-	return m
+}
+
+// SetPathTemplates makes new items get paths derived from their attributes.
+// Existing items keep their paths. It must be set before the store is used.
+func (s *GopassStore) SetPathTemplates(t *PathTemplates) {
+	s.templates = t
 }
 
 // metaFor returns an entry's cached metadata, decrypting and caching it on the
@@ -89,30 +172,33 @@ func (s *GopassStore) metaFor(ctx context.Context, path string) (map[string]stri
 		return m, nil
 	}
 
-	sec, err := s.store.Get(ctx, path, "latest")
+	// Stat before decrypting: if the file changes in between, the index
+	// records the older version and the entry is simply re-read next time.
+	stamp, stamped := s.stampFor(path)
+	sec, err := s.decrypt(ctx, path)
 	if err != nil {
 		return nil, err
 	}
 	m = metaFromSecret(sec)
-
-	s.cacheMu.Lock()
-	s.metaCache[path] = m
-	s.cacheMu.Unlock()
+	s.putMeta(path, m, stamp, stamped)
 	return m, nil
 }
 
 // putMeta refreshes the cached metadata for a path (used when an entry has just
-// been decrypted for another reason).
-func (s *GopassStore) putMeta(path string, meta map[string]string) {
+// been decrypted for another reason). stamp is the entry's file as stat'ed
+// before decryption, if it could be.
+func (s *GopassStore) putMeta(path string, meta map[string]string, stamp fileStamp, stamped bool) {
 	s.cacheMu.Lock()
-	s.metaCache[path] = meta
+	s.setMetaLocked(path, meta)
+	s.recordStamp(path, stamp, stamped)
 	s.cacheMu.Unlock()
 }
 
 // invalidateMeta drops the cached metadata for a single path.
 func (s *GopassStore) invalidateMeta(path string) {
 	s.cacheMu.Lock()
-	delete(s.metaCache, path)
+	s.dropMetaLocked(path)
+	s.forgetStamp(path)
 	s.cacheMu.Unlock()
 }
 
@@ -122,7 +208,8 @@ func (s *GopassStore) invalidateMetaPrefix(prefix string) {
 	s.cacheMu.Lock()
 	for k := range s.metaCache {
 		if k == prefix || strings.HasPrefix(k, prefix+"/") {
-			delete(s.metaCache, k)
+			s.dropMetaLocked(k)
+			s.forgetStamp(k)
 		}
 	}
 	s.cacheMu.Unlock()
@@ -143,6 +230,10 @@ func applyItemMeta(item *ItemData, meta map[string]string) {
 			if ts, err := time.Parse(time.RFC3339, val); err == nil {
 				item.Modified = ts
 			}
+		case expiresKey:
+			if ts, err := time.Parse(time.RFC3339, val); err == nil {
+				item.Expires = ts
+			}
 		case contentTypeKey:
 			item.ContentType = val
 		default:
@@ -156,33 +247,17 @@ func applyItemMeta(item *ItemData, meta map[string]string) {
 
 // Collections returns all collection names
 func (s *GopassStore) Collections(ctx context.Context) ([]string, error) {
-	allPaths, err := s.store.List(ctx)
-	if err != nil {
+	if err := s.ensureListing(ctx); err != nil {
 		return nil, err
 	}
+	names := s.listedFirstSegments()
 
-	collections := make(map[string]bool)
-	for _, p := range allPaths {
-		// Only include paths under our prefix
-		if !strings.HasPrefix(p, s.mapper.prefix+"/") {
-			continue
-		}
-
-		coll, _, err := s.mapper.ParsePath(p)
-		if err != nil {
-			continue
-		}
-
+	result := make([]string, 0, len(names))
+	for _, coll := range names {
 		// Skip special entries
-		if coll == "_aliases" || strings.HasPrefix(coll, "_") {
+		if strings.HasPrefix(coll, "_") {
 			continue
 		}
-
-		collections[coll] = true
-	}
-
-	result := make([]string, 0, len(collections))
-	for coll := range collections {
 		result = append(result, coll)
 	}
 	sort.Strings(result)
@@ -195,25 +270,9 @@ func (s *GopassStore) GetCollection(ctx context.Context, name string) (*Collecti
 
 	meta, err := s.metaFor(ctx, metaPath)
 	if err != nil {
-		// Check if collection exists by looking for any items
-		items, err := s.Items(ctx, name)
-		if err != nil || len(items) == 0 {
-			// Try listing to see if collection exists
-			allPaths, listErr := s.store.List(ctx)
-			if listErr != nil {
-				return nil, fmt.Errorf("collection not found: %s", name)
-			}
-			collPath := s.mapper.CollectionPath(name)
-			found := false
-			for _, p := range allPaths {
-				if strings.HasPrefix(p, collPath+"/") {
-					found = true
-					break
-				}
-			}
-			if !found {
-				return nil, fmt.Errorf("collection not found: %s", name)
-			}
+		// The collection exists if it has any entries
+		if err := s.ensureListing(ctx); err != nil || len(s.listedUnder(name)) == 0 {
+			return nil, fmt.Errorf("collection not found: %s", name)
 		}
 		// Collection exists but no metadata, return defaults
 		return &CollectionData{
@@ -271,6 +330,8 @@ func (s *GopassStore) CreateCollection(ctx context.Context, name, label string)
 		return err
 	}
 	s.invalidateMeta(metaPath)
+	s.listAdd(metaPath)
+	s.noteWrite(metaPath)
 	return nil
 }
 
@@ -281,6 +342,8 @@ func (s *GopassStore) DeleteCollection(ctx context.Context, name string) error {
 		return err
 	}
 	s.invalidateMetaPrefix(collPath)
+	s.listRemove(collPath)
+	s.noteWrite(collPath)
 	return nil
 }
 
@@ -310,38 +373,74 @@ func (s *GopassStore) SetCollectionLabel(ctx context.Context, name, label string
 		return err
 	}
 	s.invalidateMeta(metaPath)
+	s.listAdd(metaPath)
+	s.noteWrite(metaPath)
+	return nil
+}
+
+// RenameCollection moves the collection's folder with gopass, which commits
+// the move, so git keeps the history of every entry.
+func (s *GopassStore) RenameCollection(ctx context.Context, from, to string) error {
+	if err := validCollection(to); err != nil {
+		return err
+	}
+	names, err := s.Collections(ctx)
+	if err != nil {
+		return err
+	}
+	if !slices.Contains(names, from) {
+		return fmt.Errorf("collection not found: %s", from)
+	}
+	if slices.Contains(names, to) {
+		return fmt.Errorf("collection already exists: %s", to)
+	}
+
+	fromPath, toPath := s.mapper.CollectionPath(from), s.mapper.CollectionPath(to)
+	if err := s.store.Rename(ctx, fromPath, toPath); err != nil {
+		return err
+	}
+	s.invalidateMetaPrefix(fromPath)
+	s.invalidateMetaPrefix(toPath)
+	s.listRename(fromPath, toPath)
+	s.noteWrite(fromPath)
+	s.noteWrite(toPath)
+	s.locked[to] = s.locked[from]
+	delete(s.locked, from)
 	return nil
 }
 
 // Items returns all item IDs in a collection
 func (s *GopassStore) Items(ctx context.Context, collection string) ([]string, error) {
-	allPaths, err := s.store.List(ctx)
-	if err != nil {
+	if err := s.ensureListing(ctx); err != nil {
 		return nil, err
 	}
+	paths := s.itemPaths(collection)
 
-	collPath := s.mapper.CollectionPath(collection)
-	var items []string
-
-	for _, p := range allPaths {
-		if !strings.HasPrefix(p, collPath+"/") {
-			continue
-		}
+	items := make([]string, 0, len(paths))
+	for _, p := range paths {
+		items = append(items, s.itemID(p))
+	}
 
-		_, itemID, err := s.mapper.ParsePath(p)
-		if err != nil || itemID == "" {
-			continue
-		}
+	return items, nil
+}
 
-		// Skip metadata entries
-		if strings.HasPrefix(itemID, "_") {
-			continue
-		}
+// itemPaths returns the sorted paths of a collection's items: the entries
+// beneath it, which may be nested (see PathTemplates), except metadata.
+func (s *GopassStore) itemPaths(collection string) []string {
+	return slices.DeleteFunc(s.listedUnder(collection), func(p string) bool { return !s.isItemPath(p) })
+}
 
-		items = append(items, itemID)
-	}
+// isItemPath reports whether the entry at p is an item rather than a
+// collection's metadata or a store-level entry.
+func (s *GopassStore) isItemPath(p string) bool {
+	_, rel, err := s.mapper.ParsePath(p)
+	return err == nil && rel != "" && !strings.HasPrefix(rel, "_")
+}
 
-	return items, nil
+// itemID returns the ID of the item at p.
+func (s *GopassStore) itemID(p string) string {
+	_, rel, _ := s.mapper.ParsePath(p)
+	return s.mapper.ItemID(rel)
 }
 
 // GetItem returns an item by collection and ID
@@ -349,18 +448,24 @@ func (s *GopassStore) GetItem(ctx context.Context, collection, id string) (*Item
 	itemPath := s.mapper.ItemPath(collection, id)
 
 	// Secret retrieval always decrypts fresh — the password is never cached.
-	sec, err := s.store.Get(ctx, itemPath, "latest")
+	stamp, stamped := s.stampFor(itemPath)
+	sec, err := s.decrypt(ctx, itemPath)
 	if err != nil {
 		return nil, fmt.Errorf("item not found: %s/%s", collection, id)
 	}
 
+	secret, err := decodeEntry(sec)
+	if err != nil {
+		return nil, fmt.Errorf("item %s/%s: %w", collection, id, err)
+	}
+
 	// Refresh the metadata cache opportunistically since we just decrypted.
 	meta := metaFromSecret(sec)
-	s.putMeta(itemPath, meta)
+	s.putMeta(itemPath, meta, stamp, stamped)
 
 	item := &ItemData{
 		ID:          id,
-		Secret:      []byte(sec.Password()),
+		Secret:      secret,
 		ContentType: "text/plain",
 		Attributes:  make(map[string]string),
 	}
@@ -369,8 +474,23 @@ func (s *GopassStore) GetItem(ctx context.Context, collection, id string) (*Item
 	return item, nil
 }
 
-// CreateItem creates a new item in a collection
+// CreateItem creates a new item in a collection. When a path template (see
+// SetPathTemplates) applies to the item, it is stored at the rendered path and
+// its ID is derived from that path, replacing item.ID; the returned ID is the
+// one to use.
 func (s *GopassStore) CreateItem(ctx context.Context, collection string, item *ItemData) (string, error) {
+	if s.templates != nil {
+		s.createMu.Lock()
+		defer s.createMu.Unlock()
+		id, err := s.templatedID(ctx, collection, item)
+		if err != nil {
+			return "", err
+		}
+		if id != "" {
+			item.ID = id
+		}
+	}
+
 	// Generate a D-Bus-safe ID if not provided. The ID becomes an object-path
 	// element, which forbids hyphens, so use the same "i"+hex encoding as the
 	// keyring store and the service layer rather than a raw hyphenated UUID.
@@ -392,35 +512,20 @@ func (s *GopassStore) CreateItem(ctx context.Context, collection string, item *I
 	if item.Created.IsZero() {
 		item.Created = now
 	}
-	item.Modified = now
+	if item.Modified.IsZero() {
+		item.Modified = now
+	}
 
 	if item.ContentType == "" {
 		item.ContentType = "text/plain"
 	}
-
-	sec := secrets.New()
-	sec.SetPassword(string(item.Secret))
-	for _, kv := range []struct{ k, v string }{
-		{labelKey, item.Label},
-		{createdKey, item.Created.Format(time.RFC3339)},
-		{modifiedKey, item.Modified.Format(time.RFC3339)},
-		{contentTypeKey, item.ContentType},
-	} {
-		if err := sec.Set(kv.k, kv.v); err != nil {
-			return "", fmt.Errorf("set %s: %w", kv.k, err)
-		}
+	if err := applyTTL(item, nil); err != nil {
+		return "", err
 	}
 
-	// Add user attributes (sorted for consistency)
-	keys := make([]string, 0, len(item.Attributes))
-	for k := range item.Attributes {
-		keys = append(keys, k)
-	}
-	sort.Strings(keys)
-	for _, k := range keys {
-		if err := sec.Set(k, item.Attributes[k]); err != nil {
-			return "", fmt.Errorf("set attr %s: %w", k, err)
-		}
+	sec, err := encodeEntry(item)
+	if err != nil {
+		return "", err
 	}
 
 	itemPath := s.mapper.ItemPath(collection, item.ID)
@@ -428,11 +533,33 @@ func (s *GopassStore) CreateItem(ctx context.Context, collection string, item *I
 		return "", err
 	}
 	s.invalidateMeta(itemPath)
+	s.listAdd(itemPath)
+	s.noteWrite(itemPath)
 
 	return item.ID, nil
 }
 
-// UpdateItem updates an existing item
+// templatedID renders the item's path template and returns the ID of the
+// resulting entry, or "" if no template applies. An entry that already
+// exists at that path is never overwritten: the new one gets a "-2", "-3", ...
+// suffix instead. The caller holds createMu until the entry is listed.
+func (s *GopassStore) templatedID(ctx context.Context, collection string, item *ItemData) (string, error) {
+	rel, err := s.templates.Render(collection, item)
+	if err != nil || rel == "" {
+		return "", err
+	}
+	if err := s.ensureListing(ctx); err != nil {
+		return "", err
+	}
+	candidate := rel
+	for n := 2; s.isListed(s.mapper.ItemPath(collection, s.mapper.ItemID(candidate))); n++ {
+		candidate = fmt.Sprintf("%s-%d", rel, n)
+	}
+	return s.mapper.ItemID(candidate), nil
+}
+
+// UpdateItem updates an existing item. It stays at its path, even if the
+// attributes its path template uses change, so its ID stays valid.
 func (s *GopassStore) UpdateItem(ctx context.Context, collection, id string, item *ItemData) error {
 	existing, err := s.GetItem(ctx, collection, id)
 	if err != nil {
@@ -447,30 +574,13 @@ func (s *GopassStore) UpdateItem(ctx context.Context, collection, id string, ite
 	if item.ContentType == "" {
 		item.ContentType = existing.ContentType
 	}
-
-	sec := secrets.New()
-	sec.SetPassword(string(item.Secret))
-	for _, kv := range []struct{ k, v string }{
-		{labelKey, item.Label},
-		{createdKey, item.Created.Format(time.RFC3339)},
-		{modifiedKey, item.Modified.Format(time.RFC3339)},
-		{contentTypeKey, item.ContentType},
-	} {
-		if err := sec.Set(kv.k, kv.v); err != nil {
-			return fmt.Errorf("set %s: %w", kv.k, err)
-		}
+	if err := applyTTL(item, nil); err != nil {
+		return err
 	}
 
-	// Add user attributes (sorted for consistency)
-	keys := make([]string, 0, len(item.Attributes))
-	for k := range item.Attributes {
-		keys = append(keys, k)
-	}
-	sort.Strings(keys)
-	for _, k := range keys {
-		if err := sec.Set(k, item.Attributes[k]); err != nil {
-			return fmt.Errorf("set attr %s: %w", k, err)
-		}
+	sec, err := encodeEntry(item)
+	if err != nil {
+		return err
 	}
 
 	itemPath := s.mapper.ItemPath(collection, id)
@@ -478,6 +588,8 @@ func (s *GopassStore) UpdateItem(ctx context.Context, collection, id string, ite
 		return err
 	}
 	s.invalidateMeta(itemPath)
+	s.listAdd(itemPath)
+	s.noteWrite(itemPath)
 	return nil
 }
 
@@ -488,28 +600,87 @@ func (s *GopassStore) DeleteItem(ctx context.Context, collection, id string) err
 		return err
 	}
 	s.invalidateMeta(itemPath)
+	s.listRemove(itemPath)
+	s.noteWrite(itemPath)
 	return nil
 }
 
+// MoveItem moves the item's entry with gopass, which commits the move, so git
+// keeps its history. The entry keeps its name below the collection, and with
+// it its ID.
+func (s *GopassStore) MoveItem(ctx context.Context, from, id, to string) (string, error) {
+	if err := validCollection(to); err != nil {
+		return "", err
+	}
+	src, dst := s.mapper.ItemPath(from, id), s.mapper.ItemPath(to, id)
+	if err := s.ensureListing(ctx); err != nil {
+		return "", err
+	}
+	if !s.isListed(src) {
+		return "", fmt.Errorf("item not found: %s/%s", from, id)
+	}
+	if from == to {
+		return id, nil
+	}
+	if s.isListed(dst) {
+		return "", fmt.Errorf("item already exists: %s/%s", to, id)
+	}
+
+	if _, err := s.GetCollection(ctx, to); err != nil {
+		if err := s.CreateCollection(ctx, to, to); err != nil {
+			return "", fmt.Errorf("failed to create collection: %w", err)
+		}
+	}
+	if err := s.store.Rename(ctx, src, dst); err != nil {
+		return "", err
+	}
+	s.invalidateMeta(src)
+	s.invalidateMeta(dst)
+	s.listRename(src, dst)
+	s.noteWrite(src)
+	s.noteWrite(dst)
+	return id, nil
+}
+
 // SearchItems searches for items matching the given attributes
 func (s *GopassStore) SearchItems(ctx context.Context, collection string, attributes map[string]string) ([]*ItemData, error) {
-	items, err := s.Items(ctx, collection)
-	if err != nil {
+	if err := s.ensureListing(ctx); err != nil {
 		return nil, err
 	}
+	results := s.searchItems(ctx, collection, attributes)
+	s.saveIndexQuietly()
+	return results, nil
+}
+
+// searchItems searches the listing as it is.
+func (s *GopassStore) searchItems(ctx context.Context, collection string, attributes map[string]string) []*ItemData {
+	// Matching needs only attributes, so read cached metadata rather than
+	// decrypting the secret. Once every item's metadata is cached, the
+	// postings narrow the search down to the items having one of the
+	// attributes.
+	cold := slices.DeleteFunc(s.coldUnder(collection), func(p string) bool { return !s.isItemPath(p) })
+	failed := s.warmMeta(ctx, cold, nil)
+	paths, ok := s.candidates(collection, attributes)
+	if ok {
+		paths = slices.DeleteFunc(paths, func(p string) bool { return !s.isItemPath(p) })
+	} else {
+		paths = s.itemPaths(collection)
+	}
 
 	var results []*ItemData
-	for _, id := range items {
-		// Matching needs only attributes, so read cached metadata rather than
-		// decrypting the secret. The returned ItemData carries no Secret; the
-		// payload is decrypted lazily by GetItem when a secret is actually read.
-		meta, err := s.metaFor(ctx, s.mapper.ItemPath(collection, id))
+	for _, p := range paths {
+		if failed[p] {
+			continue
+		}
+		// The returned ItemData carries no Secret; the payload is decrypted
+		// lazily by GetItem when a secret is actually read.
+		meta, err := s.metaFor(ctx, p)
 		if err != nil {
 			continue
 		}
 
 		item := &ItemData{
-			ID:          id,
+			ID:          s.itemID(p),
 			ContentType: "text/plain",
 			Attributes:  make(map[string]string),
 		}
@@ -520,7 +691,7 @@ func (s *GopassStore) SearchItems(ctx context.Context, collection string, attrib
 		}
 	}
 
-	return results, nil
+	return results
 }
 
 // SearchAllItems searches across all collections
@@ -530,13 +701,10 @@ func (s *GopassStore) SearchAllItems(ctx context.Context, attributes map[string]
 		return nil, err
 	}
 
+	defer s.saveIndexQuietly()
 	results := make(map[string][]*ItemData)
 	for _, coll := range collections {
-		items, err := s.SearchItems(ctx, coll, attributes)
-		if err != nil {
-			continue
-		}
-		if len(items) > 0 {
+		if items := s.searchItems(ctx, coll, attributes); len(items) > 0 {
 			results[coll] = items
 		}
 	}
@@ -559,7 +727,7 @@ func (s *GopassStore) UnlockCollection(ctx context.Context, name string) error {
 // GetAlias returns the collection name for an alias
 func (s *GopassStore) GetAlias(ctx context.Context, alias string) (string, error) {
 	aliasPath := s.mapper.AliasesPath()
-	sec, err := s.store.Get(ctx, aliasPath, "latest")
+	sec, err := s.decrypt(ctx, aliasPath)
 	if err != nil {
 		// Handle default alias specially
 		if alias == "default" {
@@ -579,22 +747,45 @@ func (s *GopassStore) GetAlias(ctx context.Context, alias string) (string, error
 	return result, nil
 }
 
-// SetAlias sets an alias for a collection
-func (s *GopassStore) SetAlias(ctx context.Context, alias, collection string) error {
-	aliasPath := s.mapper.AliasesPath()
+// Aliases implements AliasLister.
+func (s *GopassStore) Aliases(ctx context.Context) (map[string]string, error) {
+	return s.readAliases(ctx)
+}
 
-	// Get existing aliases
+// readAliases reads the alias table; a missing table is empty. A table that
+// exists but can't be read is an error, so SetAlias never writes back an
+// empty table over it.
+func (s *GopassStore) readAliases(ctx context.Context) (map[string]string, error) {
 	aliases := make(map[string]string)
-	sec, err := s.store.Get(ctx, aliasPath, "latest")
-	if err == nil {
-		for _, key := range sec.Keys() {
-			if val, ok := sec.Get(key); ok {
-				aliases[key] = val
-			}
+	aliasPath := s.mapper.AliasesPath()
+	if err := s.ensureListing(ctx); err != nil {
+		return nil, err
+	}
+	if !s.isListed(aliasPath) {
+		return aliases, nil
+	}
+	sec, err := s.decrypt(ctx, aliasPath)
+	if err != nil {
+		return nil, fmt.Errorf("read aliases: %w", err)
+	}
+	for _, key := range sec.Keys() {
+		if val, ok := sec.Get(key); ok && val != "" {
+			aliases[key] = val
 		}
 	}
+	return aliases, nil
+}
+
+// SetAlias sets an alias for a collection
+func (s *GopassStore) SetAlias(ctx context.Context, alias, collection string) error {
+	s.aliasMu.Lock()
+	defer s.aliasMu.Unlock()
 
 	// Update alias
+	aliases, err := s.readAliases(ctx)
+	if err != nil {
+		return err
+	}
 	if collection == "" {
 		delete(aliases, alias)
 	} else {
@@ -610,12 +801,24 @@ func (s *GopassStore) SetAlias(ctx context.Context, alias, collection string) er
 		}
 	}
 
-	return s.store.Set(ctx, aliasPath, newSec)
+	if err := s.store.Set(ctx, s.mapper.AliasesPath(), newSec); err != nil {
+		return err
+	}
+	s.listAdd(s.mapper.AliasesPath())
+	s.noteWrite(s.mapper.AliasesPath())
+	return nil
 }
 
 // Close closes the store
 func (s *GopassStore) Close(ctx context.Context) error {
-	return s.store.Close(ctx)
+	indexErr := s.SaveIndex()
+	if err := s.store.Close(ctx); err != nil {
+		return err
+	}
+	if indexErr != nil {
+		return fmt.Errorf("save index: %w", indexErr)
+	}
+	return nil
 }
 
 func matchesAttributes(item *ItemData, attrs map[string]string) bool {
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"sync"
 	"testing"
 
 	"github.com/gopasspw/gopass/pkg/gopass"
@@ -12,10 +13,13 @@ import (
 
 // fakeGopassStore is a minimal gopass.Store used to exercise GopassStore's
 // metadata cache without GPG. It records how many times each path is decrypted
-// (Get) so tests can assert the cache avoids re-decryption.
+// (Get) so tests can assert the cache avoids re-decryption, and how many times
+// the whole store is listed. Get may be called concurrently.
 type fakeGopassStore struct {
-	data     map[string]gopass.Secret
-	getCount map[string]int
+	data      map[string]gopass.Secret
+	mu        sync.Mutex
+	getCount  map[string]int
+	listCount int
 }
 
 func newFakeGopassStore() *fakeGopassStore {
@@ -39,6 +43,9 @@ func (f *fakeGopassStore) putSecret(path, password string, attrs map[string]stri
 func (f *fakeGopassStore) String() string { return "fakeGopassStore" }
 
 func (f *fakeGopassStore) List(ctx context.Context) ([]string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.listCount++
 	out := make([]string, 0, len(f.data))
 	for k := range f.data {
 		out = append(out, k)
@@ -47,6 +54,8 @@ func (f *fakeGopassStore) List(ctx context.Context) ([]string, error) {
 }
 
 func (f *fakeGopassStore) Get(ctx context.Context, name, revision string) (gopass.Secret, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
 	f.getCount[name]++
 	sec, ok := f.data[name]
 	if !ok {
@@ -58,6 +67,8 @@ func (f *fakeGopassStore) Get(ctx context.Context, name, revision string) (gopas
 func (f *fakeGopassStore) Set(ctx context.Context, name string, b gopass.Byter) error {
 	// GopassStore always passes a *secrets.AKV, which is also a gopass.Secret,
 	// so we can store it verbatim and serve its metadata back on Get.
+	f.mu.Lock()
+	defer f.mu.Unlock()
 	if sec, ok := b.(gopass.Secret); ok {
 		f.data[name] = sec
 		return nil
@@ -86,17 +97,21 @@ func (f *fakeGopassStore) RemoveAll(ctx context.Context, prefix string) error {
 	return nil
 }
 
-func (f *fakeGopassStore) Rename(ctx context.Context, src, dest string) error { return nil }
-func (f *fakeGopassStore) Sync(ctx context.Context) error                     { return nil }
-func (f *fakeGopassStore) Close(ctx context.Context) error                    { return nil }
+func (f *fakeGopassStore) Rename(ctx context.Context, src, dest string) error {
+	for k, sec := range f.data {
+		if k == src || strings.HasPrefix(k, src+"/") {
+			f.data[dest+strings.TrimPrefix(k, src)] = sec
+			delete(f.data, k)
+		}
+	}
+	return nil
+}
+
+func (f *fakeGopassStore) Sync(ctx context.Context) error  { return nil }
+func (f *fakeGopassStore) Close(ctx context.Context) error { return nil }
 
 func newTestGopassStore(inner gopass.Store) *GopassStore {
-	return &GopassStore{
-		store:     inner,
-		mapper:    NewMapper("secret-service"),
-		locked:    make(map[string]bool),
-		metaCache: make(map[string]map[string]string),
-	}
+	return NewGopassStoreWithBackend(inner, "secret-service")
 }
 
 // TestSearchItemsCachesMetadata verifies the perf fix: repeated searches must
@@ -105,7 +120,7 @@ func TestSearchItemsCachesMetadata(t *testing.T) {
 	ctx := context.Background()
 	fake := newFakeGopassStore()
 	s := newTestGopassStore(fake)
-	path := s.mapper.ItemPath("default", "item-a")
+	path := s.mapper.ItemPath("default", "item_a")
 	fake.putSecret(path, "secret-a", map[string]string{"service": "etherscan.io"})
 
 	for i := range 5 {
@@ -113,8 +128,8 @@ func TestSearchItemsCachesMetadata(t *testing.T) {
 		if err != nil {
 			t.Fatalf("SearchItems #%d: %v", i, err)
 		}
-		if len(res) != 1 || res[0].ID != "item-a" {
-			t.Fatalf("SearchItems #%d: got %d results, want item-a", i, len(res))
+		if len(res) != 1 || res[0].ID != "item_a" {
+			t.Fatalf("SearchItems #%d: got %d results, want item_a", i, len(res))
 		}
 	}
 
@@ -134,7 +149,7 @@ func TestSearchItemsNeverCachesSecret(t *testing.T) {
 
 	fake := newFakeGopassStore()
 	s := newTestGopassStore(fake)
-	path := s.mapper.ItemPath("default", "item-a")
+	path := s.mapper.ItemPath("default", "item_a")
 	fake.putSecret(path, payload, map[string]string{"service": "etherscan.io"})
 
 	res, err := s.SearchItems(ctx, "default", map[string]string{"service": "etherscan.io"})
@@ -150,7 +165,7 @@ func TestSearchItemsNeverCachesSecret(t *testing.T) {
 	}
 
 	// Reading the actual secret must work and must not poison the cache.
-	item, err := s.GetItem(ctx, "default", "item-a")
+	item, err := s.GetItem(ctx, "default", "item_a")
 	if err != nil {
 		t.Fatalf("GetItem: %v", err)
 	}
@@ -168,7 +183,7 @@ func TestWriteInvalidatesCache(t *testing.T) {
 	ctx := context.Background()
 	fake := newFakeGopassStore()
 	s := newTestGopassStore(fake)
-	path := s.mapper.ItemPath("default", "item-a")
+	path := s.mapper.ItemPath("default", "item_a")
 	fake.putSecret(path, "secret-a", map[string]string{
 		labelKey:  "etherscan key",
 		"service": "etherscan.io",
@@ -180,7 +195,7 @@ func TestWriteInvalidatesCache(t *testing.T) {
 	}
 
 	// Update the item's attributes via the daemon's own write path.
-	err := s.UpdateItem(ctx, "default", "item-a", &ItemData{
+	err := s.UpdateItem(ctx, "default", "item_a", &ItemData{
 		Label:      "etherscan key",
 		Secret:     []byte("secret-a"),
 		Attributes: map[string]string{"service": "etherscan-v2.io"},
@@ -312,3 +327,33 @@ func assertCacheHasNoSecret(t *testing.T, s *GopassStore, secret string) {
 		}
 	}
 }
+
+// failingGetStore fails every read, as gopass does when decryption fails.
+type failingGetStore struct{ *fakeGopassStore }
+
+func (f failingGetStore) Get(ctx context.Context, name, revision string) (gopass.Secret, error) {
+	return nil, fmt.Errorf("gpg: decryption failed: No secret key")
+}
+
+func TestAliasesReadErrorIsNotEmptyTable(t *testing.T) {
+	ctx := context.Background()
+	fake := newFakeGopassStore()
+	s := newTestGopassStore(fake)
+	if aliases, err := s.Aliases(ctx); err != nil || len(aliases) != 0 {
+		t.Fatalf("Aliases without a table = %v, %v; want empty", aliases, err)
+	}
+	if err := s.SetAlias(ctx, "login", "personal"); err != nil {
+		t.Fatalf("SetAlias: %v", err)
+	}
+
+	s = newTestGopassStore(failingGetStore{fake})
+	if _, err := s.Aliases(ctx); err == nil {
+		t.Error("Aliases succeeded although the table can't be read")
+	}
+	if err := s.SetAlias(ctx, "work", "team"); err == nil {
+		t.Error("SetAlias succeeded although the table can't be read")
+	}
+	if got, _ := fake.data[s.mapper.AliasesPath()].Get("login"); got != "personal" {
+		t.Errorf("alias table rewritten: login = %q", got)
+	}
+}
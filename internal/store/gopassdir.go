@@ -0,0 +1,75 @@
+package store
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gopasspw/gitconfig"
+	"github.com/gopasspw/gopass/pkg/appdir"
+)
+
+// GopassMountDir returns the directory on disk that holds a gopass mount ("" for
+// the root store). The gopass API only exposes store contents, not locations,
+// so this follows gopass's own lookup order: an explicit storePath for the
+// root store, mounts.path / mounts.<mount>.path from the environment overrides
+// and the per-user gopass config, then gopass's built-in defaults.
+func GopassMountDir(storePath, mount string) string {
+	if mount == "" && storePath != "" {
+		return storePath
+	}
+
+	key := "mounts.path"
+	if mount != "" {
+		key = "mounts." + mount + ".path"
+	}
+	if dir := gopassConfigValue(key); dir != "" {
+		return expandHome(dir)
+	}
+
+	if mount != "" {
+		return filepath.Join(appdir.UserData(), "stores", strings.ReplaceAll(mount, "/", "-"))
+	}
+	if dir := os.Getenv("PASSWORD_STORE_DIR"); dir != "" {
+		return expandHome(dir)
+	}
+	if legacy := filepath.Join(appdir.UserHome(), ".password-store"); isDir(legacy) {
+		return legacy
+	}
+	return filepath.Join(appdir.UserData(), "stores", "root")
+}
+
+// gopassConfigValue looks up a key in gopass's config, loaded the way gopass
+// loads it: the GOPASS_CONFIG_* environment overrides, the per-user and
+// system config files with their includes, and the root store's own config.
+// It returns "" if the key is unset.
+func gopassConfigValue(key string) string {
+	cfg := gitconfig.New()
+	cfg.Name = "gopass"
+	cfg.EnvPrefix = "GOPASS_CONFIG"
+	cfg.GlobalConfig = os.Getenv("GOPASS_CONFIG")
+	cfg.SystemConfig = "/etc/gopass/config"
+	cfg.NoWrites = true
+	cfg.LoadAll("")
+	if root := cfg.Get("mounts.path"); root != "" {
+		cfg.LoadAll(expandHome(root))
+	}
+	return cfg.Get(key)
+}
+
+func expandHome(dir string) string {
+	if dir == "~" || strings.HasPrefix(dir, "~/") {
+		return filepath.Join(appdir.UserHome(), dir[1:])
+	}
+	return dir
+}
+
+func isDir(dir string) bool {
+	fi, err := os.Stat(dir)
+	return err == nil && fi.IsDir()
+}
+
+func isFile(p string) bool {
+	fi, err := os.Stat(p)
+	return err == nil && fi.Mode().IsRegular()
+}
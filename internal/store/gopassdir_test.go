@@ -0,0 +1,73 @@
+package store
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGopassMountDir_ReadsConfigFile(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("GOPASS_HOMEDIR", home)
+	t.Setenv("GOPASS_CONFIG", "")
+	t.Setenv("GOPASS_CONFIG_NOSYSTEM", "1")
+	t.Setenv("GOPASS_CONFIG_COUNT", "")
+
+	dir := filepath.Join(home, ".config", "gopass")
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		t.Fatal(err)
+	}
+	writeFile := func(name, content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeFile("config", `# gopass config
+[Mounts]
+	path = /srv/root
+[mounts "work/team"]
+	path = "/srv/team store"
+[include]
+	path = extra
+`)
+	writeFile("extra", `[mounts "infra"]
+	path = /srv/infra
+`)
+
+	for mount, want := range map[string]string{
+		"":          "/srv/root",
+		"work/team": "/srv/team store",
+		"infra":     "/srv/infra",
+	} {
+		if got := GopassMountDir("", mount); got != want {
+			t.Errorf("GopassMountDir(%q) = %q, want %q", mount, got, want)
+		}
+	}
+}
+
+func TestGopassMountDir(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("GOPASS_HOMEDIR", home)
+	t.Setenv("GOPASS_CONFIG", filepath.Join(home, "missing"))
+	t.Setenv("PASSWORD_STORE_DIR", "")
+	t.Setenv("GOPASS_CONFIG_COUNT", "2")
+	t.Setenv("GOPASS_CONFIG_NOSYSTEM", "1")
+	t.Setenv("GOPASS_CONFIG_KEY_0", "core.autosync")
+	t.Setenv("GOPASS_CONFIG_VALUE_0", "true")
+	t.Setenv("GOPASS_CONFIG_KEY_1", "mounts.work.path")
+	t.Setenv("GOPASS_CONFIG_VALUE_1", "~/work")
+
+	if got := GopassMountDir("/explicit", ""); got != "/explicit" {
+		t.Errorf("root with store path = %q", got)
+	}
+	if got, want := GopassMountDir("/explicit", "work"), filepath.Join(home, "work"); got != want {
+		t.Errorf("overridden mount = %q, want %q", got, want)
+	}
+	if got := GopassMountDir("", "team/infra"); !strings.HasSuffix(got, filepath.Join("stores", "team-infra")) {
+		t.Errorf("default mount dir = %q", got)
+	}
+}
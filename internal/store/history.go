@@ -0,0 +1,274 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gopasspw/gopass/pkg/gopass/secrets"
+
+	"github.com/nikicat/gopass-secret-service/internal/gitsync"
+	"github.com/nikicat/gopass-secret-service/internal/secmem"
+)
+
+// ErrHistoryUnsupported is returned by ItemHistory methods for items whose
+// store keeps no history.
+var ErrHistoryUnsupported = errors.New("item history is not supported by this store")
+
+// ErrUnknownRevision is returned for a revision that isn't one of the item's.
+var ErrUnknownRevision = errors.New("unknown revision")
+
+// Revision is one stored version of an item.
+type Revision struct {
+	ID      string
+	Time    time.Time
+	Message string
+}
+
+// ItemHistory is implemented by stores that keep previous versions of items.
+type ItemHistory interface {
+	// ItemRevisions lists an item's revisions, newest first.
+	ItemRevisions(ctx context.Context, collection, id string) ([]Revision, error)
+	// GetItemRevision returns the item as it was at revision.
+	GetItemRevision(ctx context.Context, collection, id, revision string) (*ItemData, error)
+	// RestoreItemRevision makes the item's value, label and attributes at
+	// revision current again, as a new revision.
+	RestoreItemRevision(ctx context.Context, collection, id, revision string) error
+}
+
+// ItemRevisions implements ItemHistory by routing to the collection's store.
+func (m *MultiStore) ItemRevisions(ctx context.Context, collection, id string) ([]Revision, error) {
+	h, ok := m.routeByCollection(collection).(ItemHistory)
+	if !ok {
+		return nil, ErrHistoryUnsupported
+	}
+	return h.ItemRevisions(ctx, collection, id)
+}
+
+// GetItemRevision implements ItemHistory by routing to the collection's store.
+func (m *MultiStore) GetItemRevision(ctx context.Context, collection, id, revision string) (*ItemData, error) {
+	h, ok := m.routeByCollection(collection).(ItemHistory)
+	if !ok {
+		return nil, ErrHistoryUnsupported
+	}
+	return h.GetItemRevision(ctx, collection, id, revision)
+}
+
+// RestoreItemRevision implements ItemHistory by routing to the collection's
+// store.
+func (m *MultiStore) RestoreItemRevision(ctx context.Context, collection, id, revision string) error {
+	h, ok := m.routeByCollection(collection).(ItemHistory)
+	if !ok {
+		return ErrHistoryUnsupported
+	}
+	return h.RestoreItemRevision(ctx, collection, id, revision)
+}
+
+// ItemRevisions implements ItemHistory from the git log of the entry's file,
+// following it across moves and renames. The gopass API doesn't expose
+// revisions, so this needs the store directory (see SetDir).
+func (s *GopassStore) ItemRevisions(ctx context.Context, collection, id string) ([]Revision, error) {
+	revs, err := s.fileLog(ctx, collection, id)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]Revision, 0, len(revs))
+	for _, r := range revs {
+		out = append(out, r.Revision)
+	}
+	return out, nil
+}
+
+// fileRevision is a revision of an entry with the name the entry had then
+// and its file, relative to the top of the repository.
+type fileRevision struct {
+	Revision
+	name string
+	file string
+}
+
+// fileLog returns the revisions of an item's entry, newest first.
+func (s *GopassStore) fileLog(ctx context.Context, collection, id string) ([]fileRevision, error) {
+	current := s.mapper.ItemPath(collection, id)
+	file, err := s.entryFile(current)
+	if err != nil {
+		return nil, err
+	}
+	// Logged paths are relative to the top of the repository, which may be
+	// above the prefix directory.
+	top, err := gitsync.Git(ctx, s.dir, "rev-parse", "--show-prefix")
+	if err != nil {
+		return nil, fmt.Errorf("history of %s/%s: %w", collection, id, err)
+	}
+	rel, err := filepath.Rel(s.dir, file)
+	if err != nil {
+		return nil, fmt.Errorf("history of %s/%s: %w", collection, id, err)
+	}
+	currentFile := top + filepath.ToSlash(rel)
+	out, err := gitsync.Git(ctx, filepath.Dir(file), "log", "--follow", "--name-only", "--format=%x01%H%x00%ct%x00%s", "--", filepath.Base(file))
+	if err != nil {
+		return nil, fmt.Errorf("history of %s/%s: %w", collection, id, err)
+	}
+
+	var revs []fileRevision
+	for _, entry := range strings.Split(out, "\x01") {
+		header, files, _ := strings.Cut(entry, "\n")
+		fields := strings.SplitN(header, "\x00", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		ts, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			continue
+		}
+		name, logged := current, currentFile
+		if f := strings.TrimSpace(files); f != "" {
+			logged = f
+			if rel, ok := strings.CutPrefix(f, top); ok && rel != "" {
+				name = path.Join(s.mapper.prefix, strings.TrimSuffix(strings.TrimSuffix(rel, ".gpg"), ".age"))
+			}
+		}
+		revs = append(revs, fileRevision{
+			Revision: Revision{ID: fields[0], Time: time.Unix(ts, 0), Message: fields[2]},
+			name:     name,
+			file:     logged,
+		})
+	}
+	return revs, nil
+}
+
+// GetItemRevision implements ItemHistory. The secret is decrypted fresh and
+// never cached, like in GetItem. A revision from before the item was moved is
+// read under the name the entry had then.
+func (s *GopassStore) GetItemRevision(ctx context.Context, collection, id, revision string) (*ItemData, error) {
+	if !isRevisionID(revision) {
+		return nil, fmt.Errorf("item %s/%s: %w %q", collection, id, ErrUnknownRevision, revision)
+	}
+	revs, err := s.fileLog(ctx, collection, id)
+	if err != nil {
+		return nil, err
+	}
+	i := slices.IndexFunc(revs, func(r fileRevision) bool { return strings.HasPrefix(r.ID, revision) })
+	if i < 0 {
+		return nil, fmt.Errorf("item %s/%s: %w %s", collection, id, ErrUnknownRevision, revision)
+	}
+	raw, err := s.readRevision(ctx, revs[i])
+	if err != nil {
+		return nil, fmt.Errorf("item %s/%s at %s: %w", collection, id, revision, err)
+	}
+	defer secmem.Wipe(raw)
+	sec := secrets.ParseAKV(raw)
+	secret, err := decodeEntry(sec)
+	if err != nil {
+		return nil, fmt.Errorf("item %s/%s at %s: %w", collection, id, revision, err)
+	}
+
+	item := &ItemData{
+		ID:          id,
+		Secret:      secret,
+		ContentType: "text/plain",
+		Attributes:  make(map[string]string),
+	}
+	applyItemMeta(item, metaFromSecret(sec))
+	return item, nil
+}
+
+// RestoreItemRevision implements ItemHistory by writing the old version as a
+// regular update, so the restore is itself a revision that can be undone.
+func (s *GopassStore) RestoreItemRevision(ctx context.Context, collection, id, revision string) error {
+	old, err := s.GetItemRevision(ctx, collection, id, revision)
+	if err != nil {
+		return err
+	}
+	return s.UpdateItem(ctx, collection, id, old)
+}
+
+// entryFile returns the path of the encrypted file behind a store path.
+func (s *GopassStore) entryFile(p string) (string, error) {
+	rel, ok := strings.CutPrefix(p, s.mapper.prefix+"/")
+	if !ok || s.dir == "" {
+		return "", fmt.Errorf("%s: %w", p, ErrHistoryUnsupported)
+	}
+	base := filepath.Join(s.dir, filepath.FromSlash(rel))
+	for _, ext := range []string{".gpg", ".age"} {
+		if isFile(base + ext) {
+			return base + ext, nil
+		}
+	}
+	return "", fmt.Errorf("item not found: %s", path.Base(p))
+}
+
+// isRevisionID accepts git object names (full or abbreviated hashes) only,
+// so a revision can never be mistaken for a command-line option.
+func isRevisionID(rev string) bool {
+	if len(rev) < 4 || len(rev) > 64 {
+		return false
+	}
+	for _, c := range rev {
+		if !strings.ContainsRune("0123456789abcdef", c) {
+			return false
+		}
+	}
+	return true
+}
+
+// readRevision returns the raw content of an entry at a revision. GPG
+// entries are read from the store's own repository and decrypted with gpg,
+// as gopass does. Decrypting age entries needs gopass's identities, so those
+// are read with the gopass CLI.
+func (s *GopassStore) readRevision(ctx context.Context, r fileRevision) ([]byte, error) {
+	if path.Ext(r.file) == ".age" {
+		return gopassShowRevision(ctx, r.name, r.ID)
+	}
+	blob, err := gitsync.Blob(ctx, s.dir, r.ID, r.file)
+	if err != nil {
+		return nil, fmt.Errorf("git: %w", err)
+	}
+	return s.decryptGPG(ctx, blob)
+}
+
+// gpgDecrypt decrypts a gopass GPG entry.
+func gpgDecrypt(ctx context.Context, ciphertext []byte) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, "gpg", "--quiet", "--decrypt")
+	cmd.Stdin = bytes.NewReader(ciphertext)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		secmem.Wipe(stdout.Bytes())
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("gpg: %w: %s", err, msg)
+		}
+		return nil, fmt.Errorf("gpg: %w", err)
+	}
+	return stdout.Bytes(), nil
+}
+
+// gopassShowRevision returns the raw content of an entry at a git revision
+// with the gopass CLI, which must be in PATH and see the same store as the
+// daemon.
+func gopassShowRevision(ctx context.Context, name, revision string) ([]byte, error) {
+	if _, err := exec.LookPath("gopass"); err != nil {
+		return nil, fmt.Errorf("old revisions of age entries are read with the gopass CLI, which is not in PATH: %w", ErrHistoryUnsupported)
+	}
+	cmd := exec.CommandContext(ctx, "gopass", "show", "--unsafe", "--noparsing", "--revision", revision, "--", name)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		secmem.Wipe(stdout.Bytes())
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("gopass show: %w: %s", err, msg)
+		}
+		return nil, fmt.Errorf("gopass show: %w", err)
+	}
+	return stdout.Bytes(), nil
+}
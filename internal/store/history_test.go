@@ -0,0 +1,220 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/nikicat/gopass-secret-service/internal/gitsync"
+)
+
+// newHistoryStore returns a store whose prefix directory is inside a fresh git
+// repository. Entries are "encrypted" as their plain serialized form, which
+// old revisions are decrypted back to as is.
+func newHistoryStore(t *testing.T) (*GopassStore, *fakeGopassStore, string) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	t.Setenv("GIT_AUTHOR_NAME", "test")
+	t.Setenv("GIT_AUTHOR_EMAIL", "test@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "test")
+	t.Setenv("GIT_COMMITTER_EMAIL", "test@example.com")
+	t.Setenv("GIT_CONFIG_GLOBAL", os.DevNull)
+
+	repo := t.TempDir()
+	if _, err := gitsync.Git(context.Background(), repo, "init", "--quiet"); err != nil {
+		t.Fatal(err)
+	}
+	backend := newFakeGopassStore()
+	s := newTestGopassStore(backend)
+	s.SetDir(filepath.Join(repo, s.mapper.prefix))
+	s.decryptGPG = func(_ context.Context, ciphertext []byte) ([]byte, error) {
+		return ciphertext, nil
+	}
+	return s, backend, repo
+}
+
+// commitEntry writes what gopass would have written for an entry and commits
+// it, like gopass does after every Set.
+func commitEntry(t *testing.T, s *GopassStore, backend *fakeGopassStore, repo, collection, id string) {
+	t.Helper()
+	name := s.mapper.ItemPath(collection, id)
+	file := filepath.Join(repo, name+".gpg")
+	if err := os.MkdirAll(filepath.Dir(file), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(file, backend.data[name].Bytes(), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	if _, err := gitsync.Git(ctx, repo, "add", "."); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := gitsync.Git(ctx, repo, "commit", "--quiet", "-m", "Save secret to "+name); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGopassStore_ItemHistory(t *testing.T) {
+	ctx := context.Background()
+	s, backend, repo := newHistoryStore(t)
+
+	id, err := s.CreateItem(ctx, "default", &ItemData{
+		Label:       "token",
+		Secret:      []byte("good-token"),
+		ContentType: "text/plain",
+		Attributes:  map[string]string{"service": "api"},
+	})
+	if err != nil {
+		t.Fatalf("CreateItem: %v", err)
+	}
+	commitEntry(t, s, backend, repo, "default", id)
+
+	if err := s.UpdateItem(ctx, "default", id, &ItemData{
+		Label:      "token",
+		Secret:     []byte("garbage"),
+		Attributes: map[string]string{"service": "api"},
+	}); err != nil {
+		t.Fatalf("UpdateItem: %v", err)
+	}
+	commitEntry(t, s, backend, repo, "default", id)
+
+	revs, err := s.ItemRevisions(ctx, "default", id)
+	if err != nil {
+		t.Fatalf("ItemRevisions: %v", err)
+	}
+	if len(revs) != 2 {
+		t.Fatalf("got %d revisions, want 2: %+v", len(revs), revs)
+	}
+	oldest := revs[1]
+	if oldest.Time.IsZero() || oldest.Message == "" {
+		t.Errorf("revision missing time or message: %+v", oldest)
+	}
+
+	old, err := s.GetItemRevision(ctx, "default", id, oldest.ID)
+	if err != nil {
+		t.Fatalf("GetItemRevision: %v", err)
+	}
+	if string(old.Secret) != "good-token" || old.Attributes["service"] != "api" {
+		t.Errorf("old revision = %q %v, want good-token", old.Secret, old.Attributes)
+	}
+
+	if err := s.RestoreItemRevision(ctx, "default", id, oldest.ID); err != nil {
+		t.Fatalf("RestoreItemRevision: %v", err)
+	}
+	cur, err := s.GetItem(ctx, "default", id)
+	if err != nil {
+		t.Fatalf("GetItem: %v", err)
+	}
+	if string(cur.Secret) != "good-token" {
+		t.Errorf("secret after restore = %q, want good-token", cur.Secret)
+	}
+}
+
+func TestGopassStore_GetItemRevisionRejectsOptions(t *testing.T) {
+	s := newTestGopassStore(newFakeGopassStore())
+	s.decryptGPG = func(context.Context, []byte) ([]byte, error) {
+		t.Fatal("revision read")
+		return nil, nil
+	}
+	for _, rev := range []string{"--help", "HEAD~1", "", "abc"} {
+		if _, err := s.GetItemRevision(context.Background(), "default", "x", rev); err == nil {
+			t.Errorf("revision %q accepted", rev)
+		}
+	}
+}
+
+func TestMultiStore_HistoryUnsupported(t *testing.T) {
+	m := NewMultiStore(newFakeStore("primary"))
+	if _, err := m.ItemRevisions(context.Background(), "default", "x"); err != ErrHistoryUnsupported {
+		t.Errorf("ItemRevisions error = %v, want ErrHistoryUnsupported", err)
+	}
+}
+
+func TestGopassStore_ItemHistoryFollowsMoves(t *testing.T) {
+	ctx := context.Background()
+	s, backend, repo := newHistoryStore(t)
+
+	id, err := s.CreateItem(ctx, "default", &ItemData{Label: "token", Secret: []byte("before-move")})
+	if err != nil {
+		t.Fatalf("CreateItem: %v", err)
+	}
+	commitEntry(t, s, backend, repo, "default", id)
+
+	if _, err := s.MoveItem(ctx, "default", id, "work"); err != nil {
+		t.Fatalf("MoveItem: %v", err)
+	}
+	newFile := s.mapper.ItemPath("work", id) + ".gpg"
+	if err := os.MkdirAll(filepath.Join(repo, filepath.Dir(newFile)), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	for _, args := range [][]string{
+		{"mv", s.mapper.ItemPath("default", id) + ".gpg", newFile},
+		{"commit", "--quiet", "-m", "Move secret"},
+	} {
+		if _, err := gitsync.Git(ctx, repo, args...); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	revs, err := s.ItemRevisions(ctx, "work", id)
+	if err != nil || len(revs) != 2 {
+		t.Fatalf("ItemRevisions = %+v, %v; want 2 revisions", revs, err)
+	}
+	old, err := s.GetItemRevision(ctx, "work", id, revs[1].ID)
+	if err != nil {
+		t.Fatalf("GetItemRevision before the move: %v", err)
+	}
+	if string(old.Secret) != "before-move" {
+		t.Errorf("secret before the move = %q", old.Secret)
+	}
+
+	if _, err := s.GetItemRevision(ctx, "work", id, "deadbeef"); !errors.Is(err, ErrUnknownRevision) {
+		t.Errorf("GetItemRevision of a foreign revision: err = %v, want ErrUnknownRevision", err)
+	}
+}
+
+func TestGopassStore_AgeRevisionNeedsGopassCLI(t *testing.T) {
+	ctx := context.Background()
+	s, backend, repo := newHistoryStore(t)
+	gitPath, err := exec.LookPath("git")
+	if err != nil {
+		t.Skip("git not installed")
+	}
+
+	id, err := s.CreateItem(ctx, "default", &ItemData{Label: "token", Secret: []byte("value")})
+	if err != nil {
+		t.Fatalf("CreateItem: %v", err)
+	}
+	name := s.mapper.ItemPath("default", id)
+	file := filepath.Join(repo, name+".age")
+	if err := os.MkdirAll(filepath.Dir(file), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(file, backend.data[name].Bytes(), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	for _, args := range [][]string{{"add", "."}, {"commit", "--quiet", "-m", "Save secret"}} {
+		if _, err := gitsync.Git(ctx, repo, args...); err != nil {
+			t.Fatal(err)
+		}
+	}
+	revs, err := s.ItemRevisions(ctx, "default", id)
+	if err != nil || len(revs) != 1 {
+		t.Fatalf("ItemRevisions = %+v, %v; want 1 revision", revs, err)
+	}
+
+	// A PATH with git but no gopass.
+	bin := t.TempDir()
+	if err := os.Symlink(gitPath, filepath.Join(bin, "git")); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin)
+	if _, err := s.GetItemRevision(ctx, "default", id, revs[0].ID); !errors.Is(err, ErrHistoryUnsupported) {
+		t.Errorf("GetItemRevision without gopass: err = %v, want ErrHistoryUnsupported", err)
+	}
+}
@@ -0,0 +1,304 @@
+package store
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/gopasspw/gopass/pkg/gopass/secrets"
+)
+
+// The attribute index persists metaCache across restarts, so the first search
+// after startup doesn't have to decrypt every entry. It is a JSON document
+// sealed with AES-256-GCM under a random key. The key itself lives in the
+// password store as an ordinary entry (indexKeyName), so it is protected by
+// the same GPG/age recipients as the secrets and costs one decryption to
+// unlock instead of one per entry.
+//
+// Every indexed entry records the size and mtime its file had when it was
+// decrypted. On load only entries whose files still match are trusted; the
+// rest are decrypted again on first use. A git pull or checkout rewrites the
+// files it touches, so their mtimes change and they get re-read as well.
+const (
+	indexKeyName = "_index_key"
+	indexVersion = 1
+)
+
+// fileStamp identifies one version of an entry's encrypted file.
+type fileStamp struct {
+	ModTime int64 `json:"mtime"`
+	Size    int64 `json:"size"`
+}
+
+type indexEntry struct {
+	Stamp fileStamp         `json:"stamp"`
+	Meta  map[string]string `json:"meta"`
+}
+
+type indexDoc struct {
+	Version int                   `json:"version"`
+	Entries map[string]indexEntry `json:"entries"`
+}
+
+// attrIndex is the persistent side of metaCache. stamps and dirty are guarded
+// by GopassStore.cacheMu alongside the cache itself.
+type attrIndex struct {
+	file   string
+	aead   cipher.AEAD
+	stamps map[string]fileStamp
+	dirty  bool
+
+	saveMu sync.Mutex // serializes writers of file
+}
+
+// EnableIndex loads the persistent attribute index from file, creating the
+// index key in the store on first use, and keeps the index up to date from
+// then on. It needs the store directory (see SetDir) to validate entries. A
+// missing or unreadable index file is not an error: the index starts empty.
+// It returns how many entries were loaded.
+func (s *GopassStore) EnableIndex(ctx context.Context, file string) (int, error) {
+	if s.dir == "" {
+		return 0, fmt.Errorf("gopass store %s: directory unknown", s.mapper.prefix)
+	}
+	key, err := s.indexKey(ctx)
+	if err != nil {
+		return 0, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return 0, fmt.Errorf("index key: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return 0, fmt.Errorf("index key: %w", err)
+	}
+	idx := &attrIndex{
+		file:   file,
+		aead:   aead,
+		stamps: make(map[string]fileStamp),
+	}
+
+	doc, err := idx.read()
+	if err != nil {
+		// Treat it like a cold start; the next save replaces the file.
+		doc = &indexDoc{}
+	}
+
+	s.cacheMu.Lock()
+	defer s.cacheMu.Unlock()
+	loaded := 0
+	for p, e := range doc.Entries {
+		if !strings.HasPrefix(p, s.mapper.prefix+"/") || e.Meta == nil {
+			continue
+		}
+		if stamp, ok := s.stampFor(p); ok && stamp == e.Stamp {
+			s.setMetaLocked(p, e.Meta)
+			idx.stamps[p] = stamp
+			loaded++
+		}
+	}
+	// Drop stale entries from the file on the next save.
+	idx.dirty = loaded != len(doc.Entries)
+	s.index = idx
+	return loaded, nil
+}
+
+// indexKey returns the index encryption key, generating and storing one if
+// the store doesn't have it yet. A key entry that exists but can't be
+// decrypted is an error rather than a reason to replace it.
+func (s *GopassStore) indexKey(ctx context.Context) ([]byte, error) {
+	keyPath := path.Join(s.mapper.prefix, indexKeyName)
+	if err := s.ensureListing(ctx); err != nil {
+		return nil, err
+	}
+	if s.isListed(keyPath) {
+		sec, err := s.decrypt(ctx, keyPath)
+		if err != nil {
+			return nil, fmt.Errorf("read index key: %w", err)
+		}
+		key, err := base64.StdEncoding.DecodeString(sec.Password())
+		if err != nil || len(key) != 32 {
+			return nil, fmt.Errorf("corrupt index key %s", keyPath)
+		}
+		return key, nil
+	}
+
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	sec := secrets.NewAKV()
+	sec.SetPassword(base64.StdEncoding.EncodeToString(key))
+	if err := s.store.Set(ctx, keyPath, sec); err != nil {
+		return nil, fmt.Errorf("store index key: %w", err)
+	}
+	s.listAdd(keyPath)
+	s.noteWrite(keyPath)
+	return key, nil
+}
+
+// SaveIndex writes the attribute index if it changed since the last save.
+func (s *GopassStore) SaveIndex() error {
+	s.cacheMu.RLock()
+	idx := s.index
+	s.cacheMu.RUnlock()
+	if idx == nil {
+		return nil
+	}
+
+	idx.saveMu.Lock()
+	defer idx.saveMu.Unlock()
+
+	s.cacheMu.Lock()
+	if !idx.dirty {
+		s.cacheMu.Unlock()
+		return nil
+	}
+	doc := &indexDoc{Version: indexVersion, Entries: make(map[string]indexEntry, len(idx.stamps))}
+	for p, stamp := range idx.stamps {
+		if meta, ok := s.metaCache[p]; ok {
+			doc.Entries[p] = indexEntry{Stamp: stamp, Meta: meta}
+		}
+	}
+	idx.dirty = false
+	s.cacheMu.Unlock()
+
+	if err := idx.write(doc); err != nil {
+		s.cacheMu.Lock()
+		idx.dirty = true
+		s.cacheMu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// saveIndexQuietly persists entries decrypted by a search right away, so they
+// survive a crash. A failure is retried on the next save and reported by
+// Close.
+func (s *GopassStore) saveIndexQuietly() {
+	_ = s.SaveIndex()
+}
+
+// stampFor stats the file behind a store path. gopass entries are stored
+// with the crypto backend's extension, which the path doesn't include.
+func (s *GopassStore) stampFor(p string) (fileStamp, bool) {
+	rel, ok := strings.CutPrefix(p, s.mapper.prefix+"/")
+	if !ok || s.dir == "" {
+		return fileStamp{}, false
+	}
+	base := filepath.Join(s.dir, filepath.FromSlash(rel))
+	for _, ext := range []string{".gpg", ".age"} {
+		if fi, err := os.Stat(base + ext); err == nil && fi.Mode().IsRegular() {
+			return fileStamp{ModTime: fi.ModTime().UnixNano(), Size: fi.Size()}, true
+		}
+	}
+	return fileStamp{}, false
+}
+
+// recordStamp notes which file version a freshly decrypted entry came from.
+// The caller holds cacheMu.
+func (s *GopassStore) recordStamp(p string, stamp fileStamp, ok bool) {
+	if s.index == nil {
+		return
+	}
+	if ok {
+		s.index.stamps[p] = stamp
+	} else {
+		delete(s.index.stamps, p)
+	}
+	s.index.dirty = true
+}
+
+// forgetStamp drops p from the index. The caller holds cacheMu.
+func (s *GopassStore) forgetStamp(p string) {
+	if s.index == nil {
+		return
+	}
+	if _, ok := s.index.stamps[p]; ok {
+		delete(s.index.stamps, p)
+		s.index.dirty = true
+	}
+}
+
+func (idx *attrIndex) read() (*indexDoc, error) {
+	data, err := os.ReadFile(idx.file)
+	if err != nil {
+		return nil, err
+	}
+	n := idx.aead.NonceSize()
+	if len(data) < n {
+		return nil, errors.New("index file truncated")
+	}
+	plain, err := idx.aead.Open(nil, data[:n], data[n:], nil)
+	if err != nil {
+		return nil, fmt.Errorf("decrypt index: %w", err)
+	}
+	var doc indexDoc
+	if err := json.Unmarshal(plain, &doc); err != nil {
+		return nil, fmt.Errorf("parse index: %w", err)
+	}
+	if doc.Version != indexVersion {
+		return nil, fmt.Errorf("unsupported index version %d", doc.Version)
+	}
+	return &doc, nil
+}
+
+// write replaces the index file atomically so a crash never leaves a
+// half-written index behind.
+func (idx *attrIndex) write(doc *indexDoc) error {
+	plain, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, idx.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+	data := idx.aead.Seal(nonce, nonce, plain, nil)
+	if err := writeFileAtomic(idx.file, data); err != nil {
+		return fmt.Errorf("write index: %w", err)
+	}
+	return nil
+}
+
+// writeFileAtomic replaces file with data via a temporary file in the same
+// directory, creating the directory if needed.
+func writeFileAtomic(file string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(file), 0o700); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(file), "."+filepath.Base(file)+"-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), file)
+}
+
+// IndexFile returns the default location of the attribute index for a store
+// directory: one file per directory under the user's cache dir.
+func IndexFile(dir string) (string, error) {
+	cache, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	name := strings.Trim(strings.ReplaceAll(filepath.Clean(dir), string(filepath.Separator), "-"), "-")
+	return filepath.Join(cache, "gopass-secret-service", "index", name+".idx"), nil
+}
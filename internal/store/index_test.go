@@ -0,0 +1,126 @@
+package store
+
+import (
+	"context"
+	"os"
+	"path"
+	"path/filepath"
+	"testing"
+)
+
+// newIndexedStore returns a store over backend whose entries live in dir, with
+// the attribute index in indexFile.
+func newIndexedStore(t *testing.T, backend *fakeGopassStore, dir, indexFile string) *GopassStore {
+	t.Helper()
+	s := newTestGopassStore(backend)
+	s.SetDir(dir)
+	if _, err := s.EnableIndex(context.Background(), indexFile); err != nil {
+		t.Fatalf("EnableIndex: %v", err)
+	}
+	return s
+}
+
+// seedIndexedItems creates items through s and writes the files gopass would
+// have written for them.
+func seedIndexedItems(t *testing.T, s *GopassStore, dir string, services ...string) map[string]string {
+	t.Helper()
+	ids := make(map[string]string)
+	for _, svc := range services {
+		id, err := s.CreateItem(context.Background(), "default", &ItemData{
+			Label:      svc,
+			Secret:     []byte("pw-" + svc),
+			Attributes: map[string]string{"service": svc},
+		})
+		if err != nil {
+			t.Fatalf("CreateItem: %v", err)
+		}
+		writeEntry(t, filepath.Join(dir, "default", id+".gpg"))
+		ids[svc] = id
+	}
+	return ids
+}
+
+func TestGopassStore_IndexAvoidsDecryptionAfterRestart(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	indexFile := filepath.Join(t.TempDir(), "index.idx")
+	backend := newFakeGopassStore()
+
+	first := newIndexedStore(t, backend, dir, indexFile)
+	ids := seedIndexedItems(t, first, dir, "a", "b", "c")
+	if _, err := first.SearchItems(ctx, "default", map[string]string{"service": "a"}); err != nil {
+		t.Fatalf("SearchItems: %v", err)
+	}
+	if err := first.Close(ctx); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	// Change one entry on disk while the daemon is down.
+	changed := filepath.Join(dir, "default", ids["b"]+".gpg")
+	if err := os.WriteFile(changed, []byte("new ciphertext"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	backend.getCount = make(map[string]int)
+	second := newIndexedStore(t, backend, dir, indexFile)
+	results, err := second.SearchItems(ctx, "default", map[string]string{"service": "a"})
+	if err != nil {
+		t.Fatalf("SearchItems: %v", err)
+	}
+	if len(results) != 1 || results[0].ID != ids["a"] {
+		t.Errorf("results = %+v, want item a", results)
+	}
+	for svc, id := range ids {
+		n := backend.getCount[second.mapper.ItemPath("default", id)]
+		want := 0
+		if svc == "b" {
+			want = 1
+		}
+		if n != want {
+			t.Errorf("item %s decrypted %d times, want %d", svc, n, want)
+		}
+	}
+}
+
+func TestGopassStore_IndexIgnoresUnreadableFile(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	indexFile := filepath.Join(t.TempDir(), "index.idx")
+	if err := os.WriteFile(indexFile, []byte("garbage"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	backend := newFakeGopassStore()
+
+	s := newIndexedStore(t, backend, dir, indexFile)
+	ids := seedIndexedItems(t, s, dir, "a")
+	results, err := s.SearchItems(ctx, "default", map[string]string{"service": "a"})
+	if err != nil || len(results) != 1 {
+		t.Fatalf("SearchItems = %v, %v; want one result", results, err)
+	}
+
+	// The search replaced the garbage with a readable index.
+	backend.getCount = make(map[string]int)
+	again := newIndexedStore(t, backend, dir, indexFile)
+	if _, err := again.SearchItems(ctx, "default", nil); err != nil {
+		t.Fatalf("SearchItems: %v", err)
+	}
+	if n := backend.getCount[again.mapper.ItemPath("default", ids["a"])]; n != 0 {
+		t.Errorf("item decrypted %d times after reload, want 0", n)
+	}
+}
+
+func TestGopassStore_IndexKeyNotReplaced(t *testing.T) {
+	dir := t.TempDir()
+	backend := newFakeGopassStore()
+	first := newIndexedStore(t, backend, dir, filepath.Join(t.TempDir(), "index.idx"))
+	keyPath := path.Join(first.mapper.prefix, indexKeyName)
+	key := backend.data[keyPath]
+	if key == nil {
+		t.Fatalf("index key not stored at %s", keyPath)
+	}
+
+	newIndexedStore(t, backend, dir, filepath.Join(t.TempDir(), "index.idx"))
+	if backend.data[keyPath].Password() != key.Password() {
+		t.Errorf("index key replaced on second start")
+	}
+}
@@ -0,0 +1,152 @@
+package store
+
+import (
+	"encoding/binary"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"golang.org/x/sys/unix"
+)
+
+// dirWatchMask selects the inotify events that can change what a gopass entry
+// decrypts to. Plain writes end in IN_CLOSE_WRITE; gopass and git replace
+// files by rename, which shows up as IN_MOVED_TO/IN_MOVED_FROM.
+const dirWatchMask = unix.IN_CLOSE_WRITE | unix.IN_CREATE | unix.IN_DELETE |
+	unix.IN_MOVED_FROM | unix.IN_MOVED_TO | unix.IN_DELETE_SELF | unix.IN_ONLYDIR
+
+// watchEvent is a single filesystem change reported by dirWatcher. overflow
+// means the kernel dropped events and the whole tree must be rescanned.
+type watchEvent struct {
+	path     string
+	overflow bool
+}
+
+// dirWatcher watches a directory tree with inotify. inotify is not recursive,
+// so every subdirectory gets its own watch, added as directories appear.
+// Git's own bookkeeping under .git is never watched.
+type dirWatcher struct {
+	fd   int
+	file *os.File
+
+	mu   sync.Mutex
+	dirs map[int]string // watch descriptor -> directory
+
+	events chan watchEvent
+}
+
+func newDirWatcher(root string) (*dirWatcher, error) {
+	fd, err := unix.InotifyInit1(unix.IN_CLOEXEC | unix.IN_NONBLOCK)
+	if err != nil {
+		return nil, os.NewSyscallError("inotify_init1", err)
+	}
+	// A non-blocking fd wrapped in an *os.File goes through the runtime
+	// poller, so Close reliably wakes up a pending Read.
+	w := &dirWatcher{
+		fd:     fd,
+		file:   os.NewFile(uintptr(fd), "inotify"),
+		dirs:   make(map[int]string),
+		events: make(chan watchEvent, 256),
+	}
+	if err := w.addTree(root, nil); err != nil {
+		w.file.Close()
+		return nil, err
+	}
+	go w.run()
+	return w, nil
+}
+
+// addTree watches dir and every directory beneath it. Files found along the
+// way are passed to found, which lets a directory that was moved into the tree
+// report its contents (they generate no events of their own).
+func (w *dirWatcher) addTree(dir string, found func(string)) error {
+	return filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// Vanished between the event and the walk; nothing to watch.
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if !d.IsDir() {
+			if found != nil {
+				found(p)
+			}
+			return nil
+		}
+		if d.Name() == ".git" && p != dir {
+			return filepath.SkipDir
+		}
+		wd, err := unix.InotifyAddWatch(w.fd, p, dirWatchMask)
+		if err != nil {
+			return os.NewSyscallError("inotify_add_watch", err)
+		}
+		w.mu.Lock()
+		w.dirs[wd] = p
+		w.mu.Unlock()
+		return nil
+	})
+}
+
+func (w *dirWatcher) run() {
+	defer close(w.events)
+
+	buf := make([]byte, 64*(unix.SizeofInotifyEvent+unix.NAME_MAX+1))
+	for {
+		n, err := w.file.Read(buf)
+		if err != nil {
+			return
+		}
+		for off := 0; off+unix.SizeofInotifyEvent <= n; {
+			// struct inotify_event { int wd; u32 mask, cookie, len; char name[]; }
+			wd := int(int32(binary.NativeEndian.Uint32(buf[off:])))
+			mask := binary.NativeEndian.Uint32(buf[off+4:])
+			nameLen := int(binary.NativeEndian.Uint32(buf[off+12:]))
+			nameStart := off + unix.SizeofInotifyEvent
+			name := strings.TrimRight(string(buf[nameStart:nameStart+nameLen]), "\x00")
+			off = nameStart + nameLen
+
+			w.handle(wd, mask, name)
+		}
+	}
+}
+
+func (w *dirWatcher) handle(wd int, mask uint32, name string) {
+	if mask&unix.IN_Q_OVERFLOW != 0 {
+		w.events <- watchEvent{overflow: true}
+		return
+	}
+
+	w.mu.Lock()
+	dir, ok := w.dirs[wd]
+	if mask&unix.IN_IGNORED != 0 {
+		delete(w.dirs, wd)
+	}
+	w.mu.Unlock()
+	if !ok || name == "" {
+		return
+	}
+
+	p := filepath.Join(dir, name)
+	if mask&unix.IN_ISDIR != 0 {
+		if name == ".git" {
+			return
+		}
+		if mask&(unix.IN_CREATE|unix.IN_MOVED_TO) != 0 {
+			if err := w.addTree(p, func(f string) { w.events <- watchEvent{path: f} }); err != nil {
+				// Without a watch we'd silently miss changes below p.
+				w.events <- watchEvent{overflow: true}
+				return
+			}
+		}
+	}
+	w.events <- watchEvent{path: p}
+}
+
+// Close stops the watcher; the events channel is closed once run returns.
+func (w *dirWatcher) Close() error {
+	return w.file.Close()
+}
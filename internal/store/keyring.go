@@ -1,5 +1,6 @@
-// Package store, keyring.go: in-memory session storage backed by the Linux
-// kernel keyring.
+// Package store, keyring.go: in-memory volatile collections backed by the
+// Linux kernel keyring. Each collection is a child keyring of the daemon's
+// root keyring, so deleting one unlinks all of its items at once.
 //
 // All kernel keyring syscalls run on a single dedicated OS thread, pinned via
 // runtime.LockOSThread. The reason is non-obvious enough to spell out: in
@@ -16,7 +17,7 @@
 //
 // Quota note: a non-root user has a default of 200 keys / 20 000 bytes per UID
 // (see /proc/sys/kernel/keys/{maxkeys,maxbytes}). OIDC tokens are ~1–5 KB, so
-// the budget covers a handful of session items per user. Exceeding the quota
+// the budget covers a handful of volatile items per user. Exceeding the quota
 // surfaces as EDQUOT on Create/Update; callers can fall back to the primary
 // store.
 package store
@@ -26,6 +27,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"math"
 	"runtime"
 	"sort"
 	"sync"
@@ -33,6 +35,8 @@ import (
 
 	"github.com/google/uuid"
 	"golang.org/x/sys/unix"
+
+	"github.com/nikicat/gopass-secret-service/internal/secmem"
 )
 
 // SessionCollectionName is the internal name of the volatile, in-memory
@@ -42,6 +46,8 @@ const SessionCollectionName = "session"
 const (
 	keyringKeyType         = "user"
 	keyringRootDescription = "gopass-secret-service-session"
+	// Collection keyrings are described as keyringCollPrefix + name.
+	keyringCollPrefix = "gopass-secret-service:"
 	// Kernel per-key payload limit. KEYCTL_READ on a buffer smaller than the
 	// actual payload returns the real size without copying; we use this as
 	// the upper bound so a single read suffices.
@@ -49,17 +55,21 @@ const (
 )
 
 // KeyringStore implements Store backed by a per-daemon child of the Linux
-// process keyring. It serves a single collection (SessionCollectionName);
-// callers that want a multi-collection facade should wrap it with MultiStore.
+// process keyring. It always serves SessionCollectionName; further
+// collections are created and deleted at runtime. Callers that want a facade
+// over durable collections too should wrap it with MultiStore.
 //
 // All state mutations happen on a single dedicated worker goroutine — see the
 // package comment for why. Methods marshal their work onto that worker via
 // the requests channel and wait for the response.
 type KeyringStore struct {
-	ringID    int
-	items     map[string]int // managed only by the worker goroutine
-	collLabel string
-	collTime  time.Time
+	ringID int
+	colls  map[string]*keyringColl // managed only by the worker goroutine
+
+	// ttls give new and updated items a lifetime (see SetTTLRules).
+	ttls TTLRules
+	// expiryChanged wakes WatchExpired when an item's expiry was set.
+	expiryChanged chan struct{}
 
 	requests   chan keyringJob
 	closed     chan struct{} // signals worker to exit
@@ -79,15 +89,41 @@ type keyringResult struct {
 	err   error
 }
 
+// keyringColl is one volatile collection: a child keyring of the root, the
+// kernel key IDs of its items by item ID, and when the items with a TTL
+// expire.
+type keyringColl struct {
+	ringID   int
+	label    string
+	created  time.Time
+	modified time.Time
+	items    map[string]int
+	expires  map[string]time.Time
+}
+
+// live reports whether the item exists and hasn't expired. The kernel may
+// not have reaped an expired key yet, and WatchExpired may not have reported
+// it, but it's gone as far as callers are concerned.
+func (c *keyringColl) live(id string, now time.Time) bool {
+	if _, ok := c.items[id]; !ok {
+		return false
+	}
+	exp, ok := c.expires[id]
+	return !ok || now.Before(exp)
+}
+
 // NewKeyringStore starts the worker goroutine, has it install the daemon's
-// process keyring and create the child keyring under it, and returns the
-// store. If any of that fails, the worker exits and the error propagates.
+// process keyring, create the root keyring under it and the session
+// collection under that, and returns the store. If any of that fails, the
+// worker exits and the error propagates.
 func NewKeyringStore() (*KeyringStore, error) {
 	s := &KeyringStore{
-		items:      make(map[string]int),
+		colls:      make(map[string]*keyringColl),
 		requests:   make(chan keyringJob),
 		closed:     make(chan struct{}),
 		workerDone: make(chan struct{}),
+
+		expiryChanged: make(chan struct{}, 1),
 	}
 	initDone := make(chan error, 1)
 	go s.worker(initDone)
@@ -117,19 +153,25 @@ func (s *KeyringStore) worker(initDone chan<- error) {
 		return
 	}
 	s.ringID = ringID
-	s.collLabel = "Session"
-	s.collTime = time.Now()
+	if _, err := s.addColl(SessionCollectionName, "Session"); err != nil {
+		initDone <- err
+		return
+	}
 	close(initDone)
 
 	for {
 		select {
 		case <-s.closed:
-			// Best-effort: clear the child keyring so its contents are
-			// reclaimed promptly even if the daemon keeps running.
+			// Best-effort: clear the collection keyrings and the root so
+			// their contents are reclaimed promptly even if the daemon
+			// keeps running.
+			for _, c := range s.colls {
+				_, _ = unix.KeyctlInt(unix.KEYCTL_CLEAR, c.ringID, 0, 0, 0)
+			}
 			if s.ringID != 0 {
 				_, _ = unix.KeyctlInt(unix.KEYCTL_CLEAR, s.ringID, 0, 0, 0)
 			}
-			s.items = map[string]int{}
+			s.colls = map[string]*keyringColl{}
 			return
 		case job := <-s.requests:
 			value, err := job.fn()
@@ -164,6 +206,7 @@ type itemPayload struct {
 	Attributes  map[string]string `json:"attributes,omitempty"`
 	Created     time.Time         `json:"created"`
 	Modified    time.Time         `json:"modified"`
+	Expires     time.Time         `json:"expires,omitzero"`
 }
 
 func encodeItem(item *ItemData) ([]byte, error) {
@@ -174,6 +217,7 @@ func encodeItem(item *ItemData) ([]byte, error) {
 		Attributes:  item.Attributes,
 		Created:     item.Created,
 		Modified:    item.Modified,
+		Expires:     item.Expires,
 	})
 }
 
@@ -190,43 +234,89 @@ func decodeItem(id string, payload []byte) (*ItemData, error) {
 		Attributes:  p.Attributes,
 		Created:     p.Created,
 		Modified:    p.Modified,
+		Expires:     p.Expires,
 	}, nil
 }
 
-// readKeyPayload runs on the worker thread. Caller must be on the worker.
-func readKeyPayload(keyID int) ([]byte, error) {
-	buf := make([]byte, keyringMaxPayload)
-	n, err := unix.KeyctlBuffer(unix.KEYCTL_READ, keyID, buf, len(buf))
+// readKeyPayload runs on the worker thread. Caller must be on the worker,
+// and wipes the payload, which is in locked memory, when done.
+func readKeyPayload(keyID int) (*secmem.Buffer, error) {
+	buf := secmem.New(keyringMaxPayload)
+	defer buf.Wipe()
+	n, err := unix.KeyctlBuffer(unix.KEYCTL_READ, keyID, buf.Bytes(), keyringMaxPayload)
 	if err != nil {
 		return nil, fmt.Errorf("read key %d: %w", keyID, err)
 	}
-	if n > len(buf) {
-		return nil, fmt.Errorf("key %d payload (%d bytes) exceeds %d-byte cap", keyID, n, len(buf))
+	if n > keyringMaxPayload {
+		return nil, fmt.Errorf("key %d payload (%d bytes) exceeds %d-byte cap", keyID, n, keyringMaxPayload)
 	}
-	return buf[:n], nil
+	return secmem.Copy(buf.Bytes()[:n]), nil
 }
 
-func (s *KeyringStore) checkColl(name string) error {
-	if name != SessionCollectionName {
-		return fmt.Errorf("keyring store: unknown collection %q", name)
+// addColl creates the child keyring of a new collection. Caller must be on
+// the worker.
+func (s *KeyringStore) addColl(name, label string) (*keyringColl, error) {
+	ringID, err := unix.AddKey("keyring", keyringCollPrefix+name, nil, s.ringID)
+	if err != nil {
+		return nil, fmt.Errorf("create keyring for collection %s: %w", name, err)
 	}
-	return nil
+	now := time.Now()
+	c := &keyringColl{
+		ringID:   ringID,
+		label:    label,
+		created:  now,
+		modified: now,
+		items:    make(map[string]int),
+		expires:  make(map[string]time.Time),
+	}
+	s.colls[name] = c
+	return c, nil
 }
 
-func (s *KeyringStore) Collections(ctx context.Context) ([]string, error) {
-	return []string{SessionCollectionName}, nil
+// doColl runs fn on the worker with the named collection, failing if there
+// is no such collection.
+func (s *KeyringStore) doColl(name string, fn func(c *keyringColl) (any, error)) (any, error) {
+	return s.do(func() (any, error) {
+		c, ok := s.colls[name]
+		if !ok {
+			return nil, fmt.Errorf("keyring store: unknown collection %q", name)
+		}
+		return fn(c)
+	})
 }
 
-func (s *KeyringStore) GetCollection(ctx context.Context, name string) (*CollectionData, error) {
-	if err := s.checkColl(name); err != nil {
+// HasCollection reports whether name is one of the store's collections. It
+// lets a MultiStore route collections created at runtime to the keyring.
+func (s *KeyringStore) HasCollection(name string) bool {
+	v, err := s.do(func() (any, error) {
+		_, ok := s.colls[name]
+		return ok, nil
+	})
+	return err == nil && v.(bool)
+}
+
+func (s *KeyringStore) Collections(ctx context.Context) ([]string, error) {
+	v, err := s.do(func() (any, error) {
+		names := make([]string, 0, len(s.colls))
+		for name := range s.colls {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return names, nil
+	})
+	if err != nil {
 		return nil, err
 	}
-	v, err := s.do(func() (any, error) {
+	return v.([]string), nil
+}
+
+func (s *KeyringStore) GetCollection(ctx context.Context, name string) (*CollectionData, error) {
+	v, err := s.doColl(name, func(c *keyringColl) (any, error) {
 		return &CollectionData{
-			Name:     SessionCollectionName,
-			Label:    s.collLabel,
-			Created:  s.collTime,
-			Modified: s.collTime,
+			Name:     name,
+			Label:    c.label,
+			Created:  c.created,
+			Modified: c.modified,
 			Locked:   false,
 		}, nil
 	})
@@ -236,46 +326,106 @@ func (s *KeyringStore) GetCollection(ctx context.Context, name string) (*Collect
 	return v.(*CollectionData), nil
 }
 
-// CreateCollection is a label-only operation here: the session keyring is
-// created in NewKeyringStore and can't be re-created. Callers that pass
+// CreateCollection creates the collection's child keyring. For an existing
+// collection it only updates the label, so callers that pass
 // SessionCollectionName at startup get their label honoured.
 func (s *KeyringStore) CreateCollection(ctx context.Context, name, label string) error {
-	if err := s.checkColl(name); err != nil {
-		return err
-	}
 	_, err := s.do(func() (any, error) {
-		if label != "" {
-			s.collLabel = label
+		if c, ok := s.colls[name]; ok {
+			if label != "" {
+				c.label = label
+			}
+			return nil, nil
+		}
+		if label == "" {
+			label = name
 		}
+		return s.addColl(name, label)
+	})
+	return err
+}
+
+// RenameCollection moves every item into a new child keyring named after to,
+// since keyring descriptions can't change, and drops the old one. The session
+// collection can't be renamed.
+func (s *KeyringStore) RenameCollection(ctx context.Context, from, to string) error {
+	if from == SessionCollectionName || to == SessionCollectionName {
+		return fmt.Errorf("session collection cannot be renamed")
+	}
+	_, err := s.doColl(from, func(c *keyringColl) (any, error) {
+		if _, ok := s.colls[to]; ok {
+			return nil, fmt.Errorf("collection already exists: %s", to)
+		}
+		n, err := s.addColl(to, c.label)
+		if err != nil {
+			return nil, err
+		}
+		n.created = c.created
+		for id := range c.items {
+			if err := moveKey(c, n, id); err != nil {
+				s.undoRename(c, n, to)
+				return nil, err
+			}
+		}
+		if _, err := unix.KeyctlInt(unix.KEYCTL_UNLINK, c.ringID, s.ringID, 0, 0); err != nil {
+			return nil, fmt.Errorf("unlink keyring %d: %w", c.ringID, err)
+		}
+		delete(s.colls, from)
 		return nil, nil
 	})
 	return err
 }
 
+// undoRename returns the items of a failed RenameCollection from n to c and
+// removes n, which was created for the rename. Caller must be on the worker.
+func (s *KeyringStore) undoRename(c, n *keyringColl, to string) {
+	for id := range n.items {
+		// Linking a key into a keyring it was just unlinked from only
+		// fails if the key is gone, and then there's nothing to restore.
+		_ = moveKey(n, c, id)
+	}
+	_, _ = unix.KeyctlInt(unix.KEYCTL_CLEAR, n.ringID, 0, 0, 0)
+	_, _ = unix.KeyctlInt(unix.KEYCTL_UNLINK, n.ringID, s.ringID, 0, 0)
+	delete(s.colls, to)
+}
+
+// DeleteCollection clears the collection's keyring and unlinks it from the
+// root, which releases every item in it. The session collection can't be
+// deleted.
 func (s *KeyringStore) DeleteCollection(ctx context.Context, name string) error {
-	return fmt.Errorf("session collection cannot be deleted")
+	if name == SessionCollectionName {
+		return fmt.Errorf("session collection cannot be deleted")
+	}
+	_, err := s.doColl(name, func(c *keyringColl) (any, error) {
+		if _, err := unix.KeyctlInt(unix.KEYCTL_CLEAR, c.ringID, 0, 0, 0); err != nil {
+			return nil, fmt.Errorf("clear keyring %d: %w", c.ringID, err)
+		}
+		if _, err := unix.KeyctlInt(unix.KEYCTL_UNLINK, c.ringID, s.ringID, 0, 0); err != nil {
+			return nil, fmt.Errorf("unlink keyring %d: %w", c.ringID, err)
+		}
+		delete(s.colls, name)
+		return nil, nil
+	})
+	return err
 }
 
 func (s *KeyringStore) SetCollectionLabel(ctx context.Context, name, label string) error {
-	if err := s.checkColl(name); err != nil {
-		return err
-	}
-	_, err := s.do(func() (any, error) {
-		s.collLabel = label
-		s.collTime = time.Now()
+	_, err := s.doColl(name, func(c *keyringColl) (any, error) {
+		c.label = label
+		c.modified = time.Now()
 		return nil, nil
 	})
 	return err
 }
 
 func (s *KeyringStore) Items(ctx context.Context, collection string) ([]string, error) {
-	if err := s.checkColl(collection); err != nil {
-		return nil, err
-	}
-	v, err := s.do(func() (any, error) {
-		ids := make([]string, 0, len(s.items))
-		for id := range s.items {
-			ids = append(ids, id)
+	v, err := s.doColl(collection, func(c *keyringColl) (any, error) {
+		now := time.Now()
+		ids := make([]string, 0, len(c.items))
+		for id := range c.items {
+			if c.live(id, now) {
+				ids = append(ids, id)
+			}
 		}
 		sort.Strings(ids)
 		return ids, nil
@@ -287,19 +437,17 @@ func (s *KeyringStore) Items(ctx context.Context, collection string) ([]string,
 }
 
 func (s *KeyringStore) GetItem(ctx context.Context, collection, id string) (*ItemData, error) {
-	if err := s.checkColl(collection); err != nil {
-		return nil, err
-	}
-	v, err := s.do(func() (any, error) {
-		keyID, ok := s.items[id]
-		if !ok {
+	v, err := s.doColl(collection, func(c *keyringColl) (any, error) {
+		keyID := c.items[id]
+		if !c.live(id, time.Now()) {
 			return nil, fmt.Errorf("item not found: %s", id)
 		}
 		payload, err := readKeyPayload(keyID)
 		if err != nil {
 			return nil, err
 		}
-		return decodeItem(id, payload)
+		defer payload.Wipe()
+		return decodeItem(id, payload.Bytes())
 	})
 	if err != nil {
 		return nil, err
@@ -311,9 +459,6 @@ func (s *KeyringStore) GetItem(ctx context.Context, collection, id string) (*Ite
 // key. If the ID already maps to an existing key, AddKey updates the payload
 // in place (kernel-level same-description coalescing).
 func (s *KeyringStore) CreateItem(ctx context.Context, collection string, item *ItemData) (string, error) {
-	if err := s.checkColl(collection); err != nil {
-		return "", err
-	}
 	if item.ID == "" {
 		rawID := uuid.New()
 		item.ID = fmt.Sprintf("i%x", rawID[:])
@@ -322,20 +467,29 @@ func (s *KeyringStore) CreateItem(ctx context.Context, collection string, item *
 	if item.Created.IsZero() {
 		item.Created = now
 	}
-	item.Modified = now
+	if item.Modified.IsZero() {
+		item.Modified = now
+	}
 	if item.ContentType == "" {
 		item.ContentType = "text/plain"
 	}
+	if err := applyTTL(item, s.ttls); err != nil {
+		return "", err
+	}
 	payload, err := encodeItem(item)
 	if err != nil {
 		return "", err
 	}
-	v, err := s.do(func() (any, error) {
-		keyID, err := unix.AddKey(keyringKeyType, item.ID, payload, s.ringID)
+	defer secmem.Wipe(payload)
+	v, err := s.doColl(collection, func(c *keyringColl) (any, error) {
+		keyID, err := unix.AddKey(keyringKeyType, item.ID, payload, c.ringID)
 		if err != nil {
 			return "", fmt.Errorf("add key: %w", err)
 		}
-		s.items[item.ID] = keyID
+		c.items[item.ID] = keyID
+		if err := s.setExpiry(c, item.ID, item.Expires); err != nil {
+			return "", err
+		}
 		return item.ID, nil
 	})
 	if err != nil {
@@ -348,18 +502,20 @@ func (s *KeyringStore) CreateItem(ctx context.Context, collection string, item *
 // AddKey with the same description+type+ringid coalesces onto the existing
 // key, so the key ID is stable across updates.
 func (s *KeyringStore) UpdateItem(ctx context.Context, collection, id string, item *ItemData) error {
-	if err := s.checkColl(collection); err != nil {
+	if err := applyTTL(item, s.ttls); err != nil {
 		return err
 	}
-	_, err := s.do(func() (any, error) {
-		existingID, ok := s.items[id]
-		if !ok {
+	_, err := s.doColl(collection, func(c *keyringColl) (any, error) {
+		existingID := c.items[id]
+		if !c.live(id, time.Now()) {
 			return nil, fmt.Errorf("item not found: %s", id)
 		}
 		if existingPayload, err := readKeyPayload(existingID); err == nil {
-			if prev, err := decodeItem(id, existingPayload); err == nil {
+			if prev, err := decodeItem(id, existingPayload.Bytes()); err == nil {
 				item.Created = prev.Created
+				secmem.Wipe(prev.Secret)
 			}
+			existingPayload.Wipe()
 		}
 		item.ID = id
 		item.Modified = time.Now()
@@ -370,54 +526,211 @@ func (s *KeyringStore) UpdateItem(ctx context.Context, collection, id string, it
 		if err != nil {
 			return nil, err
 		}
-		keyID, err := unix.AddKey(keyringKeyType, id, payload, s.ringID)
+		defer secmem.Wipe(payload)
+		keyID, err := unix.AddKey(keyringKeyType, id, payload, c.ringID)
 		if err != nil {
 			return nil, fmt.Errorf("update key: %w", err)
 		}
-		s.items[id] = keyID
-		return nil, nil
+		c.items[id] = keyID
+		return nil, s.setExpiry(c, id, item.Expires)
 	})
 	return err
 }
 
 func (s *KeyringStore) DeleteItem(ctx context.Context, collection, id string) error {
-	if err := s.checkColl(collection); err != nil {
-		return err
-	}
-	_, err := s.do(func() (any, error) {
-		keyID, ok := s.items[id]
-		if !ok {
+	_, err := s.doColl(collection, func(c *keyringColl) (any, error) {
+		keyID := c.items[id]
+		if !c.live(id, time.Now()) {
 			return nil, fmt.Errorf("item not found: %s", id)
 		}
-		if _, err := unix.KeyctlInt(unix.KEYCTL_UNLINK, keyID, s.ringID, 0, 0); err != nil {
+		if _, err := unix.KeyctlInt(unix.KEYCTL_UNLINK, keyID, c.ringID, 0, 0); err != nil {
 			return nil, fmt.Errorf("unlink key %d: %w", keyID, err)
 		}
-		delete(s.items, id)
+		delete(c.items, id)
+		delete(c.expires, id)
 		return nil, nil
 	})
 	return err
 }
 
-func (s *KeyringStore) SearchItems(ctx context.Context, collection string, attributes map[string]string) ([]*ItemData, error) {
-	if err := s.checkColl(collection); err != nil {
-		return nil, err
+// MoveItem links the item's key into the other collection's keyring and
+// unlinks it from its own, so the payload and any timeout carry over. The
+// target collection must exist.
+func (s *KeyringStore) MoveItem(ctx context.Context, from, id, to string) (string, error) {
+	_, err := s.doColl(from, func(c *keyringColl) (any, error) {
+		if !c.live(id, time.Now()) {
+			return nil, fmt.Errorf("item not found: %s", id)
+		}
+		if from == to {
+			return nil, nil
+		}
+		dst, ok := s.colls[to]
+		if !ok {
+			var err error
+			if dst, err = s.addColl(to, to); err != nil {
+				return nil, err
+			}
+		}
+		if _, ok := dst.items[id]; ok {
+			return nil, fmt.Errorf("item already exists: %s/%s", to, id)
+		}
+		return nil, moveKey(c, dst, id)
+	})
+	if err != nil {
+		return "", err
 	}
-	v, err := s.do(func() (any, error) {
-		var matches []*ItemData
-		for id, keyID := range s.items {
-			payload, err := readKeyPayload(keyID)
-			if err != nil {
+	return id, nil
+}
+
+// moveKey moves an item's key from one collection's keyring to another's.
+// If it fails the key stays where it was. Caller must be on the worker.
+func moveKey(from, to *keyringColl, id string) error {
+	keyID := from.items[id]
+	if _, err := unix.KeyctlInt(unix.KEYCTL_LINK, keyID, to.ringID, 0, 0); err != nil {
+		return fmt.Errorf("link key %d: %w", keyID, err)
+	}
+	if _, err := unix.KeyctlInt(unix.KEYCTL_UNLINK, keyID, from.ringID, 0, 0); err != nil {
+		_, _ = unix.KeyctlInt(unix.KEYCTL_UNLINK, keyID, to.ringID, 0, 0)
+		return fmt.Errorf("unlink key %d: %w", keyID, err)
+	}
+	to.items[id] = keyID
+	if exp, ok := from.expires[id]; ok {
+		to.expires[id] = exp
+	}
+	delete(from.items, id)
+	delete(from.expires, id)
+	return nil
+}
+
+// SetTTLRules gives new and updated items a lifetime by their schema, unless
+// they carry TTLAttribute. It must be set before the store is used.
+func (s *KeyringStore) SetTTLRules(r TTLRules) {
+	s.ttls = r
+}
+
+// setExpiry has the kernel destroy the item's key at expires, or keep it for
+// good when expires is zero. Caller must be on the worker.
+func (s *KeyringStore) setExpiry(c *keyringColl, id string, expires time.Time) error {
+	secs := 0
+	if !expires.IsZero() {
+		// Timeouts have a resolution of seconds and 0 means none, so an
+		// item that is already due gets the shortest one.
+		secs = max(1, int(math.Ceil(time.Until(expires).Seconds())))
+	}
+	if _, err := unix.KeyctlInt(unix.KEYCTL_SET_TIMEOUT, c.items[id], secs, 0, 0); err != nil {
+		return fmt.Errorf("set key timeout: %w", err)
+	}
+	if expires.IsZero() {
+		delete(c.expires, id)
+		return nil
+	}
+	c.expires[id] = expires
+	select {
+	case s.expiryChanged <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// reap drops expired items and returns them as removals, along with when
+// the next item expires (zero if none will). Caller must be on the worker.
+func (s *KeyringStore) reap() ([]Change, time.Time) {
+	var changes []Change
+	var next time.Time
+	now := time.Now()
+	for name, c := range s.colls {
+		for id, exp := range c.expires {
+			if now.Before(exp) {
+				if next.IsZero() || exp.Before(next) {
+					next = exp
+				}
 				continue
 			}
-			item, err := decodeItem(id, payload)
+			// The kernel may have unlinked the key already.
+			_, _ = unix.KeyctlInt(unix.KEYCTL_UNLINK, c.items[id], c.ringID, 0, 0)
+			delete(c.items, id)
+			delete(c.expires, id)
+			changes = append(changes, Change{Collection: name, ItemID: id, Removed: true})
+		}
+	}
+	return changes, next
+}
+
+// WatchExpired reports items as removed when their TTL runs out, until ctx
+// is cancelled or the store is closed. fn is called from a single
+// goroutine, one batch at a time. The kernel destroys the keys by itself;
+// this is how callers find out.
+func (s *KeyringStore) WatchExpired(ctx context.Context, fn func([]Change)) {
+	type reaped struct {
+		changes []Change
+		next    time.Time
+	}
+	go func() {
+		for {
+			v, err := s.do(func() (any, error) {
+				changes, next := s.reap()
+				return reaped{changes, next}, nil
+			})
 			if err != nil {
-				continue
+				return
+			}
+			r := v.(reaped)
+			if len(r.changes) > 0 {
+				fn(r.changes)
+			}
+			var wake <-chan time.Time
+			var timer *time.Timer
+			if !r.next.IsZero() {
+				timer = time.NewTimer(time.Until(r.next))
+				wake = timer.C
+			}
+			select {
+			case <-ctx.Done():
+			case <-s.closed:
+			case <-s.expiryChanged:
+			case <-wake:
 			}
-			if matchesAttributes(item, attributes) {
-				matches = append(matches, item)
+			if timer != nil {
+				timer.Stop()
 			}
+			if ctx.Err() != nil {
+				return
+			}
+		}
+	}()
+}
+
+// search returns the items of c matching attributes. Caller must be on the
+// worker.
+func (c *keyringColl) search(attributes map[string]string) []*ItemData {
+	var matches []*ItemData
+	now := time.Now()
+	for id, keyID := range c.items {
+		if !c.live(id, now) {
+			continue
 		}
-		return matches, nil
+		payload, err := readKeyPayload(keyID)
+		if err != nil {
+			continue
+		}
+		item, err := decodeItem(id, payload.Bytes())
+		payload.Wipe()
+		if err != nil {
+			continue
+		}
+		// Searches don't return secrets.
+		secmem.Wipe(item.Secret)
+		item.Secret = nil
+		if matchesAttributes(item, attributes) {
+			matches = append(matches, item)
+		}
+	}
+	return matches
+}
+
+func (s *KeyringStore) SearchItems(ctx context.Context, collection string, attributes map[string]string) ([]*ItemData, error) {
+	v, err := s.doColl(collection, func(c *keyringColl) (any, error) {
+		return c.search(attributes), nil
 	})
 	if err != nil {
 		return nil, err
@@ -426,24 +739,31 @@ func (s *KeyringStore) SearchItems(ctx context.Context, collection string, attri
 }
 
 func (s *KeyringStore) SearchAllItems(ctx context.Context, attributes map[string]string) (map[string][]*ItemData, error) {
-	matches, err := s.SearchItems(ctx, SessionCollectionName, attributes)
+	v, err := s.do(func() (any, error) {
+		out := map[string][]*ItemData{}
+		for name, c := range s.colls {
+			if matches := c.search(attributes); len(matches) > 0 {
+				out[name] = matches
+			}
+		}
+		return out, nil
+	})
 	if err != nil {
 		return nil, err
 	}
-	if len(matches) == 0 {
-		return map[string][]*ItemData{}, nil
-	}
-	return map[string][]*ItemData{SessionCollectionName: matches}, nil
+	return v.(map[string][]*ItemData), nil
 }
 
-// LockCollection / UnlockCollection are no-ops: the session keyring is always
+// LockCollection / UnlockCollection are no-ops: volatile keyrings are always
 // unlocked (the kernel handles isolation by process).
 func (s *KeyringStore) LockCollection(ctx context.Context, name string) error {
-	return s.checkColl(name)
+	_, err := s.doColl(name, func(*keyringColl) (any, error) { return nil, nil })
+	return err
 }
 
 func (s *KeyringStore) UnlockCollection(ctx context.Context, name string) error {
-	return s.checkColl(name)
+	_, err := s.doColl(name, func(*keyringColl) (any, error) { return nil, nil })
+	return err
 }
 
 // GetAlias / SetAlias should be routed to the primary store by the dispatcher;
@@ -457,7 +777,7 @@ func (s *KeyringStore) SetAlias(ctx context.Context, alias, collection string) e
 	return fmt.Errorf("keyring store does not support SetAlias")
 }
 
-// Close signals the worker to clear the child keyring and exit, then blocks
+// Close signals the worker to clear the keyrings and exit, then blocks
 // until the worker is fully done. Safe to call multiple times.
 func (s *KeyringStore) Close(ctx context.Context) error {
 	s.closeOnce.Do(func() { close(s.closed) })
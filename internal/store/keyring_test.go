@@ -5,6 +5,9 @@ import (
 	"runtime"
 	"sync"
 	"testing"
+	"time"
+
+	"golang.org/x/sys/unix"
 )
 
 func newTestKeyringStore(t *testing.T) *KeyringStore {
@@ -176,8 +179,146 @@ func TestKeyringStore_CloseClearsItems(t *testing.T) {
 	if err := s.Close(ctx); err != nil {
 		t.Fatalf("Close: %v", err)
 	}
-	if len(s.items) != 0 {
-		t.Errorf("items map should be empty after Close, got %d", len(s.items))
+	if len(s.colls) != 0 {
+		t.Errorf("collections map should be empty after Close, got %d", len(s.colls))
+	}
+}
+
+func TestKeyringStore_VolatileCollections(t *testing.T) {
+	s := newTestKeyringStore(t)
+	ctx := context.Background()
+
+	if err := s.CreateCollection(ctx, "oidc", "OIDC tokens"); err != nil {
+		t.Fatalf("CreateCollection: %v", err)
+	}
+	if !s.HasCollection("oidc") {
+		t.Error("HasCollection(oidc) = false after CreateCollection")
+	}
+	coll, err := s.GetCollection(ctx, "oidc")
+	if err != nil || coll.Label != "OIDC tokens" {
+		t.Errorf("GetCollection = %+v, %v", coll, err)
+	}
+	id, err := s.CreateItem(ctx, "oidc", &ItemData{Secret: []byte("token"), Attributes: map[string]string{"service": "kubelogin"}})
+	if err != nil {
+		t.Fatalf("CreateItem: %v", err)
+	}
+	if _, err := s.CreateItem(ctx, SessionCollectionName, &ItemData{Secret: []byte("other"), Attributes: map[string]string{"service": "kubelogin"}}); err != nil {
+		t.Fatalf("CreateItem in session: %v", err)
+	}
+
+	// Collections are separate keyrings, so the same item ID doesn't clash.
+	if _, err := s.GetItem(ctx, SessionCollectionName, id); err == nil {
+		t.Error("item of oidc is visible in session")
+	}
+	byColl, err := s.SearchAllItems(ctx, map[string]string{"service": "kubelogin"})
+	if err != nil {
+		t.Fatalf("SearchAllItems: %v", err)
+	}
+	if len(byColl["oidc"]) != 1 || len(byColl[SessionCollectionName]) != 1 {
+		t.Errorf("SearchAllItems = %v, want one match per collection", byColl)
+	}
+	if cols, _ := s.Collections(ctx); len(cols) != 2 || cols[0] != "oidc" || cols[1] != SessionCollectionName {
+		t.Errorf("Collections = %v, want [oidc session]", cols)
+	}
+
+	if err := s.DeleteCollection(ctx, "oidc"); err != nil {
+		t.Fatalf("DeleteCollection: %v", err)
+	}
+	if s.HasCollection("oidc") {
+		t.Error("HasCollection(oidc) = true after DeleteCollection")
+	}
+	if _, err := s.GetItem(ctx, "oidc", id); err == nil {
+		t.Error("GetItem in a deleted collection succeeded")
+	}
+	if err := s.DeleteCollection(ctx, SessionCollectionName); err == nil {
+		t.Error("DeleteCollection(session) succeeded")
+	}
+}
+
+func TestKeyringStore_MoveAndRename(t *testing.T) {
+	s := newTestKeyringStore(t)
+	ctx := context.Background()
+	if err := s.CreateCollection(ctx, "oidc", "OIDC tokens"); err != nil {
+		t.Fatalf("CreateCollection: %v", err)
+	}
+	id, err := s.CreateItem(ctx, SessionCollectionName, &ItemData{
+		Secret:     []byte("token"),
+		Attributes: map[string]string{TTLAttribute: "1h"},
+	})
+	if err != nil {
+		t.Fatalf("CreateItem: %v", err)
+	}
+
+	if _, err := s.MoveItem(ctx, SessionCollectionName, id, "created"); err != nil {
+		t.Fatalf("MoveItem to a new collection: %v", err)
+	}
+	if !s.HasCollection("created") {
+		t.Error("MoveItem didn't create the collection")
+	}
+	if _, err := s.MoveItem(ctx, "created", id, "oidc"); err != nil {
+		t.Fatalf("MoveItem: %v", err)
+	}
+	if _, err := s.GetItem(ctx, SessionCollectionName, id); err == nil {
+		t.Error("moved item still in session")
+	}
+	if err := s.RenameCollection(ctx, "oidc", "tokens"); err != nil {
+		t.Fatalf("RenameCollection: %v", err)
+	}
+	if s.HasCollection("oidc") || !s.HasCollection("tokens") {
+		t.Error("collection not renamed")
+	}
+	item, err := s.GetItem(ctx, "tokens", id)
+	if err != nil || string(item.Secret) != "token" {
+		t.Fatalf("GetItem after move and rename: %+v, %v", item, err)
+	}
+	if time.Until(item.Expires) < 59*time.Minute {
+		t.Errorf("Expires = %v, want the lifetime to carry over", item.Expires)
+	}
+	if coll, _ := s.GetCollection(ctx, "tokens"); coll == nil || coll.Label != "OIDC tokens" {
+		t.Errorf("GetCollection(tokens) = %+v", coll)
+	}
+	if err := s.RenameCollection(ctx, SessionCollectionName, "other"); err == nil {
+		t.Error("RenameCollection(session) succeeded")
+	}
+}
+
+func TestKeyringStore_RenameRollsBack(t *testing.T) {
+	s := newTestKeyringStore(t)
+	ctx := context.Background()
+	var ids []string
+	for _, secret := range []string{"a", "b", "c"} {
+		id, err := s.CreateItem(ctx, SessionCollectionName, &ItemData{Secret: []byte(secret)})
+		if err != nil {
+			t.Fatalf("CreateItem: %v", err)
+		}
+		ids = append(ids, id)
+	}
+	if err := s.CreateCollection(ctx, "oidc", ""); err != nil {
+		t.Fatalf("CreateCollection: %v", err)
+	}
+	for _, id := range ids {
+		if _, err := s.MoveItem(ctx, SessionCollectionName, id, "oidc"); err != nil {
+			t.Fatalf("MoveItem: %v", err)
+		}
+	}
+
+	// A revoked key can't be linked into the new keyring, so the rename
+	// fails at that item, after or before moving the others.
+	if _, err := s.do(func() (any, error) {
+		return unix.KeyctlInt(unix.KEYCTL_REVOKE, s.colls["oidc"].items[ids[1]], 0, 0, 0)
+	}); err != nil {
+		t.Fatalf("revoke key: %v", err)
+	}
+	if err := s.RenameCollection(ctx, "oidc", "tokens"); err == nil {
+		t.Fatal("RenameCollection succeeded with a revoked key")
+	}
+	if !s.HasCollection("oidc") || s.HasCollection("tokens") {
+		t.Error("failed rename left the collections changed")
+	}
+	for _, id := range []string{ids[0], ids[2]} {
+		if _, err := s.GetItem(ctx, "oidc", id); err != nil {
+			t.Errorf("GetItem(%s) after the failed rename: %v", id, err)
+		}
 	}
 }
 
@@ -255,7 +396,7 @@ func (e *mismatchErr) Error() string { return "secret mismatch: want " + e.want
 // parallel. The worker serializes their syscalls, so this should never race
 // or fail. Two failure modes it would catch: (1) a regression to direct
 // syscalls from the calling goroutine (would surface EACCES on some Ms), and
-// (2) lost updates from unguarded mutation of s.items.
+// (2) lost updates from unguarded mutation of the items map.
 //
 // Each goroutine creates ONE item up-front and then loops Get/Update on it
 // rather than churning create/delete. The kernel keyring's per-UID byte
@@ -327,3 +468,69 @@ func TestKeyringStore_OperationsAfterCloseFail(t *testing.T) {
 		t.Errorf("second Close should be a no-op, got %v", err)
 	}
 }
+
+func TestKeyringStore_TTLExpiresItems(t *testing.T) {
+	s := newTestKeyringStore(t)
+	s.SetTTLRules(TTLRules{"org.example.Token": time.Second})
+	ctx := context.Background()
+
+	batches := make(chan []Change, 4)
+	watchCtx, cancel := context.WithCancel(ctx)
+	t.Cleanup(cancel)
+	s.WatchExpired(watchCtx, func(c []Change) { batches <- c })
+
+	byAttr, err := s.CreateItem(ctx, SessionCollectionName, &ItemData{
+		Secret:     []byte("a"),
+		Attributes: map[string]string{TTLAttribute: "1"},
+	})
+	if err != nil {
+		t.Fatalf("CreateItem: %v", err)
+	}
+	bySchema, err := s.CreateItem(ctx, SessionCollectionName, &ItemData{
+		Secret:     []byte("b"),
+		Attributes: map[string]string{"xdg:schema": "org.example.Token"},
+	})
+	if err != nil {
+		t.Fatalf("CreateItem: %v", err)
+	}
+	kept, err := s.CreateItem(ctx, SessionCollectionName, &ItemData{Secret: []byte("c")})
+	if err != nil {
+		t.Fatalf("CreateItem: %v", err)
+	}
+
+	removed := map[string]bool{}
+	deadline := time.After(5 * time.Second)
+	for len(removed) < 2 {
+		select {
+		case batch := <-batches:
+			for _, c := range batch {
+				if c.Collection != SessionCollectionName || !c.Removed {
+					t.Errorf("unexpected change %+v", c)
+				}
+				removed[c.ItemID] = true
+			}
+		case <-deadline:
+			t.Fatalf("expired items reported: %v", removed)
+		}
+	}
+	if !removed[byAttr] || !removed[bySchema] {
+		t.Errorf("removed = %v, want %s and %s", removed, byAttr, bySchema)
+	}
+	if ids, _ := s.Items(ctx, SessionCollectionName); len(ids) != 1 || ids[0] != kept {
+		t.Errorf("Items = %v, want [%s]", ids, kept)
+	}
+	if _, err := s.GetItem(ctx, SessionCollectionName, byAttr); err == nil {
+		t.Error("GetItem of an expired item succeeded")
+	}
+}
+
+func TestKeyringStore_RejectsInvalidTTL(t *testing.T) {
+	s := newTestKeyringStore(t)
+	_, err := s.CreateItem(context.Background(), SessionCollectionName, &ItemData{
+		Secret:     []byte("x"),
+		Attributes: map[string]string{TTLAttribute: "soon"},
+	})
+	if err == nil {
+		t.Error("CreateItem with an invalid TTL succeeded")
+	}
+}
@@ -0,0 +1,308 @@
+package store
+
+import (
+	"context"
+	"sort"
+	"strings"
+)
+
+// GopassStore keeps two in-memory structures next to metaCache so lookups
+// don't scan the whole password store:
+//
+//   - the listing, the entry paths below the prefix grouped by their first
+//     segment (the collection). gopass can only list the entire store, so
+//     without it every Items call walked all entries, including the ones that
+//     have nothing to do with the secret service. The listing is kept up to
+//     date by the store's own writes and, while Watch runs, by external
+//     changes; without Watch it can't notice `gopass insert`, so it is then
+//     re-read on every use, as before.
+//   - postings, an inverted index from an attribute key and value to the
+//     paths whose cached metadata has them, so an exact-match search only
+//     visits the entries that can match.
+//
+// Both are guarded by cacheMu.
+type listing struct {
+	// valid is cleared whenever the listing may have missed a change.
+	valid bool
+	// watched is set while Watch is delivering external changes.
+	watched bool
+	// gen counts mutations, so a listing read from gopass while one happened
+	// isn't trusted.
+	gen int
+	// paths maps the first segment below the prefix to the entry paths
+	// beneath it (or to itself, for an entry directly below the prefix).
+	paths map[string]map[string]struct{}
+	// cold holds the listed paths without cached metadata.
+	cold map[string]struct{}
+}
+
+// postingKey is the postings key of one attribute.
+func postingKey(k, v string) string {
+	return k + "\x00" + v
+}
+
+// ensureListing makes the listing current, reading it from gopass if it
+// isn't trusted.
+func (s *GopassStore) ensureListing(ctx context.Context) error {
+	s.cacheMu.RLock()
+	ok := s.listing.valid && s.listing.watched
+	gen := s.listing.gen
+	s.cacheMu.RUnlock()
+	if ok {
+		return nil
+	}
+
+	all, err := s.store.List(ctx)
+	if err != nil {
+		return err
+	}
+	s.cacheMu.Lock()
+	defer s.cacheMu.Unlock()
+	l := &s.listing
+	l.paths = make(map[string]map[string]struct{})
+	l.cold = make(map[string]struct{})
+	for _, p := range all {
+		s.listAddLocked(p)
+	}
+	l.valid = l.gen == gen
+	return nil
+}
+
+// firstSegment returns the collection an entry path belongs to, and false
+// for paths outside the prefix.
+func (s *GopassStore) firstSegment(p string) (string, bool) {
+	rest, ok := strings.CutPrefix(p, s.mapper.prefix+"/")
+	if !ok || rest == "" {
+		return "", false
+	}
+	first, _, _ := strings.Cut(rest, "/")
+	return first, true
+}
+
+// listAddLocked adds p to the listing. The caller holds cacheMu.
+func (s *GopassStore) listAddLocked(p string) {
+	first, ok := s.firstSegment(p)
+	if !ok {
+		return
+	}
+	l := &s.listing
+	if l.paths[first] == nil {
+		l.paths[first] = make(map[string]struct{})
+	}
+	l.paths[first][p] = struct{}{}
+	if _, cached := s.metaCache[p]; !cached {
+		l.cold[p] = struct{}{}
+	}
+}
+
+// listRemoveLocked removes p and everything beneath it from the listing. The
+// caller holds cacheMu.
+func (s *GopassStore) listRemoveLocked(p string) {
+	l := &s.listing
+	if p == s.mapper.prefix {
+		l.paths = make(map[string]map[string]struct{})
+		l.cold = make(map[string]struct{})
+		return
+	}
+	first, ok := s.firstSegment(p)
+	if !ok {
+		return
+	}
+	for q := range l.paths[first] {
+		if q == p || strings.HasPrefix(q, p+"/") {
+			delete(l.paths[first], q)
+			delete(l.cold, q)
+		}
+	}
+	if len(l.paths[first]) == 0 {
+		delete(l.paths, first)
+	}
+}
+
+// listAdd records that the store wrote the entry p.
+func (s *GopassStore) listAdd(p string) {
+	s.cacheMu.Lock()
+	defer s.cacheMu.Unlock()
+	s.listing.gen++
+	if s.listing.valid {
+		s.listAddLocked(p)
+	}
+}
+
+// listRemove records that the store removed p and everything beneath it.
+func (s *GopassStore) listRemove(p string) {
+	s.cacheMu.Lock()
+	defer s.cacheMu.Unlock()
+	s.listing.gen++
+	if s.listing.valid {
+		s.listRemoveLocked(p)
+	}
+}
+
+// listRename records that the store moved p and everything beneath it to to.
+func (s *GopassStore) listRename(p, to string) {
+	s.cacheMu.Lock()
+	defer s.cacheMu.Unlock()
+	s.listing.gen++
+	if !s.listing.valid {
+		return
+	}
+	first, _ := s.firstSegment(p)
+	var moved []string
+	for q := range s.listing.paths[first] {
+		if q == p || strings.HasPrefix(q, p+"/") {
+			moved = append(moved, q)
+		}
+	}
+	s.listRemoveLocked(p)
+	for _, q := range moved {
+		s.listAddLocked(to + strings.TrimPrefix(q, p))
+	}
+}
+
+// dropListing forgets the listing, e.g. after external changes that can't
+// be attributed to single entries.
+func (s *GopassStore) dropListing() {
+	s.cacheMu.Lock()
+	defer s.cacheMu.Unlock()
+	s.listing.gen++
+	s.listing.valid = false
+}
+
+// setListingWatched tells the store whether Watch reports external changes,
+// which is what lets it keep the listing between calls.
+func (s *GopassStore) setListingWatched(watched bool) {
+	s.cacheMu.Lock()
+	defer s.cacheMu.Unlock()
+	s.listing.watched = watched
+	s.listing.gen++
+	s.listing.valid = false
+}
+
+// The lookups below read the listing as it is; callers make it current with
+// ensureListing first, once per operation.
+
+// isListed reports whether the entry p exists.
+func (s *GopassStore) isListed(p string) bool {
+	first, ok := s.firstSegment(p)
+	if !ok {
+		return false
+	}
+	s.cacheMu.RLock()
+	defer s.cacheMu.RUnlock()
+	_, listed := s.listing.paths[first][p]
+	return listed
+}
+
+// listedUnder returns the sorted entry paths beneath the collection name.
+func (s *GopassStore) listedUnder(name string) []string {
+	collPath := s.mapper.CollectionPath(name)
+	s.cacheMu.RLock()
+	var out []string
+	for p := range s.listing.paths[name] {
+		if strings.HasPrefix(p, collPath+"/") {
+			out = append(out, p)
+		}
+	}
+	s.cacheMu.RUnlock()
+	sort.Strings(out)
+	return out
+}
+
+// listedFirstSegments returns the names directly below the prefix.
+func (s *GopassStore) listedFirstSegments() []string {
+	s.cacheMu.RLock()
+	defer s.cacheMu.RUnlock()
+	names := make([]string, 0, len(s.listing.paths))
+	for first := range s.listing.paths {
+		names = append(names, first)
+	}
+	return names
+}
+
+// setMetaLocked caches an entry's metadata and indexes its attributes. The
+// caller holds cacheMu.
+func (s *GopassStore) setMetaLocked(p string, meta map[string]string) {
+	s.dropMetaLocked(p)
+	s.metaCache[p] = meta
+	for k, v := range meta {
+		if strings.HasPrefix(k, metaPrefix) {
+			continue
+		}
+		key := postingKey(k, v)
+		if s.postings[key] == nil {
+			s.postings[key] = make(map[string]struct{})
+		}
+		s.postings[key][p] = struct{}{}
+	}
+	delete(s.listing.cold, p)
+}
+
+// dropMetaLocked forgets an entry's cached metadata. The caller holds
+// cacheMu.
+func (s *GopassStore) dropMetaLocked(p string) {
+	old, ok := s.metaCache[p]
+	if !ok {
+		return
+	}
+	for k, v := range old {
+		key := postingKey(k, v)
+		delete(s.postings[key], p)
+		if len(s.postings[key]) == 0 {
+			delete(s.postings, key)
+		}
+	}
+	delete(s.metaCache, p)
+	if first, ok := s.firstSegment(p); ok {
+		if _, listed := s.listing.paths[first][p]; listed {
+			s.listing.cold[p] = struct{}{}
+		}
+	}
+}
+
+// coldUnder returns the listed paths beneath the collection name without
+// cached metadata.
+func (s *GopassStore) coldUnder(name string) []string {
+	collPath := s.mapper.CollectionPath(name)
+	s.cacheMu.RLock()
+	defer s.cacheMu.RUnlock()
+	var out []string
+	for p := range s.listing.cold {
+		if strings.HasPrefix(p, collPath+"/") {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// candidates returns the sorted listed paths beneath the collection name
+// whose cached metadata has the rarest of the attributes, or false if none of
+// them narrows the search: an empty value also matches entries without the
+// attribute.
+func (s *GopassStore) candidates(name string, attributes map[string]string) ([]string, bool) {
+	collPath := s.mapper.CollectionPath(name)
+	s.cacheMu.RLock()
+	defer s.cacheMu.RUnlock()
+	var best map[string]struct{}
+	found := false
+	for k, v := range attributes {
+		if v == "" {
+			continue
+		}
+		set := s.postings[postingKey(k, v)]
+		if !found || len(set) < len(best) {
+			best, found = set, true
+		}
+	}
+	if !found {
+		return nil, false
+	}
+	out := make([]string, 0, len(best))
+	for p := range best {
+		if _, listed := s.listing.paths[name][p]; listed && strings.HasPrefix(p, collPath+"/") {
+			out = append(out, p)
+		}
+	}
+	sort.Strings(out)
+	return out, true
+}
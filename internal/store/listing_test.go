@@ -0,0 +1,141 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestGopassStore_WatchedListingAvoidsRelisting(t *testing.T) {
+	ctx := context.Background()
+	s, _, _ := newWatchedStore(t)
+	fake := s.store.(*fakeGopassStore)
+	for i := range 3 {
+		if _, err := s.CreateItem(ctx, "default", &ItemData{
+			Label: "item", Secret: []byte("x"), Attributes: map[string]string{"n": fmt.Sprint(i)},
+		}); err != nil {
+			t.Fatalf("CreateItem: %v", err)
+		}
+	}
+	// Entries that aren't the secret service's don't show up either.
+	fake.putSecret("websites/example.org", "pw", nil)
+
+	fake.listCount = 0
+	for range 3 {
+		if ids, err := s.Items(ctx, "default"); err != nil || len(ids) != 3 {
+			t.Fatalf("Items = %v, %v; want 3 items", ids, err)
+		}
+		if names, err := s.Collections(ctx); err != nil || !slices.Equal(names, []string{"default"}) {
+			t.Fatalf("Collections = %v, %v; want [default]", names, err)
+		}
+		if res, err := s.SearchAllItems(ctx, map[string]string{"n": "1"}); err != nil || len(res["default"]) != 1 {
+			t.Fatalf("SearchAllItems = %v, %v; want one match", res, err)
+		}
+	}
+	if fake.listCount != 0 {
+		t.Errorf("store listed %d times, want 0 while watched", fake.listCount)
+	}
+}
+
+func TestGopassStore_UnwatchedListingSeesExternalEntries(t *testing.T) {
+	ctx := context.Background()
+	fake := newFakeGopassStore()
+	s := newTestGopassStore(fake)
+	if _, err := s.Items(ctx, "default"); err != nil {
+		t.Fatalf("Items: %v", err)
+	}
+
+	// Without Watch nothing reports this, so the listing must be re-read.
+	fake.putSecret(s.mapper.ItemPath("default", "ext_item"), "pw", map[string]string{"service": "ext"})
+	if ids, err := s.Items(ctx, "default"); err != nil || !slices.Equal(ids, []string{"ext_item"}) {
+		t.Errorf("Items = %v, %v; want [ext_item]", ids, err)
+	}
+}
+
+func TestGopassStore_WatchedListingFollowsExternalChanges(t *testing.T) {
+	ctx := context.Background()
+	s, dir, batches := newWatchedStore(t)
+	fake := s.store.(*fakeGopassStore)
+	if _, err := s.Items(ctx, "default"); err != nil {
+		t.Fatalf("Items: %v", err)
+	}
+
+	fake.putSecret(s.mapper.ItemPath("default", "ext_item"), "pw", map[string]string{"service": "ext"})
+	entry := filepath.Join(dir, "default", "ext_item.gpg")
+	writeEntry(t, entry)
+	nextBatch(t, batches)
+	if res, err := s.SearchItems(ctx, "default", map[string]string{"service": "ext"}); err != nil || len(res) != 1 {
+		t.Errorf("SearchItems after external add = %v, %v; want ext_item", res, err)
+	}
+
+	delete(fake.data, s.mapper.ItemPath("default", "ext_item"))
+	if err := os.Remove(entry); err != nil {
+		t.Fatal(err)
+	}
+	nextBatch(t, batches)
+	if ids, err := s.Items(ctx, "default"); err != nil || len(ids) != 0 {
+		t.Errorf("Items after external removal = %v, %v; want none", ids, err)
+	}
+}
+
+// TestSearchItemsPostingsFollowWrites checks that the attribute postings
+// track the daemon's own writes, and that searching for an empty value still
+// matches items without the attribute, as a scan would.
+func TestSearchItemsPostingsFollowWrites(t *testing.T) {
+	ctx := context.Background()
+	s := newTestGopassStore(newFakeGopassStore())
+	create := func(attrs map[string]string) string {
+		t.Helper()
+		id, err := s.CreateItem(ctx, "default", &ItemData{Label: "item", Secret: []byte("x"), Attributes: attrs})
+		if err != nil {
+			t.Fatalf("CreateItem: %v", err)
+		}
+		return id
+	}
+	search := func(attrs map[string]string) []string {
+		t.Helper()
+		res, err := s.SearchItems(ctx, "default", attrs)
+		if err != nil {
+			t.Fatalf("SearchItems: %v", err)
+		}
+		var ids []string
+		for _, item := range res {
+			ids = append(ids, item.ID)
+		}
+		slices.Sort(ids)
+		return ids
+	}
+
+	a := create(map[string]string{"service": "a", "user": "me"})
+	b := create(map[string]string{"service": "b", "user": "me"})
+	create(map[string]string{"service": "c"})
+
+	if got := search(map[string]string{"user": "me", "service": "a"}); !slices.Equal(got, []string{a}) {
+		t.Errorf("search service=a user=me = %v, want [%s]", got, a)
+	}
+	if got, want := search(map[string]string{"user": "me"}), slices.Sorted(slices.Values([]string{a, b})); !slices.Equal(got, want) {
+		t.Errorf("search user=me = %v, want %v", got, want)
+	}
+	if got := search(map[string]string{"service": "c", "user": ""}); len(got) != 1 {
+		t.Errorf("search service=c user=\"\" = %v, want the item without user", got)
+	}
+
+	if err := s.UpdateItem(ctx, "default", a, &ItemData{Label: "item", Secret: []byte("x"), Attributes: map[string]string{"service": "a2"}}); err != nil {
+		t.Fatalf("UpdateItem: %v", err)
+	}
+	if got := search(map[string]string{"user": "me"}); !slices.Equal(got, []string{b}) {
+		t.Errorf("search user=me after update = %v, want [%s]", got, b)
+	}
+	if err := s.DeleteItem(ctx, "default", b); err != nil {
+		t.Fatalf("DeleteItem: %v", err)
+	}
+	if got := search(map[string]string{"user": "me"}); len(got) != 0 {
+		t.Errorf("search user=me after delete = %v, want none", got)
+	}
+	if got := search(map[string]string{"service": "a2"}); !slices.Equal(got, []string{a}) {
+		t.Errorf("search service=a2 = %v, want [%s]", got, a)
+	}
+}
@@ -1,6 +1,7 @@
 package store
 
 import (
+	"encoding/hex"
 	"fmt"
 	"path"
 	"strings"
@@ -23,7 +24,67 @@ func (m *Mapper) CollectionPath(name string) string {
 
 // ItemPath returns the GoPass path for an item
 func (m *Mapper) ItemPath(collection, id string) string {
-	return path.Join(m.prefix, collection, id)
+	return path.Join(m.prefix, collection, itemRelPath(id))
+}
+
+// pathIDPrefix marks item IDs that encode an entry path (see pathID).
+const pathIDPrefix = "p_"
+
+// ItemID returns the item ID for an entry path relative to its collection.
+// Entries named after their ID, like the UUID-named ones, keep that name as
+// ID. Other paths, such as those produced by PathTemplates, may contain
+// slashes and characters D-Bus object paths forbid, so the ID encodes them
+// with pathID. Either way the ID only changes if the entry is moved.
+func (m *Mapper) ItemID(rel string) string {
+	if isObjectPathElement(rel) && !strings.HasPrefix(rel, pathIDPrefix) {
+		return rel
+	}
+	return pathID(rel)
+}
+
+// pathID hex-encodes an entry path as an item ID, which is a valid object
+// path element whatever the path contains.
+func pathID(rel string) string {
+	return pathIDPrefix + hex.EncodeToString([]byte(rel))
+}
+
+// parsePathID reverses pathID. It returns false for IDs pathID can't have
+// produced.
+func parsePathID(id string) (string, bool) {
+	raw, err := hex.DecodeString(strings.TrimPrefix(id, pathIDPrefix))
+	if !strings.HasPrefix(id, pathIDPrefix) || err != nil || len(raw) == 0 {
+		return "", false
+	}
+	return string(raw), true
+}
+
+// itemRelPath reverses ItemID. An ID that doesn't decode to a path inside the
+// collection is used as-is.
+func itemRelPath(id string) string {
+	rel, ok := parsePathID(id)
+	if !ok {
+		return id
+	}
+	for _, seg := range strings.Split(rel, "/") {
+		if seg == "" || seg == "." || seg == ".." {
+			return id
+		}
+	}
+	return rel
+}
+
+// isObjectPathElement reports whether s is valid as one element of a D-Bus
+// object path.
+func isObjectPathElement(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_') {
+			return false
+		}
+	}
+	return true
 }
 
 // AliasesPath returns the GoPass path for the aliases file
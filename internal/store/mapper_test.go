@@ -91,6 +91,42 @@ func TestMapper(t *testing.T) {
 	})
 }
 
+func TestMapperItemID(t *testing.T) {
+	m := NewMapper("secret-service")
+
+	tests := []struct {
+		rel     string
+		encoded bool
+	}{
+		{"i0123abcd", false},
+		{"_meta", false},
+		{"item-a", true},
+		{"github.com", true},
+		{"web/github.com/me", true},
+		{"p_looks_encoded", true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.rel, func(t *testing.T) {
+			id := m.ItemID(tc.rel)
+			if encoded := id != tc.rel; encoded != tc.encoded {
+				t.Errorf("ItemID(%q) = %q, encoded = %v, want %v", tc.rel, id, encoded, tc.encoded)
+			}
+			if !isObjectPathElement(id) {
+				t.Errorf("ItemID(%q) = %q is not a valid object path element", tc.rel, id)
+			}
+			if got, want := m.ItemPath("default", id), "secret-service/default/"+tc.rel; got != want {
+				t.Errorf("ItemPath(%q) = %q, want %q", id, got, want)
+			}
+		})
+	}
+
+	// An encoded ID can't point outside its collection.
+	escape := pathIDPrefix + "2e2e2f2e2e2f78" // "../../x"
+	if got := m.ItemPath("default", escape); got != "secret-service/default/"+escape {
+		t.Errorf("ItemPath(%q) = %q", escape, got)
+	}
+}
+
 func TestSanitizeName(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -1,48 +1,99 @@
 // Package store, multi.go: a Store implementation that routes operations
-// between a primary (durable) store and a session (volatile) store.
+// between several underlying stores — typically one gopass store per mount
+// plus the volatile keyring store.
 package store
 
 import (
 	"context"
 	"fmt"
+	"path"
+
+	"github.com/nikicat/gopass-secret-service/internal/secmem"
 )
 
-// MultiStore implements Store by delegating to one of two underlying stores
-// based on the collection name. Calls referencing SessionCollectionName go to
-// the session store; everything else goes to the primary. Operations that
-// don't reference a collection (most alias operations, Close) are handled by
-// fan-out or by the primary alone.
+// Route sends every collection whose name matches one of Patterns to Store.
+// Patterns use path.Match syntax, so "work-*" or an exact name both work.
+// Has, when set, additionally claims the collections it reports, e.g. the
+// ones a store created at runtime under names no pattern covers.
+type Route struct {
+	Patterns []string
+	Store    Store
+	Has      func(name string) bool
+}
+
+// Matches reports whether the collection name is claimed by this route
+func (r Route) Matches(name string) bool {
+	for _, pattern := range r.Patterns {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return r.Has != nil && r.Has(name)
+}
+
+// MultiStore implements Store by delegating to one of several underlying
+// stores based on the collection name. Routes are tried in order and the
+// first match wins; collections no route claims go to the primary. Operations
+// that don't reference a collection are handled by fan-out (Collections,
+// SearchAllItems, Close) or by the primary alone (aliases).
 type MultiStore struct {
 	Primary Store
-	Session Store
+	Routes  []Route
 }
 
-// NewMultiStore returns a MultiStore that routes the session collection to
-// session and everything else to primary.
-func NewMultiStore(primary, session Store) *MultiStore {
-	return &MultiStore{Primary: primary, Session: session}
+// NewMultiStore returns a MultiStore that consults routes in order and sends
+// everything they don't claim to primary.
+func NewMultiStore(primary Store, routes ...Route) *MultiStore {
+	return &MultiStore{Primary: primary, Routes: routes}
 }
 
 func (m *MultiStore) routeByCollection(name string) Store {
-	if name == SessionCollectionName {
-		return m.Session
+	for _, r := range m.Routes {
+		if r.Matches(name) {
+			return r.Store
+		}
 	}
 	return m.Primary
 }
 
-func (m *MultiStore) Collections(ctx context.Context) ([]string, error) {
-	primary, err := m.Primary.Collections(ctx)
-	if err != nil {
-		return nil, err
+// stores returns every distinct underlying store, primary first. Several
+// routes may share a store (e.g. two patterns for one mount), so identity is
+// deduplicated to avoid double fan-out.
+func (m *MultiStore) stores() []Store {
+	out := []Store{m.Primary}
+	for _, r := range m.Routes {
+		dup := false
+		for _, s := range out {
+			if s == r.Store {
+				dup = true
+				break
+			}
+		}
+		if !dup {
+			out = append(out, r.Store)
+		}
 	}
-	// Surface the session collection as a peer of primary collections so
-	// Service.Collections includes it without special-casing.
-	for _, name := range primary {
-		if name == SessionCollectionName {
-			return primary, nil
+	return out
+}
+
+// Collections lists the union of all underlying stores' collections. Like
+// SearchAllItems it keeps a name only from the store it routes to, so a stale
+// collection left on a mount that no longer owns it isn't listed: every other
+// method would send it to another store.
+func (m *MultiStore) Collections(ctx context.Context) ([]string, error) {
+	var out []string
+	for _, s := range m.stores() {
+		names, err := s.Collections(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range names {
+			if m.routeByCollection(name) == s {
+				out = append(out, name)
+			}
 		}
 	}
-	return append(primary, SessionCollectionName), nil
+	return out, nil
 }
 
 func (m *MultiStore) GetCollection(ctx context.Context, name string) (*CollectionData, error) {
@@ -61,6 +112,16 @@ func (m *MultiStore) SetCollectionLabel(ctx context.Context, name, label string)
 	return m.routeByCollection(name).SetCollectionLabel(ctx, name, label)
 }
 
+// RenameCollection renames within the collection's store; a collection can't
+// be renamed onto another store's route.
+func (m *MultiStore) RenameCollection(ctx context.Context, from, to string) error {
+	src := m.routeByCollection(from)
+	if m.routeByCollection(to) != src {
+		return fmt.Errorf("cannot rename %s to %s: the new name belongs to another store", from, to)
+	}
+	return src.RenameCollection(ctx, from, to)
+}
+
 func (m *MultiStore) Items(ctx context.Context, collection string) ([]string, error) {
 	return m.routeByCollection(collection).Items(ctx, collection)
 }
@@ -81,29 +142,53 @@ func (m *MultiStore) DeleteItem(ctx context.Context, collection, id string) erro
 	return m.routeByCollection(collection).DeleteItem(ctx, collection, id)
 }
 
-func (m *MultiStore) SearchItems(ctx context.Context, collection string, attributes map[string]string) ([]*ItemData, error) {
-	return m.routeByCollection(collection).SearchItems(ctx, collection, attributes)
-}
-
-// SearchAllItems fans out across both stores. A failure in either is logged
-// upstream by returning the first error; partial results from the other store
-// are dropped to avoid surprising callers with half a result set.
-func (m *MultiStore) SearchAllItems(ctx context.Context, attributes map[string]string) (map[string][]*ItemData, error) {
-	primary, err := m.Primary.SearchAllItems(ctx, attributes)
+// MoveItem moves within a store natively. Between stores the item is copied
+// to the target and then deleted from the source; if that fails, the copy is
+// deleted again.
+func (m *MultiStore) MoveItem(ctx context.Context, from, id, to string) (string, error) {
+	src, dst := m.routeByCollection(from), m.routeByCollection(to)
+	if src == dst {
+		return src.MoveItem(ctx, from, id, to)
+	}
+	item, err := src.GetItem(ctx, from, id)
 	if err != nil {
-		return nil, err
+		return "", err
 	}
-	session, err := m.Session.SearchAllItems(ctx, attributes)
+	defer secmem.Wipe(item.Secret)
+	newID, err := dst.CreateItem(ctx, to, item)
 	if err != nil {
-		return nil, err
+		return "", err
 	}
-	if primary == nil {
-		primary = map[string][]*ItemData{}
+	if err := src.DeleteItem(ctx, from, id); err != nil {
+		_ = dst.DeleteItem(ctx, to, newID)
+		return "", err
 	}
-	for k, v := range session {
-		primary[k] = v
+	return newID, nil
+}
+
+func (m *MultiStore) SearchItems(ctx context.Context, collection string, attributes map[string]string) ([]*ItemData, error) {
+	return m.routeByCollection(collection).SearchItems(ctx, collection, attributes)
+}
+
+// SearchAllItems fans out across all stores. A failure in any is returned as
+// the first error; partial results from the others are dropped to avoid
+// surprising callers with half a result set. Results are kept only for
+// collections that route back to the store that produced them, so a stale
+// collection left on a mount that no longer owns it can't shadow the real one.
+func (m *MultiStore) SearchAllItems(ctx context.Context, attributes map[string]string) (map[string][]*ItemData, error) {
+	out := map[string][]*ItemData{}
+	for _, s := range m.stores() {
+		results, err := s.SearchAllItems(ctx, attributes)
+		if err != nil {
+			return nil, err
+		}
+		for coll, items := range results {
+			if m.routeByCollection(coll) == s {
+				out[coll] = items
+			}
+		}
 	}
-	return primary, nil
+	return out, nil
 }
 
 func (m *MultiStore) LockCollection(ctx context.Context, name string) error {
@@ -133,11 +218,22 @@ func (m *MultiStore) SetAlias(ctx context.Context, alias, collection string) err
 	return m.Primary.SetAlias(ctx, alias, collection)
 }
 
+// Aliases implements AliasLister with the primary's alias table.
+func (m *MultiStore) Aliases(ctx context.Context) (map[string]string, error) {
+	l, ok := m.Primary.(AliasLister)
+	if !ok {
+		return nil, ErrAliasesUnsupported
+	}
+	return l.Aliases(ctx)
+}
+
+// Close closes every underlying store and returns the first error.
 func (m *MultiStore) Close(ctx context.Context) error {
-	primaryErr := m.Primary.Close(ctx)
-	sessionErr := m.Session.Close(ctx)
-	if primaryErr != nil {
-		return primaryErr
+	var firstErr error
+	for _, s := range m.stores() {
+		if err := s.Close(ctx); err != nil && firstErr == nil {
+			firstErr = err
+		}
 	}
-	return sessionErr
+	return firstErr
 }
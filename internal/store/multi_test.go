@@ -3,6 +3,7 @@ package store
 import (
 	"context"
 	"fmt"
+	"slices"
 	"testing"
 	"time"
 )
@@ -63,6 +64,18 @@ func (f *fakeStore) SetCollectionLabel(ctx context.Context, name, label string)
 	return nil
 }
 
+func (f *fakeStore) RenameCollection(ctx context.Context, from, to string) error {
+	c, ok := f.collections[from]
+	if !ok {
+		return fmt.Errorf("%s: not found %s", f.name, from)
+	}
+	c.Name = to
+	f.collections[to], f.items[to] = c, f.items[from]
+	delete(f.collections, from)
+	delete(f.items, from)
+	return nil
+}
+
 func (f *fakeStore) Items(ctx context.Context, collection string) ([]string, error) {
 	m, ok := f.items[collection]
 	if !ok {
@@ -78,11 +91,19 @@ func (f *fakeStore) Items(ctx context.Context, collection string) ([]string, err
 func (f *fakeStore) GetItem(ctx context.Context, collection, id string) (*ItemData, error) {
 	m := f.items[collection]
 	if it, ok := m[id]; ok {
-		return it, nil
+		return withSecretCopy(it), nil
 	}
 	return nil, fmt.Errorf("%s: item not found %s/%s", f.name, collection, id)
 }
 
+// withSecretCopy returns a copy of item that doesn't share its secret: a
+// store must not keep the slice it's given nor hand out the one it keeps.
+func withSecretCopy(item *ItemData) *ItemData {
+	c := *item
+	c.Secret = append([]byte(nil), item.Secret...)
+	return &c
+}
+
 func (f *fakeStore) CreateItem(ctx context.Context, collection string, item *ItemData) (string, error) {
 	if f.items[collection] == nil {
 		f.items[collection] = map[string]*ItemData{}
@@ -90,12 +111,12 @@ func (f *fakeStore) CreateItem(ctx context.Context, collection string, item *Ite
 	if item.ID == "" {
 		item.ID = fmt.Sprintf("%s-item-%d", f.name, len(f.items[collection]))
 	}
-	f.items[collection][item.ID] = item
+	f.items[collection][item.ID] = withSecretCopy(item)
 	return item.ID, nil
 }
 
 func (f *fakeStore) UpdateItem(ctx context.Context, collection, id string, item *ItemData) error {
-	f.items[collection][id] = item
+	f.items[collection][id] = withSecretCopy(item)
 	return nil
 }
 
@@ -104,6 +125,15 @@ func (f *fakeStore) DeleteItem(ctx context.Context, collection, id string) error
 	return nil
 }
 
+func (f *fakeStore) MoveItem(ctx context.Context, from, id, to string) (string, error) {
+	it, err := f.GetItem(ctx, from, id)
+	if err != nil {
+		return "", err
+	}
+	delete(f.items[from], id)
+	return f.CreateItem(ctx, to, it)
+}
+
 func (f *fakeStore) SearchItems(ctx context.Context, collection string, attributes map[string]string) ([]*ItemData, error) {
 	var out []*ItemData
 	for _, it := range f.items[collection] {
@@ -154,12 +184,16 @@ func (f *fakeStore) Close(ctx context.Context) error {
 	return nil
 }
 
+func sessionRoute(session Store) Route {
+	return Route{Patterns: []string{SessionCollectionName}, Store: session}
+}
+
 func newMulti() (*MultiStore, *fakeStore, *fakeStore) {
 	primary := newFakeStore("primary")
 	session := newFakeStore("session")
 	_ = primary.CreateCollection(context.Background(), "default", "Default")
 	_ = session.CreateCollection(context.Background(), SessionCollectionName, "Session")
-	return NewMultiStore(primary, session), primary, session
+	return NewMultiStore(primary, sessionRoute(session)), primary, session
 }
 
 func TestMultiStore_RouteByCollectionName(t *testing.T) {
@@ -206,7 +240,8 @@ func TestMultiStore_SessionCollectionsNotDuplicated(t *testing.T) {
 	primary := newFakeStore("primary")
 	session := newFakeStore("session")
 	_ = primary.CreateCollection(context.Background(), SessionCollectionName, "old")
-	m := NewMultiStore(primary, session)
+	_ = session.CreateCollection(context.Background(), SessionCollectionName, "Session")
+	m := NewMultiStore(primary, sessionRoute(session))
 
 	cols, _ := m.Collections(context.Background())
 	count := 0
@@ -266,6 +301,27 @@ func TestMultiStore_SearchAllItemsMergesBoth(t *testing.T) {
 	}
 }
 
+func TestMultiStore_MoveItemBetweenStores(t *testing.T) {
+	m, primary, session := newMulti()
+	ctx := context.Background()
+	session.items[SessionCollectionName]["s1"] = &ItemData{ID: "s1", Secret: []byte("x")}
+
+	id, err := m.MoveItem(ctx, SessionCollectionName, "s1", "default")
+	if err != nil {
+		t.Fatalf("MoveItem: %v", err)
+	}
+	if _, ok := session.items[SessionCollectionName]["s1"]; ok {
+		t.Error("item left in the source store")
+	}
+	if got := primary.items["default"][id]; got == nil || string(got.Secret) != "x" {
+		t.Errorf("item in the target store = %+v", got)
+	}
+
+	if err := m.RenameCollection(ctx, "default", SessionCollectionName); err == nil {
+		t.Error("RenameCollection onto another store's route succeeded")
+	}
+}
+
 func TestMultiStore_CloseClosesBoth(t *testing.T) {
 	m, primary, session := newMulti()
 	if err := m.Close(context.Background()); err != nil {
@@ -275,3 +331,131 @@ func TestMultiStore_CloseClosesBoth(t *testing.T) {
 		t.Errorf("Close counts: primary=%d session=%d, want 1/1", primary.closeCount, session.closeCount)
 	}
 }
+
+func TestMultiStore_RoutesByGlobFirstMatchWins(t *testing.T) {
+	primary := newFakeStore("primary")
+	work := newFakeStore("work")
+	other := newFakeStore("other")
+	m := NewMultiStore(primary,
+		Route{Patterns: []string{"work-*", "jobs"}, Store: work},
+		Route{Patterns: []string{"work-secret", "*-other"}, Store: other},
+	)
+	ctx := context.Background()
+
+	for _, name := range []string{"work-a", "work-secret", "jobs", "x-other", "default"} {
+		if err := m.CreateCollection(ctx, name, name); err != nil {
+			t.Fatalf("CreateCollection %s: %v", name, err)
+		}
+	}
+	for name, want := range map[string]*fakeStore{
+		"work-a":      work,
+		"work-secret": work, // first matching route wins
+		"jobs":        work,
+		"x-other":     other,
+		"default":     primary,
+	} {
+		if _, ok := want.collections[name]; !ok {
+			t.Errorf("collection %q not routed to %s", name, want.name)
+		}
+	}
+}
+
+func TestMultiStore_RoutesCollectionsTheStoreHas(t *testing.T) {
+	primary := newFakeStore("primary")
+	volatile := newFakeStore("volatile")
+	m := NewMultiStore(primary, Route{
+		Patterns: []string{"ci-*"},
+		Store:    volatile,
+		Has: func(name string) bool {
+			_, ok := volatile.collections[name]
+			return ok
+		},
+	})
+	ctx := context.Background()
+
+	// A collection created directly on the store is claimed from then on.
+	_ = volatile.CreateCollection(ctx, "oidc", "OIDC")
+	if _, err := m.CreateItem(ctx, "oidc", &ItemData{ID: "t"}); err != nil {
+		t.Fatalf("CreateItem: %v", err)
+	}
+	if _, ok := volatile.items["oidc"]["t"]; !ok {
+		t.Error("item in oidc not routed to the volatile store")
+	}
+	if err := m.CreateCollection(ctx, "other", "Other"); err != nil {
+		t.Fatalf("CreateCollection: %v", err)
+	}
+	if _, ok := primary.collections["other"]; !ok {
+		t.Error("unclaimed collection not routed to the primary")
+	}
+}
+
+func TestMultiStore_CollectionsUnionAcrossRoutes(t *testing.T) {
+	primary := newFakeStore("primary")
+	work := newFakeStore("work")
+	ctx := context.Background()
+	_ = primary.CreateCollection(ctx, "default", "Default")
+	_ = work.CreateCollection(ctx, "work", "Work")
+	_ = work.CreateCollection(ctx, "default", "stray")
+	m := NewMultiStore(primary, Route{Patterns: []string{"work"}, Store: work})
+
+	cols, err := m.Collections(ctx)
+	if err != nil {
+		t.Fatalf("Collections: %v", err)
+	}
+	if len(cols) != 2 {
+		t.Errorf("Collections = %v, want default and work once each", cols)
+	}
+}
+
+func TestMultiStore_SearchAllItemsIgnoresUnroutedCollections(t *testing.T) {
+	primary := newFakeStore("primary")
+	work := newFakeStore("work")
+	ctx := context.Background()
+	_ = primary.CreateCollection(ctx, "default", "Default")
+	_ = work.CreateCollection(ctx, "default", "stray")
+	primary.items["default"]["p1"] = &ItemData{ID: "p1", Attributes: map[string]string{"k": "v"}}
+	work.items["default"]["w1"] = &ItemData{ID: "w1", Attributes: map[string]string{"k": "v"}}
+	m := NewMultiStore(primary, Route{Patterns: []string{"work"}, Store: work})
+
+	got, err := m.SearchAllItems(ctx, map[string]string{"k": "v"})
+	if err != nil {
+		t.Fatalf("SearchAllItems: %v", err)
+	}
+	if len(got["default"]) != 1 || got["default"][0].ID != "p1" {
+		t.Errorf("default results = %+v, want only the primary's p1", got["default"])
+	}
+}
+
+func TestMultiStore_CloseSharedStoreOnce(t *testing.T) {
+	primary := newFakeStore("primary")
+	work := newFakeStore("work")
+	m := NewMultiStore(primary,
+		Route{Patterns: []string{"a"}, Store: work},
+		Route{Patterns: []string{"b"}, Store: work},
+	)
+	if err := m.Close(context.Background()); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if primary.closeCount != 1 || work.closeCount != 1 {
+		t.Errorf("Close counts: primary=%d work=%d, want 1/1", primary.closeCount, work.closeCount)
+	}
+}
+
+func TestMultiStore_CollectionsIgnoresUnroutedCollections(t *testing.T) {
+	primary := newFakeStore("primary")
+	work := newFakeStore("work")
+	ctx := context.Background()
+	_ = primary.CreateCollection(ctx, "default", "Default")
+	_ = work.CreateCollection(ctx, "work", "Work")
+	_ = work.CreateCollection(ctx, "left-behind", "Stale")
+	m := NewMultiStore(primary, Route{Patterns: []string{"work"}, Store: work})
+
+	cols, err := m.Collections(ctx)
+	if err != nil {
+		t.Fatalf("Collections: %v", err)
+	}
+	slices.Sort(cols)
+	if !slices.Equal(cols, []string{"default", "work"}) {
+		t.Errorf("Collections = %v, want default and work without the collection work no longer owns", cols)
+	}
+}
@@ -0,0 +1,87 @@
+package store
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// PathTemplates derives readable entry paths for new items from their
+// attributes, e.g. `{{.Attr "service"}}/{{.Attr "username"}}`, instead of
+// naming them after a UUID. The template is picked by the item's xdg:schema
+// attribute, falling back to a default one.
+type PathTemplates struct {
+	fallback *template.Template
+	bySchema map[string]*template.Template
+}
+
+// pathData is what templates are executed with.
+type pathData struct {
+	collection string
+	item       *ItemData
+}
+
+// Attr returns the value of an attribute, or "" if the item doesn't have it.
+func (d pathData) Attr(name string) string {
+	return d.item.Attributes[name]
+}
+
+// Label returns the item's label.
+func (d pathData) Label() string {
+	return d.item.Label
+}
+
+// Collection returns the name of the item's collection.
+func (d pathData) Collection() string {
+	return d.collection
+}
+
+// NewPathTemplates parses the default template (empty for none) and the
+// per-schema ones.
+func NewPathTemplates(fallback string, bySchema map[string]string) (*PathTemplates, error) {
+	t := &PathTemplates{bySchema: make(map[string]*template.Template, len(bySchema))}
+	if fallback != "" {
+		tmpl, err := template.New("default").Parse(fallback)
+		if err != nil {
+			return nil, fmt.Errorf("default path template: %w", err)
+		}
+		t.fallback = tmpl
+	}
+	for schema, text := range bySchema {
+		tmpl, err := template.New(schema).Parse(text)
+		if err != nil {
+			return nil, fmt.Errorf("path template for %s: %w", schema, err)
+		}
+		t.bySchema[schema] = tmpl
+	}
+	return t, nil
+}
+
+// Render returns the entry path of item relative to its collection, or ""
+// when no template applies or the template renders to nothing (e.g. all the
+// attributes it uses are missing). Every path segment is sanitized, and
+// segments starting with "." or "_" lose that prefix so an item can't be
+// mistaken for a dotfile or store metadata.
+func (t *PathTemplates) Render(collection string, item *ItemData) (string, error) {
+	tmpl, ok := t.bySchema[item.Attributes["xdg:schema"]]
+	if !ok {
+		tmpl = t.fallback
+	}
+	if tmpl == nil {
+		return "", nil
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, pathData{collection: collection, item: item}); err != nil {
+		return "", fmt.Errorf("item path template: %w", err)
+	}
+
+	var segments []string
+	for _, seg := range strings.Split(buf.String(), "/") {
+		seg = strings.TrimLeft(SanitizeName(strings.TrimSpace(seg)), "._")
+		if seg != "" {
+			segments = append(segments, seg)
+		}
+	}
+	return strings.Join(segments, "/"), nil
+}
@@ -0,0 +1,190 @@
+package store
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gopasspw/gopass/pkg/gopass"
+)
+
+func newTemplatedStore(t *testing.T) (*GopassStore, *fakeGopassStore) {
+	t.Helper()
+	templates, err := NewPathTemplates(`{{.Attr "service"}}`, map[string]string{
+		"org.gnome.keyring.NetworkPassword": `{{.Attr "xdg:schema"}}/{{.Attr "server"}}/{{.Attr "user"}}`,
+	})
+	if err != nil {
+		t.Fatalf("NewPathTemplates: %v", err)
+	}
+	fake := newFakeGopassStore()
+	s := newTestGopassStore(fake)
+	s.SetPathTemplates(templates)
+	return s, fake
+}
+
+func TestPathTemplatesRender(t *testing.T) {
+	templates, err := NewPathTemplates("", map[string]string{
+		"test": `{{.Collection}}/{{.Attr "a"}}/{{.Attr "b"}}/{{.Label}}`,
+	})
+	if err != nil {
+		t.Fatalf("NewPathTemplates: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		label string
+		attrs map[string]string
+		want  string
+	}{
+		{"all attributes", "My Key", map[string]string{"xdg:schema": "test", "a": "x", "b": "y"}, "login/x/y/My_Key"},
+		{"missing attribute", "k", map[string]string{"xdg:schema": "test", "a": "x"}, "login/x/k"},
+		{"traversal and dotfiles", "", map[string]string{"xdg:schema": "test", "a": "..", "b": ".hidden"}, "login/hidden"},
+		{"underscore prefix", "", map[string]string{"xdg:schema": "test", "a": "_meta"}, "login/meta"},
+		{"no template", "k", map[string]string{"xdg:schema": "other"}, ""},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := templates.Render("login", &ItemData{Label: tc.label, Attributes: tc.attrs})
+			if err != nil {
+				t.Fatalf("Render: %v", err)
+			}
+			if got != tc.want {
+				t.Errorf("Render = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestPathTemplatesParseError(t *testing.T) {
+	if _, err := NewPathTemplates(`{{.Attr "a"`, nil); err == nil {
+		t.Error("NewPathTemplates accepted an unterminated action")
+	}
+}
+
+func TestCreateItemUsesPathTemplate(t *testing.T) {
+	ctx := context.Background()
+	s, fake := newTemplatedStore(t)
+
+	attrs := map[string]string{"xdg:schema": "org.gnome.keyring.NetworkPassword", "server": "github.com", "user": "me"}
+	id, err := s.CreateItem(ctx, "default", &ItemData{ID: "i0123", Secret: []byte("pw"), Attributes: attrs})
+	if err != nil {
+		t.Fatalf("CreateItem: %v", err)
+	}
+	want := "secret-service/default/org.gnome.keyring.NetworkPassword/github.com/me"
+	if _, ok := fake.data[want]; !ok {
+		t.Fatalf("no entry at %s; have %v", want, fake.data)
+	}
+	if !isObjectPathElement(id) {
+		t.Errorf("ID %q is not a valid object path element", id)
+	}
+
+	item, err := s.GetItem(ctx, "default", id)
+	if err != nil || string(item.Secret) != "pw" {
+		t.Fatalf("GetItem(%s) = %v, %v", id, item, err)
+	}
+
+	// The ID survives an update that changes the attributes the path uses.
+	item.Attributes["user"] = "someone-else"
+	if err := s.UpdateItem(ctx, "default", id, item); err != nil {
+		t.Fatalf("UpdateItem: %v", err)
+	}
+	ids, err := s.Items(ctx, "default")
+	if err != nil {
+		t.Fatalf("Items: %v", err)
+	}
+	if len(ids) != 1 || ids[0] != id {
+		t.Errorf("Items = %v, want [%s]", ids, id)
+	}
+}
+
+func TestCreateItemPathCollision(t *testing.T) {
+	ctx := context.Background()
+	s, fake := newTemplatedStore(t)
+
+	attrs := map[string]string{"service": "github.com"}
+	first, err := s.CreateItem(ctx, "default", &ItemData{Secret: []byte("one"), Attributes: attrs})
+	if err != nil {
+		t.Fatalf("CreateItem: %v", err)
+	}
+	second, err := s.CreateItem(ctx, "default", &ItemData{Secret: []byte("two"), Attributes: attrs})
+	if err != nil {
+		t.Fatalf("CreateItem: %v", err)
+	}
+	if first == second {
+		t.Fatalf("both items got ID %s", first)
+	}
+	for _, p := range []string{"secret-service/default/github.com", "secret-service/default/github.com-2"} {
+		if _, ok := fake.data[p]; !ok {
+			t.Errorf("no entry at %s", p)
+		}
+	}
+	item, err := s.GetItem(ctx, "default", first)
+	if err != nil || string(item.Secret) != "one" {
+		t.Errorf("first item was overwritten: %v, %v", item, err)
+	}
+}
+
+func TestCreateItemWithoutTemplateKeepsUUIDLayout(t *testing.T) {
+	ctx := context.Background()
+	s, fake := newTemplatedStore(t)
+	fake.putSecret("secret-service/default/i0123abcd", "old", nil)
+
+	id, err := s.CreateItem(ctx, "default", &ItemData{ID: "i4567", Secret: []byte("new")})
+	if err != nil {
+		t.Fatalf("CreateItem: %v", err)
+	}
+	if id != "i4567" {
+		t.Errorf("ID = %q, want the caller's i4567", id)
+	}
+	ids, err := s.Items(ctx, "default")
+	if err != nil {
+		t.Fatalf("Items: %v", err)
+	}
+	if len(ids) != 2 {
+		t.Errorf("Items = %v, want the old and the new item", ids)
+	}
+}
+
+// slowSetStore widens the window between choosing a free path and writing it.
+type slowSetStore struct{ *fakeGopassStore }
+
+func (s slowSetStore) Set(ctx context.Context, name string, b gopass.Byter) error {
+	time.Sleep(5 * time.Millisecond)
+	return s.fakeGopassStore.Set(ctx, name, b)
+}
+
+func TestCreateItemPathCollisionConcurrent(t *testing.T) {
+	ctx := context.Background()
+	templated, fake := newTemplatedStore(t)
+	s := newTestGopassStore(slowSetStore{fake})
+	s.SetPathTemplates(templated.templates)
+
+	const n = 8
+	ids := make([]string, n)
+	var wg sync.WaitGroup
+	for i := range n {
+		wg.Go(func() {
+			id, err := s.CreateItem(ctx, "default", &ItemData{
+				Secret:     []byte("pw"),
+				Attributes: map[string]string{"service": "github.com"},
+			})
+			if err != nil {
+				t.Errorf("CreateItem: %v", err)
+			}
+			ids[i] = id
+		})
+	}
+	wg.Wait()
+
+	seen := make(map[string]bool)
+	for _, id := range ids {
+		if seen[id] {
+			t.Errorf("ID %s handed out twice: %v", id, ids)
+		}
+		seen[id] = true
+	}
+	if got := len(fake.data); got != n+1 { // the items and the collection metadata
+		t.Errorf("%d entries written, want %d: one was overwritten", got, n+1)
+	}
+}
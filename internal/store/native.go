@@ -0,0 +1,312 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ErrReadOnly is returned for writes to a collection that can't be modified
+// through the Secret Service API.
+var ErrReadOnly = errors.New("collection is read-only")
+
+// NativePathAttribute is the attribute carrying a native entry's gopass path.
+const NativePathAttribute = "gopass:path"
+
+// NativeOptions configures a NativeStore.
+type NativeOptions struct {
+	// Collection is the name the subtree is exposed under
+	Collection string
+	// Label is the collection's label; defaults to Collection
+	Label string
+	// Schema, if set, is reported as every item's xdg:schema attribute
+	Schema string
+	// Fields renames gopass keys to attributes, e.g. url -> server. Keys
+	// not listed keep their gopass name.
+	Fields map[string]string
+	// Exclude lists gopass path prefixes to leave out, such as the
+	// secret-service prefix when the subtree contains it.
+	Exclude []string
+}
+
+// NativeStore exposes a subtree of ordinary gopass entries (as created with
+// `gopass insert`) as a single read-only collection. Each entry becomes an
+// item: the first line is the secret, its key/value fields are attributes and
+// its path is the label. Metadata reads, caching, the persistent index and
+// watching are delegated to a GopassStore rooted at the subtree.
+type NativeStore struct {
+	gs   *GopassStore
+	opts NativeOptions
+}
+
+// NewNativeStore exposes the entries below gs's prefix, which must not be
+// empty. gs must not be used as a regular store at the same time.
+func NewNativeStore(gs *GopassStore, opts NativeOptions) *NativeStore {
+	if opts.Label == "" {
+		opts.Label = opts.Collection
+	}
+	return &NativeStore{gs: gs, opts: opts}
+}
+
+func (n *NativeStore) entryPath(id string) (string, error) {
+	rel, ok := parsePathID(id)
+	if !ok {
+		return "", fmt.Errorf("item not found: %s", id)
+	}
+	return n.gs.mapper.prefix + "/" + rel, nil
+}
+
+func (n *NativeStore) checkColl(name string) error {
+	if name != n.opts.Collection {
+		return fmt.Errorf("native store: unknown collection %q", name)
+	}
+	return nil
+}
+
+// entries lists the gopass paths exposed by the collection.
+func (n *NativeStore) entries(ctx context.Context) ([]string, error) {
+	all, err := n.gs.store.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var out []string
+	for _, p := range all {
+		if !strings.HasPrefix(p, n.gs.mapper.prefix+"/") || n.excluded(p) {
+			continue
+		}
+		out = append(out, p)
+	}
+	sort.Strings(out)
+	return out, nil
+}
+
+func (n *NativeStore) excluded(p string) bool {
+	for _, ex := range n.opts.Exclude {
+		if p == ex || strings.HasPrefix(p, ex+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// itemFromMeta builds an item (without its secret) from an entry's fields.
+func (n *NativeStore) itemFromMeta(p string, meta map[string]string) *ItemData {
+	rel := strings.TrimPrefix(p, n.gs.mapper.prefix+"/")
+	item := &ItemData{
+		ID:          pathID(rel),
+		Label:       p,
+		ContentType: "text/plain",
+		Attributes:  make(map[string]string, len(meta)+2),
+	}
+	for k, v := range meta {
+		if name, ok := n.opts.Fields[k]; ok {
+			k = name
+		}
+		item.Attributes[k] = v
+	}
+	if n.opts.Schema != "" {
+		item.Attributes["xdg:schema"] = n.opts.Schema
+	}
+	item.Attributes[NativePathAttribute] = p
+	if stamp, ok := n.gs.stampFor(p); ok {
+		item.Modified = time.Unix(0, stamp.ModTime)
+		item.Created = item.Modified
+	}
+	return item
+}
+
+func (n *NativeStore) Collections(ctx context.Context) ([]string, error) {
+	return []string{n.opts.Collection}, nil
+}
+
+func (n *NativeStore) GetCollection(ctx context.Context, name string) (*CollectionData, error) {
+	if err := n.checkColl(name); err != nil {
+		return nil, err
+	}
+	return &CollectionData{Name: name, Label: n.opts.Label}, nil
+}
+
+// CreateCollection accepts the collection's own name so callers that ensure
+// collections exist keep working; it never writes.
+func (n *NativeStore) CreateCollection(ctx context.Context, name, label string) error {
+	if err := n.checkColl(name); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (n *NativeStore) DeleteCollection(ctx context.Context, name string) error {
+	return ErrReadOnly
+}
+
+func (n *NativeStore) SetCollectionLabel(ctx context.Context, name, label string) error {
+	return ErrReadOnly
+}
+
+func (n *NativeStore) RenameCollection(ctx context.Context, from, to string) error {
+	return ErrReadOnly
+}
+
+func (n *NativeStore) Items(ctx context.Context, collection string) ([]string, error) {
+	if err := n.checkColl(collection); err != nil {
+		return nil, err
+	}
+	entries, err := n.entries(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, 0, len(entries))
+	for _, p := range entries {
+		ids = append(ids, pathID(strings.TrimPrefix(p, n.gs.mapper.prefix+"/")))
+	}
+	return ids, nil
+}
+
+func (n *NativeStore) GetItem(ctx context.Context, collection, id string) (*ItemData, error) {
+	if err := n.checkColl(collection); err != nil {
+		return nil, err
+	}
+	p, err := n.entryPath(id)
+	if err != nil || n.excluded(p) {
+		return nil, fmt.Errorf("item not found: %s", id)
+	}
+	stamp, stamped := n.gs.stampFor(p)
+	sec, err := n.gs.decrypt(ctx, p)
+	if err != nil {
+		return nil, fmt.Errorf("item not found: %s", id)
+	}
+	meta := metaFromSecret(sec)
+	n.gs.putMeta(p, meta, stamp, stamped)
+
+	item := n.itemFromMeta(p, meta)
+	item.Secret = []byte(sec.Password())
+	return item, nil
+}
+
+func (n *NativeStore) CreateItem(ctx context.Context, collection string, item *ItemData) (string, error) {
+	return "", ErrReadOnly
+}
+
+func (n *NativeStore) UpdateItem(ctx context.Context, collection, id string, item *ItemData) error {
+	return ErrReadOnly
+}
+
+func (n *NativeStore) DeleteItem(ctx context.Context, collection, id string) error {
+	return ErrReadOnly
+}
+
+func (n *NativeStore) MoveItem(ctx context.Context, from, id, to string) (string, error) {
+	return "", ErrReadOnly
+}
+
+func (n *NativeStore) SearchItems(ctx context.Context, collection string, attributes map[string]string) ([]*ItemData, error) {
+	if err := n.checkColl(collection); err != nil {
+		return nil, err
+	}
+	defer n.gs.saveIndexQuietly()
+
+	entries, err := n.entries(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var results []*ItemData
+	for _, p := range entries {
+		meta, err := n.gs.metaFor(ctx, p)
+		if err != nil {
+			continue
+		}
+		if item := n.itemFromMeta(p, meta); matchesAttributes(item, attributes) {
+			results = append(results, item)
+		}
+	}
+	return results, nil
+}
+
+func (n *NativeStore) SearchAllItems(ctx context.Context, attributes map[string]string) (map[string][]*ItemData, error) {
+	matches, err := n.SearchItems(ctx, n.opts.Collection, attributes)
+	if err != nil {
+		return nil, err
+	}
+	if len(matches) == 0 {
+		return map[string][]*ItemData{}, nil
+	}
+	return map[string][]*ItemData{n.opts.Collection: matches}, nil
+}
+
+func (n *NativeStore) LockCollection(ctx context.Context, name string) error {
+	return n.checkColl(name)
+}
+
+func (n *NativeStore) UnlockCollection(ctx context.Context, name string) error {
+	return n.checkColl(name)
+}
+
+// GetAlias / SetAlias are routed to the primary store by MultiStore.
+func (n *NativeStore) GetAlias(ctx context.Context, alias string) (string, error) {
+	return "", fmt.Errorf("native store has no aliases")
+}
+
+func (n *NativeStore) SetAlias(ctx context.Context, alias, collection string) error {
+	return fmt.Errorf("native store does not support SetAlias")
+}
+
+func (n *NativeStore) Close(ctx context.Context) error {
+	return n.gs.Close(ctx)
+}
+
+// Watch implements Watcher. Native entries don't follow the collection/item
+// layout, and item IDs are derived from paths anyway, so any change is
+// reported as a change of the whole collection.
+func (n *NativeStore) Watch(ctx context.Context, fn func([]Change)) error {
+	return n.gs.watchDir(ctx, n.changesFor, fn)
+}
+
+// Refresh implements Refresher.
+func (n *NativeStore) Refresh(files []string) []Change {
+	if n.gs.dir == "" {
+		return nil
+	}
+	set := make(map[string]bool, len(files))
+	for _, f := range files {
+		set[f] = true
+	}
+	return n.changesFor(set, false)
+}
+
+// changesFor invalidates the cached metadata of every touched entry and
+// reports a single collection change if anything relevant was touched.
+func (n *NativeStore) changesFor(files map[string]bool, resync bool) []Change {
+	prefix := n.gs.mapper.prefix
+	if resync {
+		n.gs.invalidateMetaPrefix(prefix)
+		return []Change{{Collection: n.opts.Collection}}
+	}
+	changed := false
+	for f := range files {
+		rel, err := filepath.Rel(n.gs.dir, f)
+		if err != nil || strings.HasPrefix(rel, "..") || strings.HasPrefix(filepath.Base(rel), ".") {
+			continue
+		}
+		p := path.Join(prefix, filepath.ToSlash(rel))
+		switch ext := path.Ext(p); ext {
+		case ".gpg", ".age":
+			p = strings.TrimSuffix(p, ext)
+			n.gs.invalidateMeta(p)
+		default:
+			// A directory: a moved subtree produces no per-entry events.
+			n.gs.invalidateMetaPrefix(p)
+		}
+		if !n.excluded(p) {
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+	return []Change{{Collection: n.opts.Collection}}
+}
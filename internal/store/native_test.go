@@ -0,0 +1,118 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func newTestNativeStore(fake *fakeGopassStore) *NativeStore {
+	return NewNativeStore(NewGopassStoreWithBackend(fake, "websites"), NativeOptions{
+		Collection: "web",
+		Schema:     "org.gnome.keyring.NetworkPassword",
+		Fields:     map[string]string{"url": "server", "username": "user"},
+		Exclude:    []string{"websites/secret-service"},
+	})
+}
+
+func TestNativeStore_ExposesEntries(t *testing.T) {
+	ctx := context.Background()
+	fake := newFakeGopassStore()
+	fake.putSecret("websites/github.com/me", "hunter2", map[string]string{"url": "github.com", "username": "me"})
+	fake.putSecret("websites/example.org", "pw", nil)
+	fake.putSecret("websites/secret-service/default/item", "hidden", nil)
+	fake.putSecret("other/entry", "elsewhere", nil)
+	n := newTestNativeStore(fake)
+
+	ids, err := n.Items(ctx, "web")
+	if err != nil {
+		t.Fatalf("Items: %v", err)
+	}
+	if len(ids) != 2 {
+		t.Fatalf("Items = %v, want the two entries outside the excluded prefix", ids)
+	}
+
+	res, err := n.SearchItems(ctx, "web", map[string]string{
+		"xdg:schema": "org.gnome.keyring.NetworkPassword",
+		"server":     "github.com",
+		"user":       "me",
+	})
+	if err != nil {
+		t.Fatalf("SearchItems: %v", err)
+	}
+	if len(res) != 1 {
+		t.Fatalf("SearchItems = %d results, want 1", len(res))
+	}
+	if res[0].Secret != nil {
+		t.Error("SearchItems returned the secret")
+	}
+	if got := res[0].Attributes[NativePathAttribute]; got != "websites/github.com/me" {
+		t.Errorf("%s = %q", NativePathAttribute, got)
+	}
+
+	item, err := n.GetItem(ctx, "web", res[0].ID)
+	if err != nil {
+		t.Fatalf("GetItem: %v", err)
+	}
+	if string(item.Secret) != "hunter2" || item.Label != "websites/github.com/me" {
+		t.Errorf("GetItem = %q (%s), want hunter2 (websites/github.com/me)", item.Secret, item.Label)
+	}
+
+	if _, err := n.GetItem(ctx, "web", pathID("secret-service/default/item")); err == nil {
+		t.Error("GetItem returned an excluded entry")
+	}
+}
+
+func TestNativeStore_RejectsWrites(t *testing.T) {
+	ctx := context.Background()
+	fake := newFakeGopassStore()
+	fake.putSecret("websites/github.com/me", "hunter2", nil)
+	n := newTestNativeStore(fake)
+	id := pathID("github.com/me")
+
+	if _, err := n.CreateItem(ctx, "web", &ItemData{Label: "new"}); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("CreateItem: err = %v, want ErrReadOnly", err)
+	}
+	if err := n.UpdateItem(ctx, "web", id, &ItemData{Label: "changed"}); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("UpdateItem: err = %v, want ErrReadOnly", err)
+	}
+	if err := n.DeleteItem(ctx, "web", id); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("DeleteItem: err = %v, want ErrReadOnly", err)
+	}
+	if err := n.DeleteCollection(ctx, "web"); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("DeleteCollection: err = %v, want ErrReadOnly", err)
+	}
+	if err := n.SetCollectionLabel(ctx, "web", "x"); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("SetCollectionLabel: err = %v, want ErrReadOnly", err)
+	}
+	if _, ok := fake.data["websites/github.com/me"]; !ok || len(fake.data) != 1 {
+		t.Error("a rejected write modified the store")
+	}
+}
+
+func TestNativeStore_WatchReportsCollectionChange(t *testing.T) {
+	dir := t.TempDir()
+	n := newTestNativeStore(newFakeGopassStore())
+	n.gs.SetDir(dir)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	batches := make(chan []Change, 16)
+	if err := n.Watch(ctx, func(c []Change) { batches <- c }); err != nil {
+		t.Fatalf("Watch: %v", err)
+	}
+
+	// Secret-service entries inside the subtree aren't ours to report.
+	if err := os.MkdirAll(filepath.Join(dir, "secret-service"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	writeEntry(t, filepath.Join(dir, "secret-service", "default", "item.gpg"))
+	writeEntry(t, filepath.Join(dir, "github.com", "me.gpg"))
+
+	got := nextBatch(t, batches)
+	if len(got) != 1 || got[0] != (Change{Collection: "web"}) {
+		t.Errorf("changes = %+v, want a single change of collection web", got)
+	}
+}
@@ -0,0 +1,215 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Op is how a Condition compares an attribute.
+type Op string
+
+const (
+	// OpEqual matches an attribute equal to the value, like SearchItems.
+	OpEqual Op = "eq"
+	// OpPrefix matches an attribute starting with the value.
+	OpPrefix Op = "prefix"
+	// OpGlob matches an attribute against a whole-value pattern in which *
+	// matches any run of characters and ? any single one.
+	OpGlob Op = "glob"
+	// OpRegex matches an attribute containing a match of a regular
+	// expression (RE2 syntax).
+	OpRegex Op = "regex"
+	// OpExists matches items that have the attribute; the value is unused.
+	OpExists Op = "exists"
+	// OpMissing matches items that don't have the attribute; the value is
+	// unused.
+	OpMissing Op = "missing"
+	// OpHost matches an attribute holding a URL or host name whose host is
+	// the value's, ignoring case, scheme, port, path and a trailing dot.
+	OpHost Op = "host"
+)
+
+// Condition is one test of a Query on an item's attribute.
+type Condition struct {
+	Key   string
+	Op    Op
+	Value string
+}
+
+// ParseCondition parses the command-line form of a condition:
+//
+//	key=value   OpEqual
+//	key^=value  OpPrefix
+//	key~=glob   OpGlob
+//	key=~regex  OpRegex
+//	key@=host   OpHost
+//	key?        OpExists
+//	!key        OpMissing
+func ParseCondition(s string) (Condition, error) {
+	key, value, ok := strings.Cut(s, "=")
+	if !ok {
+		switch {
+		case strings.HasSuffix(s, "?") && len(s) > 1:
+			return Condition{Key: strings.TrimSuffix(s, "?"), Op: OpExists}, nil
+		case strings.HasPrefix(s, "!") && len(s) > 1:
+			return Condition{Key: strings.TrimPrefix(s, "!"), Op: OpMissing}, nil
+		}
+		return Condition{}, fmt.Errorf("invalid condition %q", s)
+	}
+	c := Condition{Key: key, Op: OpEqual, Value: value}
+	switch {
+	case strings.HasPrefix(value, "~"):
+		c.Op, c.Value = OpRegex, value[1:]
+	case strings.HasSuffix(key, "^"):
+		c.Op, c.Key = OpPrefix, key[:len(key)-1]
+	case strings.HasSuffix(key, "~"):
+		c.Op, c.Key = OpGlob, key[:len(key)-1]
+	case strings.HasSuffix(key, "@"):
+		c.Op, c.Key = OpHost, key[:len(key)-1]
+	}
+	if c.Key == "" {
+		return Condition{}, fmt.Errorf("invalid condition %q: no attribute", s)
+	}
+	return c, nil
+}
+
+// Query matches items whose attributes satisfy all of its conditions.
+type Query struct {
+	conds []Condition
+	// res holds the compiled pattern of each glob and regex condition.
+	res []*regexp.Regexp
+}
+
+// CompileQuery checks the conditions and compiles their patterns.
+func CompileQuery(conds []Condition) (*Query, error) {
+	q := &Query{conds: conds, res: make([]*regexp.Regexp, len(conds))}
+	for i, c := range conds {
+		var err error
+		switch c.Op {
+		case OpEqual, OpPrefix, OpExists, OpMissing:
+		case OpHost:
+			if normalizeHost(c.Value) == "" {
+				err = fmt.Errorf("no host in %q", c.Value)
+			}
+		case OpGlob:
+			q.res[i], err = regexp.Compile(globPattern(c.Value))
+		case OpRegex:
+			q.res[i], err = regexp.Compile(c.Value)
+		default:
+			err = fmt.Errorf("unknown operator %q", c.Op)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("condition on %s: %w", c.Key, err)
+		}
+	}
+	return q, nil
+}
+
+// Matches reports whether attrs satisfy every condition of q.
+func (q *Query) Matches(attrs map[string]string) bool {
+	for i, c := range q.conds {
+		v, ok := attrs[c.Key]
+		var match bool
+		switch c.Op {
+		case OpEqual:
+			match = ok && v == c.Value
+		case OpPrefix:
+			match = ok && strings.HasPrefix(v, c.Value)
+		case OpGlob, OpRegex:
+			match = ok && q.res[i].MatchString(v)
+		case OpExists:
+			match = ok
+		case OpMissing:
+			match = !ok
+		case OpHost:
+			match = ok && normalizeHost(v) == normalizeHost(c.Value)
+		}
+		if !match {
+			return false
+		}
+	}
+	return true
+}
+
+// exact returns the equality conditions, which every store can search by.
+func (q *Query) exact() map[string]string {
+	attrs := make(map[string]string)
+	for _, c := range q.conds {
+		if c.Op == OpEqual {
+			attrs[c.Key] = c.Value
+		}
+	}
+	return attrs
+}
+
+// SearchQuery returns the items of collection, or of every collection if it
+// is empty, that match q, by collection. The store is searched by the
+// equality conditions, so an attribute index still narrows the search, and
+// the results are filtered by the rest.
+func SearchQuery(ctx context.Context, s Store, collection string, q *Query) (map[string][]*ItemData, error) {
+	var results map[string][]*ItemData
+	if collection == "" {
+		var err error
+		if results, err = s.SearchAllItems(ctx, q.exact()); err != nil {
+			return nil, err
+		}
+	} else {
+		items, err := s.SearchItems(ctx, collection, q.exact())
+		if err != nil {
+			return nil, err
+		}
+		results = map[string][]*ItemData{collection: items}
+	}
+
+	out := make(map[string][]*ItemData)
+	for coll, items := range results {
+		for _, item := range items {
+			if q.Matches(item.Attributes) {
+				out[coll] = append(out[coll], item)
+			}
+		}
+	}
+	return out, nil
+}
+
+// globPattern translates a glob into an anchored regular expression.
+func globPattern(glob string) string {
+	var b strings.Builder
+	b.WriteString("^")
+	for _, r := range glob {
+		switch r {
+		case '*':
+			b.WriteString(".*")
+		case '?':
+			b.WriteString(".")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	b.WriteString("$")
+	return b.String()
+}
+
+// normalizeHost returns the lower-cased host of a URL or a bare host name
+// with an optional port, without a trailing dot; "" if there is none.
+func normalizeHost(v string) string {
+	host := v
+	if strings.Contains(v, "://") {
+		u, err := url.Parse(v)
+		if err != nil {
+			return ""
+		}
+		host = u.Host
+	} else if i := strings.IndexAny(host, "/?#"); i >= 0 {
+		host = host[:i]
+	}
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.TrimPrefix(strings.TrimSuffix(host, "]"), "[")
+	return strings.TrimSuffix(strings.ToLower(host), ".")
+}
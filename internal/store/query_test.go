@@ -0,0 +1,117 @@
+package store
+
+import (
+	"context"
+	"testing"
+)
+
+func TestParseCondition(t *testing.T) {
+	for _, tc := range []struct {
+		in   string
+		want Condition
+	}{
+		{"service=github", Condition{"service", OpEqual, "github"}},
+		{"service=", Condition{"service", OpEqual, ""}},
+		{"service^=https://gitlab.", Condition{"service", OpPrefix, "https://gitlab."}},
+		{"service~=*.example.com", Condition{"service", OpGlob, "*.example.com"}},
+		{"user=~^ad(min|m)$", Condition{"user", OpRegex, "^ad(min|m)$"}},
+		{"url@=Example.com", Condition{"url", OpHost, "Example.com"}},
+		{"username?", Condition{"username", OpExists, ""}},
+		{"!username", Condition{"username", OpMissing, ""}},
+		{"a=b=c", Condition{"a", OpEqual, "b=c"}},
+	} {
+		got, err := ParseCondition(tc.in)
+		if err != nil || got != tc.want {
+			t.Errorf("ParseCondition(%q) = %+v, %v; want %+v", tc.in, got, err, tc.want)
+		}
+	}
+	for _, bad := range []string{"service", "=x", "^=x", "?", "!"} {
+		if c, err := ParseCondition(bad); err == nil {
+			t.Errorf("ParseCondition(%q) = %+v, want an error", bad, c)
+		}
+	}
+}
+
+func TestQuery_Matches(t *testing.T) {
+	attrs := map[string]string{
+		"service":  "https://GitLab.example.com:8443/group/repo",
+		"username": "admin",
+	}
+	for _, tc := range []struct {
+		cond Condition
+		want bool
+	}{
+		{Condition{"username", OpEqual, "admin"}, true},
+		{Condition{"username", OpEqual, "adm"}, false},
+		{Condition{"service", OpPrefix, "https://GitLab."}, true},
+		{Condition{"service", OpPrefix, "https://github."}, false},
+		{Condition{"service", OpGlob, "https://*.example.com*"}, true},
+		{Condition{"service", OpGlob, "*.example.com"}, false},
+		{Condition{"username", OpGlob, "ad?in"}, true},
+		{Condition{"username", OpRegex, "dmi"}, true},
+		{Condition{"username", OpRegex, "^dmi"}, false},
+		{Condition{"username", OpExists, ""}, true},
+		{Condition{"password", OpExists, ""}, false},
+		{Condition{"password", OpMissing, ""}, true},
+		{Condition{"username", OpMissing, ""}, false},
+		{Condition{"service", OpHost, "gitlab.example.com"}, true},
+		{Condition{"service", OpHost, "https://gitlab.example.com./other"}, true},
+		{Condition{"service", OpHost, "example.com"}, false},
+		{Condition{"missing", OpPrefix, ""}, false},
+	} {
+		q, err := CompileQuery([]Condition{tc.cond})
+		if err != nil {
+			t.Fatalf("CompileQuery(%+v): %v", tc.cond, err)
+		}
+		if got := q.Matches(attrs); got != tc.want {
+			t.Errorf("%+v matches = %v, want %v", tc.cond, got, tc.want)
+		}
+	}
+
+	for _, bad := range []Condition{
+		{"x", OpRegex, "("},
+		{"x", OpHost, ""},
+		{"x", "like", "y"},
+	} {
+		if _, err := CompileQuery([]Condition{bad}); err == nil {
+			t.Errorf("CompileQuery(%+v) succeeded", bad)
+		}
+	}
+}
+
+func TestSearchQuery(t *testing.T) {
+	ctx := context.Background()
+	s := newTestGopassStore(newFakeGopassStore())
+	for _, item := range []struct{ coll, service, user string }{
+		{"default", "https://gitlab.example.com", "me"},
+		{"default", "https://gitlab.example.com", ""},
+		{"default", "https://github.com", "me"},
+		{"work", "https://gitlab.work.org/", "me"},
+	} {
+		attrs := map[string]string{"service": item.service}
+		if item.user != "" {
+			attrs["username"] = item.user
+		}
+		if _, err := s.CreateItem(ctx, item.coll, &ItemData{Secret: []byte("x"), Attributes: attrs}); err != nil {
+			t.Fatalf("CreateItem: %v", err)
+		}
+	}
+
+	q, err := CompileQuery([]Condition{
+		{"service", OpPrefix, "https://gitlab."},
+		{"username", OpExists, ""},
+	})
+	if err != nil {
+		t.Fatalf("CompileQuery: %v", err)
+	}
+	res, err := SearchQuery(ctx, s, "", q)
+	if err != nil {
+		t.Fatalf("SearchQuery: %v", err)
+	}
+	if len(res["default"]) != 1 || len(res["work"]) != 1 {
+		t.Errorf("SearchQuery = %v, want one item in each collection", res)
+	}
+	if res, _ := SearchQuery(ctx, s, "work", q); len(res) != 1 || len(res["work"]) != 1 {
+		t.Errorf("SearchQuery(work) = %v", res)
+	}
+}
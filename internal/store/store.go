@@ -2,6 +2,7 @@ package store
 
 import (
 	"context"
+	"errors"
 	"time"
 )
 
@@ -28,6 +29,9 @@ type ItemData struct {
 	// Modified is the last modification timestamp
 	Modified time.Time
 
+	// Expires is when the item is due for deletion; zero for never
+	Expires time.Time
+
 	// Locked indicates if the item is locked
 	Locked bool
 }
@@ -67,13 +71,21 @@ type Store interface {
 	// SetCollectionLabel updates a collection's label
 	SetCollectionLabel(ctx context.Context, name, label string) error
 
+	// RenameCollection renames a collection with its items; the new name
+	// must not be taken. Aliases are left to the caller.
+	RenameCollection(ctx context.Context, from, to string) error
+
 	// Items returns all item IDs in a collection
 	Items(ctx context.Context, collection string) ([]string, error)
 
-	// GetItem returns an item by collection and ID
+	// GetItem returns an item by collection and ID. The Secret is the
+	// caller's own copy, which it wipes when done.
 	GetItem(ctx context.Context, collection, id string) (*ItemData, error)
 
-	// CreateItem creates a new item in a collection
+	// CreateItem creates a new item in a collection. Zero Created and
+	// Modified times are set to now; others are kept, for imports. The
+	// store must not keep item.Secret, which the caller may wipe once this
+	// returns; the same goes for UpdateItem.
 	CreateItem(ctx context.Context, collection string, item *ItemData) (string, error)
 
 	// UpdateItem updates an existing item
@@ -82,6 +94,10 @@ type Store interface {
 	// DeleteItem deletes an item
 	DeleteItem(ctx context.Context, collection, id string) error
 
+	// MoveItem moves an item to another collection, creating it if needed,
+	// and returns the item's ID there. The item keeps its Created time.
+	MoveItem(ctx context.Context, from, id, to string) (string, error)
+
 	// SearchItems searches for items matching the given attributes
 	SearchItems(ctx context.Context, collection string, attributes map[string]string) ([]*ItemData, error)
 
@@ -103,3 +119,14 @@ type Store interface {
 	// Close closes the store
 	Close(ctx context.Context) error
 }
+
+// ErrAliasesUnsupported is returned by Aliases when the store can't
+// enumerate its aliases.
+var ErrAliasesUnsupported = errors.New("alias listing is not supported by this store")
+
+// AliasLister is implemented by stores that can enumerate their aliases.
+type AliasLister interface {
+	// Aliases returns every alias set with SetAlias, mapped to its
+	// collection. The implicit "default" alias is only included if set.
+	Aliases(ctx context.Context) (map[string]string, error)
+}
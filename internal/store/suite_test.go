@@ -0,0 +1,324 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testDurableStore is the behavioral contract shared by the durable backends.
+// Every durable Store must pass it; backend specifics (caching, files on
+// disk) are tested separately.
+func testDurableStore(t *testing.T, newStore func(t *testing.T) Store) {
+	ctx := context.Background()
+
+	t.Run("CreateGetRoundTrip", func(t *testing.T) {
+		s := newStore(t)
+		for _, tc := range []struct {
+			name        string
+			secret      []byte
+			contentType string
+		}{
+			{"single line", []byte("hunter2"), "text/plain"},
+			{"multi-line", []byte("line one\nline two\n\nkey: not-an-attribute\n"), "text/plain"},
+			{"binary", []byte{0x00, 0xff, '\r', '\n', 0x80}, "application/octet-stream"},
+		} {
+			id, err := s.CreateItem(ctx, "default", &ItemData{
+				Label:       tc.name,
+				Secret:      tc.secret,
+				ContentType: tc.contentType,
+				Attributes:  map[string]string{"service": tc.name},
+			})
+			if err != nil {
+				t.Fatalf("%s: CreateItem: %v", tc.name, err)
+			}
+			got, err := s.GetItem(ctx, "default", id)
+			if err != nil {
+				t.Fatalf("%s: GetItem: %v", tc.name, err)
+			}
+			if !bytes.Equal(got.Secret, tc.secret) || got.Label != tc.name || got.ContentType != tc.contentType {
+				t.Errorf("%s: got %q (%s, %s)", tc.name, got.Secret, got.Label, got.ContentType)
+			}
+			if got.Attributes["service"] != tc.name {
+				t.Errorf("%s: attributes = %v", tc.name, got.Attributes)
+			}
+			if got.Created.IsZero() || got.Modified.IsZero() {
+				t.Errorf("%s: timestamps not set", tc.name)
+			}
+		}
+	})
+
+	t.Run("UpdatePreservesCreated", func(t *testing.T) {
+		s := newStore(t)
+		id, err := s.CreateItem(ctx, "default", &ItemData{Label: "a", Secret: []byte("v1")})
+		if err != nil {
+			t.Fatalf("CreateItem: %v", err)
+		}
+		before, err := s.GetItem(ctx, "default", id)
+		if err != nil {
+			t.Fatalf("GetItem: %v", err)
+		}
+		if err := s.UpdateItem(ctx, "default", id, &ItemData{Label: "b", Secret: []byte("v2"), Attributes: map[string]string{"k": "v"}}); err != nil {
+			t.Fatalf("UpdateItem: %v", err)
+		}
+		after, err := s.GetItem(ctx, "default", id)
+		if err != nil {
+			t.Fatalf("GetItem: %v", err)
+		}
+		if string(after.Secret) != "v2" || after.Label != "b" || after.Attributes["k"] != "v" {
+			t.Errorf("after update: %q %s %v", after.Secret, after.Label, after.Attributes)
+		}
+		if !after.Created.Equal(before.Created) {
+			t.Errorf("Created changed: %v -> %v", before.Created, after.Created)
+		}
+		if err := s.UpdateItem(ctx, "default", "missing", &ItemData{}); err == nil {
+			t.Error("UpdateItem of a missing item succeeded")
+		}
+	})
+
+	t.Run("ItemsAndDelete", func(t *testing.T) {
+		s := newStore(t)
+		var ids []string
+		for _, label := range []string{"one", "two"} {
+			id, err := s.CreateItem(ctx, "default", &ItemData{Label: label, Secret: []byte(label)})
+			if err != nil {
+				t.Fatalf("CreateItem: %v", err)
+			}
+			ids = append(ids, id)
+		}
+		got, err := s.Items(ctx, "default")
+		if err != nil {
+			t.Fatalf("Items: %v", err)
+		}
+		sort.Strings(got)
+		sort.Strings(ids)
+		if len(got) != 2 || got[0] != ids[0] || got[1] != ids[1] {
+			t.Errorf("Items = %v, want %v", got, ids)
+		}
+
+		if err := s.DeleteItem(ctx, "default", ids[0]); err != nil {
+			t.Fatalf("DeleteItem: %v", err)
+		}
+		if _, err := s.GetItem(ctx, "default", ids[0]); err == nil {
+			t.Error("GetItem of a deleted item succeeded")
+		}
+		if got, _ := s.Items(ctx, "default"); len(got) != 1 || got[0] != ids[1] {
+			t.Errorf("Items after delete = %v, want [%s]", got, ids[1])
+		}
+	})
+
+	t.Run("Search", func(t *testing.T) {
+		s := newStore(t)
+		for _, item := range []struct{ coll, service, user string }{
+			{"default", "github", "me"},
+			{"default", "github", "you"},
+			{"work", "github", "me"},
+			{"work", "gitlab", "me"},
+		} {
+			if _, err := s.CreateItem(ctx, item.coll, &ItemData{
+				Secret:     []byte(item.service + item.user),
+				Attributes: map[string]string{"service": item.service, "user": item.user},
+			}); err != nil {
+				t.Fatalf("CreateItem: %v", err)
+			}
+		}
+
+		res, err := s.SearchItems(ctx, "default", map[string]string{"service": "github", "user": "me"})
+		if err != nil {
+			t.Fatalf("SearchItems: %v", err)
+		}
+		if len(res) != 1 || res[0].Attributes["user"] != "me" {
+			t.Errorf("SearchItems = %+v, want one match", res)
+		}
+		if all, _ := s.SearchItems(ctx, "default", nil); len(all) != 2 {
+			t.Errorf("SearchItems with no attributes = %d results, want 2", len(all))
+		}
+
+		byColl, err := s.SearchAllItems(ctx, map[string]string{"user": "me"})
+		if err != nil {
+			t.Fatalf("SearchAllItems: %v", err)
+		}
+		if len(byColl["default"]) != 1 || len(byColl["work"]) != 2 {
+			t.Errorf("SearchAllItems = %v", byColl)
+		}
+	})
+
+	t.Run("Expiry", func(t *testing.T) {
+		s := newStore(t)
+		id, err := s.CreateItem(ctx, "default", &ItemData{
+			Secret:     []byte("x"),
+			Attributes: map[string]string{TTLAttribute: "1h"},
+		})
+		if err != nil {
+			t.Fatalf("CreateItem: %v", err)
+		}
+		item, err := s.GetItem(ctx, "default", id)
+		if err != nil {
+			t.Fatalf("GetItem: %v", err)
+		}
+		if left := time.Until(item.Expires); left < 59*time.Minute || left > time.Hour+time.Second {
+			t.Errorf("Expires = %v, want in an hour", item.Expires)
+		}
+		if res, _ := s.SearchItems(ctx, "default", nil); len(res) != 1 || !res[0].Expires.Equal(item.Expires) {
+			t.Errorf("SearchItems doesn't report Expires: %+v", res)
+		}
+
+		// An update without a lifetime keeps the expiry it's given.
+		expires := time.Now().Add(24 * time.Hour).Truncate(time.Second)
+		if err := s.UpdateItem(ctx, "default", id, &ItemData{Secret: []byte("y"), Expires: expires}); err != nil {
+			t.Fatalf("UpdateItem: %v", err)
+		}
+		if item, _ := s.GetItem(ctx, "default", id); !item.Expires.Equal(expires) {
+			t.Errorf("Expires after update = %v, want %v", item.Expires, expires)
+		}
+
+		if _, err := s.CreateItem(ctx, "default", &ItemData{Attributes: map[string]string{TTLAttribute: "soon"}}); err == nil {
+			t.Error("CreateItem with an invalid TTL succeeded")
+		}
+	})
+
+	t.Run("Collections", func(t *testing.T) {
+		s := newStore(t)
+		if err := s.CreateCollection(ctx, "login", "Login"); err != nil {
+			t.Fatalf("CreateCollection: %v", err)
+		}
+		if _, err := s.CreateItem(ctx, "auto", &ItemData{Secret: []byte("x")}); err != nil {
+			t.Fatalf("CreateItem in a new collection: %v", err)
+		}
+		names, err := s.Collections(ctx)
+		if err != nil {
+			t.Fatalf("Collections: %v", err)
+		}
+		if len(names) != 2 || names[0] != "auto" || names[1] != "login" {
+			t.Errorf("Collections = %v, want [auto login]", names)
+		}
+
+		if err := s.SetCollectionLabel(ctx, "login", "Renamed"); err != nil {
+			t.Fatalf("SetCollectionLabel: %v", err)
+		}
+		coll, err := s.GetCollection(ctx, "login")
+		if err != nil || coll.Label != "Renamed" {
+			t.Errorf("GetCollection = %+v, %v", coll, err)
+		}
+
+		if err := s.LockCollection(ctx, "login"); err != nil {
+			t.Fatalf("LockCollection: %v", err)
+		}
+		if coll, _ := s.GetCollection(ctx, "login"); !coll.Locked {
+			t.Error("collection not locked")
+		}
+		if err := s.UnlockCollection(ctx, "login"); err != nil {
+			t.Fatalf("UnlockCollection: %v", err)
+		}
+
+		if err := s.DeleteCollection(ctx, "auto"); err != nil {
+			t.Fatalf("DeleteCollection: %v", err)
+		}
+		if _, err := s.GetCollection(ctx, "auto"); err == nil {
+			t.Error("GetCollection of a deleted collection succeeded")
+		}
+		if ids, _ := s.Items(ctx, "auto"); len(ids) != 0 {
+			t.Errorf("deleted collection still has items %v", ids)
+		}
+	})
+
+	t.Run("MoveAndRename", func(t *testing.T) {
+		s := newStore(t)
+		id, err := s.CreateItem(ctx, "default", &ItemData{Label: "a", Secret: []byte("v"), Attributes: map[string]string{"k": "v"}})
+		if err != nil {
+			t.Fatalf("CreateItem: %v", err)
+		}
+		before, _ := s.GetItem(ctx, "default", id)
+
+		newID, err := s.MoveItem(ctx, "default", id, "work")
+		if err != nil {
+			t.Fatalf("MoveItem: %v", err)
+		}
+		if _, err := s.GetItem(ctx, "default", id); err == nil {
+			t.Error("moved item still in its old collection")
+		}
+		moved, err := s.GetItem(ctx, "work", newID)
+		if err != nil {
+			t.Fatalf("GetItem after move: %v", err)
+		}
+		if string(moved.Secret) != "v" || moved.Attributes["k"] != "v" || !moved.Created.Equal(before.Created) {
+			t.Errorf("moved item = %+v, want %+v", moved, before)
+		}
+		if _, err := s.MoveItem(ctx, "default", id, "work"); err == nil {
+			t.Error("MoveItem of a missing item succeeded")
+		}
+
+		if err := s.CreateCollection(ctx, "taken", "Taken"); err != nil {
+			t.Fatalf("CreateCollection: %v", err)
+		}
+		if err := s.RenameCollection(ctx, "work", "taken"); err == nil {
+			t.Error("RenameCollection onto an existing collection succeeded")
+		}
+		if err := s.RenameCollection(ctx, "work", "job"); err != nil {
+			t.Fatalf("RenameCollection: %v", err)
+		}
+		if _, err := s.GetCollection(ctx, "work"); err == nil {
+			t.Error("renamed collection still exists under its old name")
+		}
+		if item, err := s.GetItem(ctx, "job", newID); err != nil || string(item.Secret) != "v" {
+			t.Errorf("item after rename: %+v, %v", item, err)
+		}
+		if err := s.RenameCollection(ctx, "missing", "other"); err == nil {
+			t.Error("RenameCollection of a missing collection succeeded")
+		}
+	})
+
+	t.Run("Aliases", func(t *testing.T) {
+		s := newStore(t)
+		if got, err := s.GetAlias(ctx, "default"); err != nil || got != "default" {
+			t.Errorf("GetAlias(default) = %q, %v; want the implicit default", got, err)
+		}
+		if _, err := s.GetAlias(ctx, "login"); err == nil {
+			t.Error("GetAlias of an unset alias succeeded")
+		}
+		if err := s.SetAlias(ctx, "login", "personal"); err != nil {
+			t.Fatalf("SetAlias: %v", err)
+		}
+		if got, err := s.GetAlias(ctx, "login"); err != nil || got != "personal" {
+			t.Errorf("GetAlias(login) = %q, %v", got, err)
+		}
+		if err := s.SetAlias(ctx, "login", ""); err != nil {
+			t.Fatalf("SetAlias remove: %v", err)
+		}
+		if _, err := s.GetAlias(ctx, "login"); err == nil {
+			t.Error("removed alias still resolves")
+		}
+	})
+
+	t.Run("ListAliases", func(t *testing.T) {
+		s := newStore(t)
+		// SetAlias is a read-modify-write of the whole table; concurrent
+		// callers must not lose each other's aliases.
+		var wg sync.WaitGroup
+		for i := range 10 {
+			wg.Go(func() {
+				if err := s.SetAlias(ctx, fmt.Sprintf("alias%d", i), "personal"); err != nil {
+					t.Errorf("SetAlias: %v", err)
+				}
+			})
+		}
+		wg.Wait()
+		aliases, err := s.(AliasLister).Aliases(ctx)
+		if err != nil {
+			t.Fatalf("Aliases: %v", err)
+		}
+		if len(aliases) != 10 || aliases["alias3"] != "personal" {
+			t.Errorf("Aliases = %v, want alias0..alias9", aliases)
+		}
+	})
+}
+
+func TestGopassStore_Suite(t *testing.T) {
+	testDurableStore(t, func(t *testing.T) Store {
+		return newTestGopassStore(newFakeGopassStore())
+	})
+}
@@ -0,0 +1,54 @@
+// Package store, ttl.go: item lifetimes, set by clients through an attribute
+// or by configured per-schema rules.
+package store
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// TTLAttribute gives an item a lifetime, as a Go duration ("90m") or a number
+// of seconds. It overrides the configured TTL rules.
+const TTLAttribute = "gopass:ttl"
+
+// TTLRules gives items a lifetime by their xdg:schema attribute; the "*" entry
+// applies to every other schema.
+type TTLRules map[string]time.Duration
+
+// For returns the lifetime of item, 0 for none.
+func (r TTLRules) For(item *ItemData) (time.Duration, error) {
+	if v, ok := item.Attributes[TTLAttribute]; ok {
+		return ParseTTL(v)
+	}
+	if ttl, ok := r[item.Attributes["xdg:schema"]]; ok {
+		return ttl, nil
+	}
+	return r["*"], nil
+}
+
+// applyTTL sets item.Expires from its lifetime under rules. Items without a
+// lifetime keep the Expires they came with. Lifetimes are rounded up to whole
+// seconds, the resolution of kernel key timeouts.
+func applyTTL(item *ItemData, rules TTLRules) error {
+	ttl, err := rules.For(item)
+	if err != nil {
+		return err
+	}
+	if ttl > 0 {
+		item.Expires = time.Now().Add((ttl + time.Second - 1).Truncate(time.Second))
+	}
+	return nil
+}
+
+// ParseTTL parses the value of TTLAttribute.
+func ParseTTL(v string) (time.Duration, error) {
+	if secs, err := strconv.ParseUint(v, 10, 32); err == nil {
+		return time.Duration(secs) * time.Second, nil
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid %s %q", TTLAttribute, v)
+	}
+	return d, nil
+}
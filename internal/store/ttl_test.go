@@ -0,0 +1,35 @@
+package store
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTTLRules_For(t *testing.T) {
+	rules := TTLRules{"org.example.Token": time.Hour, "*": time.Minute}
+	for _, tc := range []struct {
+		attrs map[string]string
+		want  time.Duration
+	}{
+		{map[string]string{"xdg:schema": "org.example.Token"}, time.Hour},
+		{map[string]string{"xdg:schema": "other"}, time.Minute},
+		{nil, time.Minute},
+		{map[string]string{"xdg:schema": "org.example.Token", TTLAttribute: "90"}, 90 * time.Second},
+		{map[string]string{TTLAttribute: "2h30m"}, 150 * time.Minute},
+		{map[string]string{TTLAttribute: "0"}, 0},
+	} {
+		got, err := rules.For(&ItemData{Attributes: tc.attrs})
+		if err != nil || got != tc.want {
+			t.Errorf("For(%v) = %v, %v; want %v", tc.attrs, got, err, tc.want)
+		}
+	}
+
+	if ttl, _ := TTLRules(nil).For(&ItemData{}); ttl != 0 {
+		t.Errorf("no rules: TTL = %v, want 0", ttl)
+	}
+	for _, v := range []string{"soon", "-1h", ""} {
+		if _, err := ParseTTL(v); err == nil {
+			t.Errorf("ParseTTL(%q) succeeded", v)
+		}
+	}
+}
@@ -0,0 +1,341 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Change describes a stored entry that was modified behind the daemon's back,
+// e.g. by `gopass edit` or a `git pull` inside the password store.
+type Change struct {
+	Collection string
+	// ItemID is empty for collection-level changes (metadata edits, removal)
+	ItemID string
+	// Removed is set when the item, or for collection-level changes the
+	// whole collection, no longer exists
+	Removed bool
+}
+
+// IsResync reports whether the change stands for "anything may have changed":
+// individual changes were lost and the caller should re-read everything.
+func (c Change) IsResync() bool {
+	return c.Collection == ""
+}
+
+// Watcher is implemented by stores that can report out-of-process changes.
+type Watcher interface {
+	// Watch delivers batches of changes to fn until ctx is cancelled. fn is
+	// called from a single goroutine, one batch at a time.
+	Watch(ctx context.Context, fn func([]Change)) error
+}
+
+// Refresher is implemented by stores that can re-read entries whose files are
+// known to have changed, e.g. after a git pull, without watching for them.
+type Refresher interface {
+	// Refresh drops cached state for the given files (absolute paths) and
+	// returns the resulting changes.
+	Refresh(files []string) []Change
+}
+
+const (
+	// watchDebounce is how long the tree must be quiet before a batch is
+	// delivered; a git pull touches many files in quick succession.
+	watchDebounce = 300 * time.Millisecond
+	// watchMaxDelay caps how long a continuous stream of events can hold a
+	// batch back.
+	watchMaxDelay = 2 * time.Second
+	// selfWriteWindow is how long after one of our own writes events for the
+	// same path are treated as echoes rather than external changes.
+	selfWriteWindow = watchMaxDelay + time.Second
+)
+
+// Watch implements Watcher by fanning out to every underlying store that
+// supports it. Each store's changes are filtered to the collections that
+// route to it.
+func (m *MultiStore) Watch(ctx context.Context, fn func([]Change)) error {
+	var errs []error
+	watching := 0
+	for _, s := range m.stores() {
+		w, ok := s.(Watcher)
+		if !ok {
+			continue
+		}
+		err := w.Watch(ctx, func(changes []Change) {
+			if owned := m.ownedChanges(s, changes); len(owned) > 0 {
+				fn(owned)
+			}
+		})
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		watching++
+	}
+	if watching == 0 && len(errs) == 0 {
+		return errors.New("no store supports watching")
+	}
+	return errors.Join(errs...)
+}
+
+// Refresh implements Refresher by fanning out to every underlying store that
+// supports it.
+func (m *MultiStore) Refresh(files []string) []Change {
+	var changes []Change
+	for _, s := range m.stores() {
+		if r, ok := s.(Refresher); ok {
+			changes = append(changes, m.ownedChanges(s, r.Refresh(files))...)
+		}
+	}
+	return changes
+}
+
+// ownedChanges filters changes reported by s to the collections that route
+// to it. Several stores can share a directory tree (different prefixes on one
+// mount), and each sees the others' files.
+func (m *MultiStore) ownedChanges(s Store, changes []Change) []Change {
+	owned := changes[:0:0]
+	for _, c := range changes {
+		if c.IsResync() || m.routeByCollection(c.Collection) == s {
+			owned = append(owned, c)
+		}
+	}
+	return owned
+}
+
+// SetDir tells the store where its prefix lives on disk. It is required for
+// Watch and EnableIndex; gopass itself only exposes entries by name.
+func (s *GopassStore) SetDir(dir string) {
+	s.dir = dir
+}
+
+// Dir returns the store's on-disk directory set by SetDir.
+func (s *GopassStore) Dir() string {
+	return s.dir
+}
+
+// Watch implements Watcher using inotify on the store's prefix directory.
+func (s *GopassStore) Watch(ctx context.Context, fn func([]Change)) error {
+	return s.watchDir(ctx, s.changesFor, fn)
+}
+
+// watchDir watches the store's directory, turning each debounced batch of
+// touched files into changes with changesFor.
+func (s *GopassStore) watchDir(ctx context.Context, changesFor func(files map[string]bool, resync bool) []Change, fn func([]Change)) error {
+	if s.dir == "" {
+		return fmt.Errorf("gopass store %s: directory unknown", s.mapper.prefix)
+	}
+	// The prefix directory only appears with the first entry; create it so
+	// there is something to watch from the start.
+	if err := os.MkdirAll(s.dir, 0o700); err != nil {
+		return fmt.Errorf("create %s: %w", s.dir, err)
+	}
+	w, err := newDirWatcher(s.dir)
+	if err != nil {
+		return fmt.Errorf("watch %s: %w", s.dir, err)
+	}
+	s.setListingWatched(true)
+	go func() {
+		<-ctx.Done()
+		s.setListingWatched(false)
+		w.Close()
+	}()
+	go watchLoop(ctx, w, changesFor, fn)
+	return nil
+}
+
+// Refresh implements Refresher. Files outside the store's directory are
+// ignored.
+func (s *GopassStore) Refresh(files []string) []Change {
+	if s.dir == "" {
+		return nil
+	}
+	set := make(map[string]bool, len(files))
+	for _, f := range files {
+		set[f] = true
+	}
+	return s.changesFor(set, false)
+}
+
+// SetWriteHook registers fn to be called after each write the store makes,
+// e.g. to schedule a git push. It must be set before the store is used.
+func (s *GopassStore) SetWriteHook(fn func()) {
+	s.onWrite = fn
+}
+
+// watchLoop debounces raw filesystem events into batches of changes. It runs
+// until the watcher's event channel is closed.
+func watchLoop(ctx context.Context, w *dirWatcher, changesFor func(map[string]bool, bool) []Change, fn func([]Change)) {
+	pending := make(map[string]bool)
+	resync := false
+	var first time.Time
+	timer := time.NewTimer(time.Hour)
+	timer.Stop()
+
+	for {
+		select {
+		case ev, ok := <-w.events:
+			if !ok {
+				return
+			}
+			if ev.overflow {
+				resync = true
+			} else {
+				pending[ev.path] = true
+			}
+			now := time.Now()
+			if first.IsZero() {
+				first = now
+			}
+			timer.Reset(max(0, min(watchDebounce, watchMaxDelay-now.Sub(first))))
+		case <-timer.C:
+			changes := changesFor(pending, resync)
+			pending = make(map[string]bool)
+			resync = false
+			first = time.Time{}
+			if len(changes) > 0 && ctx.Err() == nil {
+				fn(changes)
+			}
+		}
+	}
+}
+
+// changesFor turns a set of touched files into Changes. Every affected cache
+// entry is invalidated and the listing updated, but echoes of our own recent
+// writes are not reported: the daemon already emitted signals for those.
+func (s *GopassStore) changesFor(files map[string]bool, resync bool) []Change {
+	if resync {
+		s.invalidateMetaPrefix(s.mapper.prefix)
+		s.dropListing()
+		return []Change{{}}
+	}
+
+	sorted := make([]string, 0, len(files))
+	for f := range files {
+		sorted = append(sorted, f)
+	}
+	sort.Strings(sorted)
+
+	var changes []Change
+	touched := make(map[string]bool) // collections with any external change
+	for _, f := range sorted {
+		rel, err := filepath.Rel(s.dir, f)
+		if err != nil || strings.HasPrefix(rel, "..") {
+			continue
+		}
+		rel = filepath.ToSlash(rel)
+		coll, rest, _ := strings.Cut(rel, "/")
+		if strings.HasPrefix(coll, "_") || strings.HasPrefix(coll, ".") {
+			// _aliases and other store-level entries, dotfiles like .gpg-id
+			ext := path.Ext(rel)
+			entry := path.Join(s.mapper.prefix, strings.TrimSuffix(rel, ext))
+			s.invalidateMeta(entry)
+			if ext == ".gpg" || ext == ".age" {
+				s.listStat(entry, f)
+			}
+			continue
+		}
+
+		if strings.HasPrefix(path.Base(rel), ".") {
+			continue
+		}
+		ext := path.Ext(rel)
+		if ext != ".gpg" && ext != ".age" {
+			// A directory (or a stray non-entry file). Directory events matter
+			// when a whole subtree is moved, which produces no per-file events.
+			if s.isSelfWrite(path.Join(s.mapper.prefix, rel), true) {
+				continue
+			}
+			// The listing can't tell what moved in or out with it.
+			s.dropListing()
+			touched[coll] = true
+			if rest != "" || isDir(f) {
+				changes = append(changes, Change{Collection: coll})
+			}
+			continue
+		}
+
+		entry := path.Join(s.mapper.prefix, strings.TrimSuffix(rel, ext))
+		s.invalidateMeta(entry)
+		s.listStat(entry, f)
+		if s.isSelfWrite(entry, false) {
+			continue
+		}
+		_, id, err := s.mapper.ParsePath(entry)
+		if err != nil || id == "" {
+			continue
+		}
+		touched[coll] = true
+		switch {
+		case id == "_meta":
+			changes = append(changes, Change{Collection: coll})
+		case strings.HasPrefix(id, "_"):
+			// other internal entries
+		default:
+			_, statErr := os.Stat(f)
+			changes = append(changes, Change{Collection: coll, ItemID: s.mapper.ItemID(id), Removed: os.IsNotExist(statErr)})
+		}
+	}
+
+	for coll := range touched {
+		if !isDir(filepath.Join(s.dir, coll)) {
+			s.invalidateMetaPrefix(s.mapper.CollectionPath(coll))
+			s.listRemove(s.mapper.CollectionPath(coll))
+			changes = append(changes, Change{Collection: coll, Removed: true})
+		}
+	}
+	return changes
+}
+
+// listStat adds the entry to the listing or removes it from it, depending on
+// whether its file exists.
+func (s *GopassStore) listStat(entry, file string) {
+	if _, err := os.Stat(file); err == nil {
+		s.listAdd(entry)
+	} else if os.IsNotExist(err) {
+		s.listRemove(entry)
+	}
+}
+
+// noteWrite records that the daemon itself just changed path (an entry or,
+// for removals, a whole subtree) so the watcher can ignore the echo, and runs
+// the write hook.
+func (s *GopassStore) noteWrite(p string) {
+	s.cacheMu.Lock()
+	now := time.Now()
+	for k, t := range s.recentWrites {
+		if now.Sub(t) > selfWriteWindow {
+			delete(s.recentWrites, k)
+		}
+	}
+	s.recentWrites[p] = now
+	s.cacheMu.Unlock()
+
+	if s.onWrite != nil {
+		s.onWrite()
+	}
+}
+
+// isSelfWrite reports whether p, or a subtree containing it, was written by
+// the daemon within selfWriteWindow. A directory also counts as self-written
+// when we wrote anything beneath it, since gopass creates parent directories
+// as a side effect.
+func (s *GopassStore) isSelfWrite(p string, dir bool) bool {
+	s.cacheMu.RLock()
+	defer s.cacheMu.RUnlock()
+	for k, t := range s.recentWrites {
+		if time.Since(t) > selfWriteWindow {
+			continue
+		}
+		if k == p || strings.HasPrefix(p, k+"/") || (dir && strings.HasPrefix(k, p+"/")) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,147 @@
+package store
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/nikicat/gopass-secret-service/internal/gitsync"
+)
+
+// newWatchedStore returns a GopassStore whose prefix lives in a temp dir and a
+// channel receiving its change batches.
+func newWatchedStore(t *testing.T) (*GopassStore, string, <-chan []Change) {
+	t.Helper()
+	dir := t.TempDir()
+	s := newTestGopassStore(newFakeGopassStore())
+	s.SetDir(dir)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	batches := make(chan []Change, 16)
+	if err := s.Watch(ctx, func(c []Change) { batches <- c }); err != nil {
+		t.Fatalf("Watch: %v", err)
+	}
+	return s, dir, batches
+}
+
+func writeEntry(t *testing.T, p string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(p), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(p, []byte("ciphertext"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func nextBatch(t *testing.T, batches <-chan []Change) []Change {
+	t.Helper()
+	select {
+	case c := <-batches:
+		return c
+	case <-time.After(5 * time.Second):
+		t.Fatal("no change batch delivered")
+		return nil
+	}
+}
+
+func TestGopassStore_WatchReportsExternalChanges(t *testing.T) {
+	_, dir, batches := newWatchedStore(t)
+
+	entry := filepath.Join(dir, "default", "ext_item.gpg")
+	writeEntry(t, entry)
+	got := nextBatch(t, batches)
+	want := Change{Collection: "default", ItemID: "ext_item"}
+	if !containsChange(got, want) {
+		t.Errorf("changes = %+v, want %+v", got, want)
+	}
+
+	if err := os.Remove(entry); err != nil {
+		t.Fatal(err)
+	}
+	got = nextBatch(t, batches)
+	want = Change{Collection: "default", ItemID: "ext_item", Removed: true}
+	if !containsChange(got, want) {
+		t.Errorf("changes = %+v, want %+v", got, want)
+	}
+}
+
+func TestGopassStore_WatchPicksUpMovedInCollection(t *testing.T) {
+	_, dir, batches := newWatchedStore(t)
+
+	// A collection directory built elsewhere and renamed into place, as git
+	// does on checkout, produces a single event for the directory.
+	staging := t.TempDir()
+	writeEntry(t, filepath.Join(staging, "work", "a.gpg"))
+	if err := os.Rename(filepath.Join(staging, "work"), filepath.Join(dir, "work")); err != nil {
+		t.Fatal(err)
+	}
+	got := nextBatch(t, batches)
+	if !containsChange(got, Change{Collection: "work", ItemID: "a"}) {
+		t.Errorf("changes = %+v, want item work/a", got)
+	}
+
+	if err := os.RemoveAll(filepath.Join(dir, "work")); err != nil {
+		t.Fatal(err)
+	}
+	got = nextBatch(t, batches)
+	if !containsChange(got, Change{Collection: "work", Removed: true}) {
+		t.Errorf("changes = %+v, want collection work removed", got)
+	}
+}
+
+func TestGopassStore_WatchIgnoresOwnWrites(t *testing.T) {
+	s, dir, batches := newWatchedStore(t)
+	ctx := context.Background()
+
+	id, err := s.CreateItem(ctx, "default", &ItemData{Label: "own", Secret: []byte("x"), ContentType: "text/plain"})
+	if err != nil {
+		t.Fatalf("CreateItem: %v", err)
+	}
+	// The fake backend keeps entries in memory; write what gopass would.
+	writeEntry(t, filepath.Join(dir, "default", id+".gpg"))
+	writeEntry(t, filepath.Join(dir, "default", "ext_item.gpg"))
+
+	got := nextBatch(t, batches)
+	for _, c := range got {
+		if c.ItemID == id {
+			t.Errorf("own write reported as external change: %+v", got)
+		}
+	}
+	if !containsChange(got, Change{Collection: "default", ItemID: "ext_item"}) {
+		t.Errorf("changes = %+v, want ext_item", got)
+	}
+}
+
+func containsChange(changes []Change, want Change) bool {
+	for _, c := range changes {
+		if c == want {
+			return true
+		}
+	}
+	return false
+}
+
+func TestGopassStore_SetAliasSchedulesGitPush(t *testing.T) {
+	dir := t.TempDir()
+	s := newTestGopassStore(newFakeGopassStore())
+	s.SetDir(dir)
+	syncer := gitsync.New([]string{dir}, gitsync.Options{PushDelay: time.Hour})
+	s.SetWriteHook(func() { syncer.Notify(dir) })
+
+	if err := s.SetAlias(context.Background(), "login", "personal"); err != nil {
+		t.Fatalf("SetAlias: %v", err)
+	}
+	if st := syncer.Status()[0]; !st.Pending {
+		t.Errorf("no push scheduled after SetAlias: %+v", st)
+	}
+	s.cacheMu.RLock()
+	_, own := s.recentWrites[s.mapper.AliasesPath()]
+	s.cacheMu.RUnlock()
+	if !own {
+		t.Error("alias table write not recorded as the daemon's own")
+	}
+}